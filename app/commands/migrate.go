@@ -35,6 +35,23 @@ func withMigrator(action func(migrator *database.Migrator)) {
 	migrator.Register(migrations.NewCreateUserRolesTable())
 	migrator.Register(migrations.NewCreateRolePermissionsTable())
 	migrator.Register(migrations.NewCreateUserPermissionsTable())
+	migrator.Register(migrations.NewAddPersonalAccessColumnsToOAuthTokensTable())
+	migrator.Register(migrations.NewCreateSessionsTable())
+	migrator.Register(migrations.NewAddDeviceColumnsToOAuthTokensTable())
+	migrator.Register(migrations.NewCreateRateLimitsTable())
+	migrator.Register(migrations.NewCreateSecurityEventsTable())
+	migrator.Register(migrations.NewAddTwoFactorColumnsToUsersTable())
+	migrator.Register(migrations.NewAddRequiresTwoFactorToRolesTable())
+	migrator.Register(migrations.NewCreateWebAuthnCredentialsTable())
+	migrator.Register(migrations.NewCreateUserIdentitiesTable())
+	migrator.Register(migrations.NewCreateCacheVersionsTable())
+	migrator.Register(migrations.NewCreateRoleParentsTable())
+	migrator.Register(migrations.NewCreateTeamsTable())
+	migrator.Register(migrations.NewCreateTeamMembersTable())
+	migrator.Register(migrations.NewCreateTeamInvitationsTable())
+	migrator.Register(migrations.NewAddTeamIDToUserRolesAndPermissionsTables())
+	migrator.Register(migrations.NewAddValidityWindowToUserRolesAndPermissionsTables())
+	migrator.Register(migrations.NewAddSessionEpochToUsersTable())
 
 	action(migrator)
 }
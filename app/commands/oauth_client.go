@@ -14,6 +14,12 @@ var OauthClientCmd = &cobra.Command{
 	Use:   "oauth:client",
 	Short: "Crea un cliente OAuth en la base de datos",
 	Run: func(cmd *cobra.Command, args []string) {
+		personal, _ := cmd.Flags().GetBool("personal")
+		if personal {
+			createPersonalAccessClient(args)
+			return
+		}
+
 		name := "Default Client"
 		if len(args) > 0 {
 			name = args[0]
@@ -32,6 +38,31 @@ var OauthClientCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	OauthClientCmd.Flags().Bool("personal", false, "Crea el cliente para tokens de acceso personal")
+}
+
+// createPersonalAccessClient crea el cliente que emite los tokens de acceso personal
+func createPersonalAccessClient(args []string) {
+	if client, err := models.GetPersonalAccessClient(); err == nil {
+		fmt.Println("Ya existe un cliente de acceso personal:", client.Name)
+		return
+	}
+
+	name := "Personal Access Client"
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	client, err := models.CreatePersonalAccessClient(name)
+	if err != nil {
+		fmt.Println("Error creando el cliente de acceso personal:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Cliente de acceso personal creado correctamente:")
+	fmt.Println("ID:", client.ClientID)
+}
+
 func randomHex(n int) string {
 	b := make([]byte, n)
 	_, err := rand.Read(b)
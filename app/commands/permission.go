@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"semita/app/models"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var PermissionCacheResetCmd = &cobra.Command{
+	Use:   "permission:cache-reset",
+	Short: "Invalida la caché de roles y permisos de los usuarios",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Con el driver memory cada proceso tiene su propia caché, que este comando no alcanza
+		if !models.PermissionCacheShared() {
+			fmt.Println("PERMISSION_CACHE_DRIVER no es database: la caché vive en memoria de cada instancia y se vacía al reiniciarla")
+			return
+		}
+
+		models.FlushPermissionCache()
+		fmt.Println("Caché de permisos invalidada en todas las instancias")
+	},
+}
+
+var PermissionExpiringCmd = &cobra.Command{
+	Use:   "permission:expiring",
+	Short: "Lista las asignaciones de roles y permisos a usuarios que vencen pronto",
+	Run: func(cmd *cobra.Command, args []string) {
+		within, _ := cmd.Flags().GetDuration("within")
+
+		grants, err := models.GetExpiringGrants(within)
+		if err != nil {
+			log.Fatal("Error consultando las asignaciones por vencer:", err)
+		}
+		if len(grants) == 0 {
+			fmt.Printf("No hay asignaciones que venzan en los próximos %s\n", within)
+			return
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VENCE\tTIPO\tNOMBRE\tGUARD\tUSUARIO\tEQUIPO")
+		for _, grant := range grants {
+			team := "-"
+			if grant.TeamID != 0 {
+				team = fmt.Sprint(grant.TeamID)
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", grant.ExpiresAt, grant.Type, grant.Name, grant.GuardName, grant.UserEmail, team)
+		}
+		writer.Flush()
+	},
+}
+
+var PermissionExpireCmd = &cobra.Command{
+	Use:   "permission:expire",
+	Short: "Elimina las asignaciones de roles y permisos vencidas y registra su vencimiento",
+	Run: func(cmd *cobra.Command, args []string) {
+		expired, err := models.ExpireLapsedGrants()
+		if err != nil {
+			log.Fatal("Error eliminando las asignaciones vencidas:", err)
+		}
+		fmt.Printf("Se eliminaron %d asignaciones de roles y permisos vencidas\n", expired)
+	},
+}
+
+func init() {
+	PermissionExpiringCmd.Flags().Duration("within", 7*24*time.Hour, "Plazo en el que vencen las asignaciones listadas")
+}
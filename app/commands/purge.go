@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"semita/app/core/throttle"
+	"semita/app/models"
+	"semita/app/utils"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var OauthPurgeCmd = &cobra.Command{
+	Use:   "oauth:purge",
+	Short: "Elimina los tokens OAuth revocados y expirados",
+	Run: func(cmd *cobra.Command, args []string) {
+		revoked, _ := cmd.Flags().GetBool("revoked")
+		expired, _ := cmd.Flags().GetBool("expired")
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+		batchSize, _ := cmd.Flags().GetInt("batch")
+
+		// Sin filtros se eliminan ambos tipos, igual que Passport
+		if !revoked && !expired {
+			revoked, expired = true, true
+		}
+
+		deleted, err := models.PurgeTokens(revoked, expired, olderThan, batchSize)
+		if err != nil {
+			log.Fatal("Error eliminando tokens OAuth:", err)
+		}
+		fmt.Printf("Se eliminaron %d tokens OAuth (revocados: %t, expirados: %t, más antiguos que %s)\n", deleted, revoked, expired, olderThan)
+	},
+}
+
+var AuthClearResetsCmd = &cobra.Command{
+	Use:   "auth:clear-resets",
+	Short: "Elimina los tokens de restablecimiento de contraseña expirados",
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+		batchSize, _ := cmd.Flags().GetInt("batch")
+
+		deleted, err := models.PurgePasswordResets(olderThan, batchSize)
+		if err != nil {
+			log.Fatal("Error eliminando tokens de restablecimiento:", err)
+		}
+		fmt.Printf("Se eliminaron %d tokens de restablecimiento de contraseña expirados\n", deleted)
+	},
+}
+
+var SessionGcCmd = &cobra.Command{
+	Use:   "session:gc",
+	Short: "Elimina las sesiones expiradas de los drivers file y database",
+	Run: func(cmd *cobra.Command, args []string) {
+		deleted, err := utils.CollectSessionGarbage()
+		if err != nil {
+			log.Fatal("Error eliminando sesiones expiradas:", err)
+		}
+		fmt.Printf("Se eliminaron %d sesiones expiradas\n", deleted)
+	},
+}
+
+var ThrottleGcCmd = &cobra.Command{
+	Use:   "throttle:gc",
+	Short: "Elimina los contadores de intentos vencidos",
+	Run: func(cmd *cobra.Command, args []string) {
+		deleted, err := throttle.GC()
+		if err != nil {
+			log.Fatal("Error eliminando contadores de intentos:", err)
+		}
+		fmt.Printf("Se eliminaron %d contadores de intentos vencidos\n", deleted)
+	},
+}
+
+func init() {
+	OauthPurgeCmd.Flags().Bool("revoked", false, "Solo elimina los tokens revocados")
+	OauthPurgeCmd.Flags().Bool("expired", false, "Solo elimina los tokens expirados")
+	OauthPurgeCmd.Flags().Duration("older-than", 72*time.Hour, "Antigüedad mínima desde la revocación o expiración")
+	OauthPurgeCmd.Flags().Int("batch", 1000, "Cantidad de registros eliminados por lote")
+
+	AuthClearResetsCmd.Flags().Duration("older-than", 0, "Tiempo adicional tras la expiración antes de eliminar")
+	AuthClearResetsCmd.Flags().Int("batch", 1000, "Cantidad de registros eliminados por lote")
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"semita/app/core/rbac"
+
+	"github.com/spf13/cobra"
+)
+
+var RbacExportCmd = &cobra.Command{
+	Use:   "rbac:export",
+	Short: "Exporta los roles, los permisos y sus asignaciones como manifiesto YAML o JSON",
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+
+		manifest, err := rbac.Export()
+		if err != nil {
+			log.Fatal("Error leyendo los roles y permisos:", err)
+		}
+		if err = manifest.Encode(os.Stdout, format); err != nil {
+			log.Fatal("Error escribiendo el manifiesto:", err)
+		}
+	},
+}
+
+var RbacApplyCmd = &cobra.Command{
+	Use:   "rbac:apply [archivo]",
+	Short: "Sincroniza los roles y permisos de la base de datos con un manifiesto YAML o JSON",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prune, _ := cmd.Flags().GetBool("prune")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		desired, err := rbac.Load(args[0])
+		if err != nil {
+			log.Fatal("Manifiesto inválido: ", err)
+		}
+		current, err := rbac.Export()
+		if err != nil {
+			log.Fatal("Error leyendo los roles y permisos:", err)
+		}
+
+		changes, err := rbac.Plan(current, desired, prune)
+		if err != nil {
+			log.Fatal("Manifiesto inválido: ", err)
+		}
+		if len(changes) == 0 {
+			fmt.Println("Los roles y permisos ya coinciden con el manifiesto")
+			return
+		}
+
+		counts := map[string]int{}
+		for _, change := range changes {
+			fmt.Println(change)
+			counts[change.Action]++
+		}
+		fmt.Printf("\nPlan: %d a crear, %d a actualizar, %d a asignar, %d a quitar, %d a eliminar\n",
+			counts[rbac.ActionCreate], counts[rbac.ActionUpdate], counts[rbac.ActionAttach],
+			counts[rbac.ActionDetach], counts[rbac.ActionDelete])
+
+		if dryRun {
+			fmt.Println("Modo --dry-run: no se aplicó ningún cambio")
+			return
+		}
+
+		applied, err := rbac.Apply(changes)
+		if err != nil {
+			log.Fatalf("Error tras aplicar %d de %d cambios: %v", applied, len(changes), err)
+		}
+		fmt.Printf("Se aplicaron %d cambios\n", applied)
+	},
+}
+
+func init() {
+	RbacExportCmd.Flags().String("format", "yaml", "Formato del manifiesto: yaml o json")
+
+	RbacApplyCmd.Flags().Bool("prune", false, "Elimina los roles, permisos y asignaciones que no están en el manifiesto")
+	RbacApplyCmd.Flags().Bool("dry-run", false, "Muestra el plan sin aplicar cambios")
+}
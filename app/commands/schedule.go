@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"semita/app/core/throttle"
+	"semita/app/models"
+	"semita/app/utils"
+	"time"
+)
+
+// scheduledTask es una tarea de mantenimiento que el servidor ejecuta periódicamente
+type scheduledTask struct {
+	Name string
+	Run  func() error
+}
+
+// scheduledTasks devuelve las tareas que se ejecutan en cada intervalo del planificador
+func scheduledTasks() []scheduledTask {
+	return []scheduledTask{
+		{
+			Name: "oauth:purge",
+			Run: func() error {
+				_, err := models.PurgeTokens(true, true, envDuration("OAUTH_PURGE_OLDER_THAN", 72*time.Hour), 1000)
+				return err
+			},
+		},
+		{
+			Name: "auth:clear-resets",
+			Run: func() error {
+				_, err := models.PurgePasswordResets(0, 1000)
+				return err
+			},
+		},
+		{
+			Name: "session:gc",
+			Run: func() error {
+				_, err := utils.CollectSessionGarbage()
+				return err
+			},
+		},
+		{
+			Name: "throttle:gc",
+			Run: func() error {
+				_, err := throttle.GC()
+				return err
+			},
+		},
+		{
+			Name: "permission:expire",
+			Run: func() error {
+				_, err := models.ExpireLapsedGrants()
+				return err
+			},
+		},
+	}
+}
+
+// startScheduler ejecuta las tareas de mantenimiento cada SCHEDULE_INTERVAL (p. ej. "1h").
+// Si la variable no está definida el planificador queda desactivado.
+func startScheduler() {
+	interval := envDuration("SCHEDULE_INTERVAL", 0)
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			for _, task := range scheduledTasks() {
+				if err := task.Run(); err != nil {
+					utils.Logs("ERROR", fmt.Sprintf("Error ejecutando la tarea programada %s: %v", task.Name, err))
+				}
+			}
+		}
+	}()
+
+	fmt.Printf("Planificador de tareas activo cada %s\n", interval)
+}
+
+// envDuration lee una duración de una variable de entorno, con valor por defecto
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Duración inválida en %s: %v", key, err))
+		return fallback
+	}
+	return duration
+}
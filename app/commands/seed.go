@@ -39,6 +39,7 @@ func createSeederManager() *database.SeederManager {
 	manager.RegisterSeeder(seeders.NewRolesPermissionsSeeder())
 	manager.RegisterSeeder(seeders.NewCategoriesSeeder())
 	manager.RegisterSeeder(seeders.NewUsersSeeder())
+	manager.RegisterSeeder(seeders.NewOAuthScopesSeeder())
 
 	return manager
 }
@@ -4,15 +4,22 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	_ "semita/app/core/session" // Registra el driver de sesión database
 	"semita/app/http/controllers/web"
+	_ "semita/app/policies" // Registra las policies y gates de autorización
 	"semita/app/utils"
 	"semita/routes"
 	"time"
 )
 
 func StartServer() {
-	// Cargar variables de entorno
-	var appUrl = utils.GetEnv("APP_URL")
+	// Sin APP_KEY las sesiones, las URLs firmadas y los mfa_token quedarían firmados con una llave vacía
+	if _, err := utils.AppKey(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Cargar variables de entorno; APP_URL puede incluir el esquema
+	var appUrl = utils.AppAddress()
 
 	// Inicializar el enrutador Gin
 	router := routes.Web()
@@ -21,12 +28,18 @@ func StartServer() {
 	apiGroup := router.Group("/api/v1")
 	routes.Api(apiGroup)
 
+	// Montar endpoints OAuth2 / OpenID Connect
+	routes.OAuth(router)
+
 	// Archivos estáticos
 	router.Static("/public", "./public")
 
 	// Ruta 404 personalizada
 	router.NoRoute(web.Error404)
 
+	// Tareas de mantenimiento periódicas (purga de tokens, etc.)
+	startScheduler()
+
 	// Ejecución del servidor
 	server := &http.Server{
 		Addr:         appUrl,
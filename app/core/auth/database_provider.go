@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"semita/app/models"
+	"semita/app/structs"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DatabaseProvider valida el email y la contraseña (bcrypt) contra la tabla users
+type DatabaseProvider struct{}
+
+// Name devuelve el nombre del proveedor
+func (DatabaseProvider) Name() string {
+	return "database"
+}
+
+// Authenticate busca al usuario por email y compara la contraseña con su hash
+func (DatabaseProvider) Authenticate(_ context.Context, credentials Credentials) (structs.UserStruct, error) {
+	user, err := models.GetUserByEmail(credentials.Login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return structs.UserStruct{}, ErrUserNotFound
+	}
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(credentials.Password)) != nil {
+		return user, ErrInvalidCredentials
+	}
+	return user, nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Nombres de los guards incluidos. Coinciden con los valores de guard_name de roles y permisos.
+const (
+	GuardWeb = "web"
+	GuardAPI = "api"
+)
+
+// GuardDriver resuelve el usuario autenticado de una petición
+type GuardDriver interface {
+	// Name es el nombre del guard y el guard_name de sus roles y permisos
+	Name() string
+	// User devuelve el usuario autenticado por este guard
+	User(context *gin.Context) (structs.UserStruct, bool)
+	// Stateless indica que el guard responde con JSON en lugar de redirigir
+	Stateless() bool
+	// PermissionGuards son los guard_name cuyos roles y permisos aplican a este guard
+	PermissionGuards() []string
+}
+
+// SessionGuard autentica con la cookie de sesión del login web
+type SessionGuard struct{}
+
+func (SessionGuard) Name() string { return GuardWeb }
+
+// User carga de la base de datos al usuario de la sesión, que solo guarda su ID, nombre y email
+func (SessionGuard) User(context *gin.Context) (structs.UserStruct, bool) {
+	sessionUser, authenticated := utils.GetAuthenticatedUser(context.Request)
+	if !authenticated {
+		return structs.UserStruct{}, false
+	}
+	user, err := models.GetUserByID(strconv.Itoa(sessionUser.ID))
+	if err != nil {
+		return structs.UserStruct{}, false
+	}
+	return user, true
+}
+
+func (SessionGuard) Stateless() bool { return false }
+
+func (SessionGuard) PermissionGuards() []string { return []string{GuardWeb} }
+
+// TokenGuard autentica con el token Bearer validado por middleware.AuthMiddleware, que deja el
+// user_id en el contexto de la petición
+type TokenGuard struct{}
+
+func (TokenGuard) Name() string { return GuardAPI }
+
+func (TokenGuard) User(context *gin.Context) (structs.UserStruct, bool) {
+	userID := context.GetString("user_id")
+	if userID == "" {
+		return structs.UserStruct{}, false
+	}
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		return structs.UserStruct{}, false
+	}
+	return user, true
+}
+
+func (TokenGuard) Stateless() bool { return true }
+
+// Solo aplican los roles y permisos con guard_name api: los de la web no autorizan peticiones con
+// token. Lo que deba valer en ambos se asigna en cada guard.
+func (TokenGuard) PermissionGuards() []string { return []string{GuardAPI} }
+
+var (
+	guardsMutex sync.RWMutex
+	guards      = map[string]GuardDriver{
+		GuardWeb: SessionGuard{},
+		GuardAPI: TokenGuard{},
+	}
+)
+
+// RegisterGuard agrega o reemplaza un guard; debe llamarse al arrancar
+func RegisterGuard(guard GuardDriver) {
+	guardsMutex.Lock()
+	defer guardsMutex.Unlock()
+	guards[guard.Name()] = guard
+}
+
+// GetGuard busca un guard por nombre
+func GetGuard(name string) (GuardDriver, bool) {
+	guardsMutex.RLock()
+	defer guardsMutex.RUnlock()
+	guard, ok := guards[name]
+	return guard, ok
+}
+
+// ResolveGuard devuelve el guard de la petición: el de token si AuthMiddleware autenticó un Bearer,
+// y el de sesión en otro caso
+func ResolveGuard(context *gin.Context) GuardDriver {
+	if context.GetString("user_id") != "" {
+		if guard, ok := GetGuard(GuardAPI); ok {
+			return guard
+		}
+	}
+	guard, _ := GetGuard(GuardWeb)
+	return guard
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// identityKey es la clave del contexto donde se guarda la identidad resuelta de la petición
+const identityKey = "auth_identity"
+
+// Identity es el usuario autenticado de una petición con sus roles y permisos ya cargados
+type Identity struct {
+	User        structs.UserStruct
+	Guard       GuardDriver
+	Team        *structs.TeamStruct // Equipo actual; nil si no hay o el usuario no es miembro
+	Roles       []structs.RoleStruct
+	Permissions []structs.PermissionStruct // Directos y heredados de sus roles, globales y del equipo
+}
+
+// resolvedIdentity es lo que se guarda en el contexto; identity es nil para los invitados
+type resolvedIdentity struct {
+	identity *Identity
+	err      error
+}
+
+// Resolve devuelve la identidad de la petición, cargándola la primera vez con el guard que
+// corresponda y guardándola en el contexto para el resto de la petición. Devuelve nil si no hay
+// usuario autenticado; el error indica que no se pudieron cargar sus roles o permisos.
+func Resolve(context *gin.Context) (*Identity, error) {
+	if cached, ok := context.Get(identityKey); ok {
+		if resolved, ok := cached.(resolvedIdentity); ok {
+			return resolved.identity, resolved.err
+		}
+	}
+
+	resolved := load(context)
+	context.Set(identityKey, resolved)
+	return resolved.identity, resolved.err
+}
+
+func load(context *gin.Context) resolvedIdentity {
+	guard := ResolveGuard(context)
+	user, ok := guard.User(context)
+	if !ok {
+		return resolvedIdentity{}
+	}
+
+	identity := &Identity{User: user, Guard: guard}
+	if teamID := TeamID(context); teamID != 0 {
+		// Un equipo al que el usuario no pertenece se ignora: solo quedan sus asignaciones globales
+		team, err := models.GetUserTeam(teamID, user.ID)
+		if err == nil {
+			identity.Team = team
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			utils.Logs("ERROR", "No se pudo cargar el equipo del usuario: "+err.Error())
+		}
+	}
+
+	roles, permissions, err := models.GetUserRolesAndPermissions(user.ID, identity.TeamID())
+	if err != nil {
+		utils.Logs("ERROR", "No se pudieron cargar los roles y permisos del usuario: "+err.Error())
+		return resolvedIdentity{identity: identity, err: err}
+	}
+
+	identity.Roles = roles
+	identity.Permissions = permissions
+	return resolvedIdentity{identity: identity}
+}
+
+// Forget descarta la identidad guardada; debe llamarse si la petición inicia o cierra la sesión
+// después de haberla resuelto
+func Forget(context *gin.Context) {
+	context.Set(identityKey, nil)
+}
+
+// User devuelve el usuario autenticado de la petición, por sesión o por token
+func User(context *gin.Context) (structs.UserStruct, bool) {
+	identity, _ := Resolve(context)
+	if identity == nil {
+		return structs.UserStruct{}, false
+	}
+	return identity.User, true
+}
+
+// ID devuelve el ID del usuario autenticado, o 0 si no hay
+func ID(context *gin.Context) int {
+	user, _ := User(context)
+	return user.ID
+}
+
+// Check indica si la petición tiene un usuario autenticado
+func Check(context *gin.Context) bool {
+	_, ok := User(context)
+	return ok
+}
+
+// Guard devuelve el nombre del guard de la petición, aunque no haya usuario autenticado
+func Guard(context *gin.Context) string {
+	return ResolveGuard(context).Name()
+}
+
+// HasRole indica si el usuario de la petición tiene el rol. Sin guard explícito se usan los
+// guard_name que acepta el guard de la petición.
+func HasRole(context *gin.Context, roleName string, guardName ...string) bool {
+	identity, _ := Resolve(context)
+	return identity.HasRole(roleName, guardName...)
+}
+
+// HasPermission indica si el usuario de la petición tiene el permiso, directo o por un rol
+func HasPermission(context *gin.Context, permissionName string, guardName ...string) bool {
+	identity, _ := Resolve(context)
+	return identity.HasPermission(permissionName, guardName...)
+}
+
+// TeamID devuelve el ID del equipo actual de la identidad, o 0 si no hay
+func (identity *Identity) TeamID() int {
+	if identity == nil || identity.Team == nil {
+		return 0
+	}
+	return identity.Team.ID
+}
+
+// HasRole indica si la identidad tiene el rol en alguno de los guards
+func (identity *Identity) HasRole(roleName string, guardName ...string) bool {
+	if identity == nil {
+		return false
+	}
+	guards := identity.permissionGuards(guardName)
+	for _, role := range identity.Roles {
+		if role.Name == roleName && slices.Contains(guards, role.GuardName) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyRole indica si la identidad tiene al menos uno de los roles
+func (identity *Identity) HasAnyRole(roleNames []string, guardName ...string) bool {
+	for _, roleName := range roleNames {
+		if identity.HasRole(roleName, guardName...) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllRoles indica si la identidad tiene todos los roles
+func (identity *Identity) HasAllRoles(roleNames []string, guardName ...string) bool {
+	if identity == nil {
+		return false
+	}
+	for _, roleName := range roleNames {
+		if !identity.HasRole(roleName, guardName...) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPermission indica si la identidad tiene el permiso en alguno de los guards, directamente o
+// por un comodín como "users.*"
+func (identity *Identity) HasPermission(permissionName string, guardName ...string) bool {
+	if identity == nil {
+		return false
+	}
+	guards := identity.permissionGuards(guardName)
+	for _, permission := range identity.Permissions {
+		if slices.Contains(guards, permission.GuardName) && models.PermissionMatches(permission.Name, permissionName) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyPermission indica si la identidad tiene al menos uno de los permisos
+func (identity *Identity) HasAnyPermission(permissionNames []string, guardName ...string) bool {
+	for _, permissionName := range permissionNames {
+		if identity.HasPermission(permissionName, guardName...) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllPermissions indica si la identidad tiene todos los permisos
+func (identity *Identity) HasAllPermissions(permissionNames []string, guardName ...string) bool {
+	if identity == nil {
+		return false
+	}
+	for _, permissionName := range permissionNames {
+		if !identity.HasPermission(permissionName, guardName...) {
+			return false
+		}
+	}
+	return true
+}
+
+// RoleNames devuelve los nombres de los roles de la identidad
+func (identity *Identity) RoleNames() []string {
+	if identity == nil {
+		return nil
+	}
+	names := make([]string, len(identity.Roles))
+	for i, role := range identity.Roles {
+		names[i] = role.Name
+	}
+	return names
+}
+
+// PermissionNames devuelve los nombres de los permisos de la identidad
+func (identity *Identity) PermissionNames() []string {
+	if identity == nil {
+		return nil
+	}
+	names := make([]string, len(identity.Permissions))
+	for i, permission := range identity.Permissions {
+		names[i] = permission.Name
+	}
+	return names
+}
+
+// permissionGuards devuelve solo el guard explícito o, si no hay, los que acepta el guard de la identidad
+func (identity *Identity) permissionGuards(guardName []string) []string {
+	if len(guardName) > 0 && guardName[0] != "" {
+		return []string{guardName[0]}
+	}
+	return identity.Guard.PermissionGuards()
+}
@@ -0,0 +1,394 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LDAPConfig es la configuración del proveedor LDAP / Active Directory
+type LDAPConfig struct {
+	URL                string // ldap://host:389 o ldaps://host:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string // Cuenta de servicio para buscar usuarios; vacío usa bind anónimo
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // {login} se reemplaza por el login escapado
+	EmailAttribute     string
+	NameAttribute      string
+	GroupAttribute     string            // Atributo del usuario con sus grupos (memberOf en AD)
+	GroupBaseDN        string            // Si se define, los grupos se buscan con GroupFilter
+	GroupFilter        string            // {dn} se reemplaza por el DN del usuario escapado
+	GroupRoles         map[string]string // DN del grupo en minúsculas => nombre del rol
+	Provision          bool              // Crear el usuario local en su primer login
+	Timeout            time.Duration
+
+	// Dial abre la conexión; por defecto ldap.DialURL con Timeout. Se puede reemplazar en pruebas.
+	Dial func(url string) (ldap.Client, error)
+}
+
+// LDAPConfigFromEnv lee la configuración de las variables LDAP_*
+func LDAPConfigFromEnv() LDAPConfig {
+	config := LDAPConfig{
+		URL:                os.Getenv("LDAP_URL"),
+		StartTLS:           os.Getenv("LDAP_START_TLS") == "true",
+		InsecureSkipVerify: os.Getenv("LDAP_INSECURE_SKIP_VERIFY") == "true",
+		BindDN:             os.Getenv("LDAP_BIND_DN"),
+		BindPassword:       os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:             os.Getenv("LDAP_BASE_DN"),
+		UserFilter:         envOrDefault("LDAP_USER_FILTER", "(&(objectClass=person)(mail={login}))"),
+		EmailAttribute:     envOrDefault("LDAP_EMAIL_ATTRIBUTE", "mail"),
+		NameAttribute:      envOrDefault("LDAP_NAME_ATTRIBUTE", "displayName"),
+		GroupAttribute:     envOrDefault("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+		GroupBaseDN:        os.Getenv("LDAP_GROUP_BASE_DN"),
+		GroupFilter:        envOrDefault("LDAP_GROUP_FILTER", "(&(objectClass=groupOfNames)(member={dn}))"),
+		GroupRoles:         ParseGroupRoles(os.Getenv("LDAP_GROUP_ROLES")),
+		Provision:          os.Getenv("LDAP_PROVISION") != "false",
+		Timeout:            5 * time.Second,
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("LDAP_TIMEOUT")); err == nil && seconds > 0 {
+		config.Timeout = time.Duration(seconds) * time.Second
+	}
+	return config
+}
+
+// ParseGroupRoles interpreta LDAP_GROUP_ROLES: pares "DN del grupo:rol" separados por punto y coma
+func ParseGroupRoles(value string) map[string]string {
+	roles := map[string]string{}
+	for _, pair := range strings.Split(value, ";") {
+		separator := strings.LastIndex(pair, ":")
+		if separator < 0 {
+			continue
+		}
+		group := strings.ToLower(strings.TrimSpace(pair[:separator]))
+		role := strings.TrimSpace(pair[separator+1:])
+		if group != "" && role != "" {
+			roles[group] = role
+		}
+	}
+	return roles
+}
+
+func envOrDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// LDAPProvider autentica contra un directorio LDAP: busca al usuario con la cuenta de servicio,
+// hace bind con su DN y su contraseña, y sincroniza el usuario local y sus roles
+type LDAPProvider struct {
+	config LDAPConfig
+	store  ldapLocalStore
+}
+
+// ldapLocalStore son las operaciones sobre los usuarios y roles locales que hace el proveedor
+type ldapLocalStore interface {
+	GetUserByEmail(email string) (structs.UserStruct, error)
+	StoreUser(user structs.StoreUserStruct) error
+	MarkEmailVerified(userID int) error
+	GetRoleByName(name string, guardName string) (*structs.RoleStruct, error)
+	UserHasRole(userID int, roleID int) (bool, error)
+	AssignRoleToUser(userID int, roleID int) error
+	RevokeRoleFromUser(userID int, roleID int) error
+}
+
+// modelsLocalStore guarda los usuarios y roles locales en la base de datos con los modelos
+type modelsLocalStore struct{}
+
+func (modelsLocalStore) GetUserByEmail(email string) (structs.UserStruct, error) {
+	return models.GetUserByEmail(email)
+}
+
+func (modelsLocalStore) StoreUser(user structs.StoreUserStruct) error {
+	return models.StoreUser(user)
+}
+
+func (modelsLocalStore) MarkEmailVerified(userID int) error {
+	return models.MarkEmailVerified(userID)
+}
+
+func (modelsLocalStore) GetRoleByName(name string, guardName string) (*structs.RoleStruct, error) {
+	return models.GetRoleByName(name, guardName)
+}
+
+func (modelsLocalStore) UserHasRole(userID int, roleID int) (bool, error) {
+	return models.UserHasRole(userID, roleID)
+}
+
+func (modelsLocalStore) AssignRoleToUser(userID int, roleID int) error {
+	return models.AssignRoleToUser(userID, roleID)
+}
+
+func (modelsLocalStore) RevokeRoleFromUser(userID int, roleID int) error {
+	return models.RevokeRoleFromUser(userID, roleID)
+}
+
+// NewLDAPProvider valida la configuración y crea el proveedor
+func NewLDAPProvider(config LDAPConfig) (*LDAPProvider, error) {
+	if config.URL == "" || config.BaseDN == "" {
+		return nil, errors.New("LDAP_URL y LDAP_BASE_DN son obligatorias")
+	}
+	if !strings.Contains(config.UserFilter, "{login}") {
+		return nil, errors.New("LDAP_USER_FILTER debe contener {login}")
+	}
+	provider := &LDAPProvider{config: config, store: modelsLocalStore{}}
+	if provider.config.Dial == nil {
+		provider.config.Dial = provider.dial
+	}
+	return provider, nil
+}
+
+// Name devuelve el nombre del proveedor
+func (p *LDAPProvider) Name() string {
+	return "ldap"
+}
+
+// ldapUser son los datos del usuario leídos del directorio
+type ldapUser struct {
+	DN     string
+	Email  string
+	Name   string
+	Groups []string
+}
+
+// Authenticate valida las credenciales en el directorio y devuelve el usuario local
+func (p *LDAPProvider) Authenticate(_ context.Context, credentials Credentials) (structs.UserStruct, error) {
+	directoryUser, err := p.lookup(credentials)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	user, err := p.localUser(directoryUser)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	if len(p.config.GroupRoles) > 0 {
+		if err := p.syncRoles(user.ID, directoryUser.Groups); err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error sincronizando los roles LDAP del usuario %d: %v", user.ID, err))
+		}
+	}
+	return user, nil
+}
+
+// lookup busca al usuario, verifica su contraseña con un bind y lee sus grupos
+func (p *LDAPProvider) lookup(credentials Credentials) (ldapUser, error) {
+	conn, err := p.config.Dial(p.config.URL)
+	if err != nil {
+		return ldapUser{}, err
+	}
+	defer conn.Close()
+
+	if p.config.StartTLS {
+		if err := conn.StartTLS(p.tlsConfig()); err != nil {
+			return ldapUser{}, err
+		}
+	}
+
+	if err := p.bindService(conn); err != nil {
+		return ldapUser{}, err
+	}
+
+	attributes := []string{p.config.EmailAttribute, p.config.NameAttribute}
+	if p.config.GroupAttribute != "" {
+		attributes = append(attributes, p.config.GroupAttribute)
+	}
+	filter := strings.ReplaceAll(p.config.UserFilter, "{login}", ldap.EscapeFilter(credentials.Login))
+	result, err := conn.Search(ldap.NewSearchRequest(p.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(p.config.Timeout.Seconds()), false, filter, attributes, nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return ldapUser{}, err
+	}
+	if result == nil || len(result.Entries) == 0 {
+		return ldapUser{}, ErrUserNotFound
+	}
+	if len(result.Entries) > 1 {
+		return ldapUser{}, fmt.Errorf("el filtro LDAP devolvió más de un usuario para %q", credentials.Login)
+	}
+	entry := result.Entries[0]
+
+	// Un bind con contraseña vacía es un bind no autenticado y el servidor lo acepta: Attempt ya lo descarta
+	if err := conn.Bind(entry.DN, credentials.Password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return ldapUser{}, ErrInvalidCredentials
+		}
+		return ldapUser{}, err
+	}
+
+	directoryUser := ldapUser{
+		DN:    entry.DN,
+		Email: entry.GetAttributeValue(p.config.EmailAttribute),
+		Name:  entry.GetAttributeValue(p.config.NameAttribute),
+	}
+	if p.config.GroupAttribute != "" {
+		directoryUser.Groups = entry.GetAttributeValues(p.config.GroupAttribute)
+	}
+
+	if p.config.GroupBaseDN != "" {
+		// Los grupos se buscan con la cuenta de servicio, que puede tener más permisos de lectura
+		if err := p.bindService(conn); err != nil {
+			return ldapUser{}, err
+		}
+		groups, err := p.searchGroups(conn, entry.DN)
+		if err != nil {
+			return ldapUser{}, err
+		}
+		directoryUser.Groups = append(directoryUser.Groups, groups...)
+	}
+
+	if directoryUser.Email == "" {
+		return ldapUser{}, fmt.Errorf("el usuario %s no tiene el atributo %s", entry.DN, p.config.EmailAttribute)
+	}
+	return directoryUser, nil
+}
+
+// bindService hace bind con la cuenta de servicio, o anónimo si no está configurada
+func (p *LDAPProvider) bindService(conn ldap.Client) error {
+	if p.config.BindDN == "" {
+		return conn.UnauthenticatedBind("")
+	}
+	return conn.Bind(p.config.BindDN, p.config.BindPassword)
+}
+
+// searchGroups busca los grupos que tienen al usuario como miembro
+func (p *LDAPProvider) searchGroups(conn ldap.Client, userDN string) ([]string, error) {
+	filter := strings.ReplaceAll(p.config.GroupFilter, "{dn}", ldap.EscapeFilter(userDN))
+	result, err := conn.Search(ldap.NewSearchRequest(p.config.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(p.config.Timeout.Seconds()), false, filter, []string{"dn"}, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		groups = append(groups, entry.DN)
+	}
+	return groups, nil
+}
+
+// localUser devuelve el usuario local con el email del directorio, creándolo si está habilitado el
+// aprovisionamiento. El directorio es la fuente de verdad del email, así que queda verificado.
+func (p *LDAPProvider) localUser(directoryUser ldapUser) (structs.UserStruct, error) {
+	user, err := p.store.GetUserByEmail(directoryUser.Email)
+	if err == nil {
+		if !user.HasVerifiedEmail() {
+			if err := p.store.MarkEmailVerified(user.ID); err != nil {
+				return structs.UserStruct{}, err
+			}
+		}
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return structs.UserStruct{}, err
+	}
+	if !p.config.Provision {
+		return structs.UserStruct{}, ErrUserNotFound
+	}
+
+	// La contraseña local es aleatoria: el usuario siempre entra con la del directorio
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return structs.UserStruct{}, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(base64.RawURLEncoding.EncodeToString(random)), bcrypt.DefaultCost)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	name := directoryUser.Name
+	if name == "" {
+		name = strings.Split(directoryUser.Email, "@")[0]
+	}
+	if err := p.store.StoreUser(structs.StoreUserStruct{Name: name, Email: directoryUser.Email, Password: string(hashedPassword)}); err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	user, err = p.store.GetUserByEmail(directoryUser.Email)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+	if err := p.store.MarkEmailVerified(user.ID); err != nil {
+		return structs.UserStruct{}, err
+	}
+	utils.Logs("INFO", fmt.Sprintf("Usuario %s creado desde LDAP (%s)", user.Email, directoryUser.DN))
+	return user, nil
+}
+
+// syncRoles asigna los roles de los grupos del usuario y revoca los roles mapeados de los grupos a
+// los que ya no pertenece. Los roles que no aparecen en LDAP_GROUP_ROLES no se tocan.
+func (p *LDAPProvider) syncRoles(userID int, groups []string) error {
+	wanted := map[string]bool{}
+	for _, group := range groups {
+		if role, ok := p.config.GroupRoles[strings.ToLower(group)]; ok {
+			wanted[role] = true
+		}
+	}
+
+	managed := map[string]bool{}
+	for _, role := range p.config.GroupRoles {
+		managed[role] = true
+	}
+
+	for roleName := range managed {
+		role, err := p.store.GetRoleByName(roleName, "web")
+		if err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Rol %s de LDAP_GROUP_ROLES no encontrado: %v", roleName, err))
+			continue
+		}
+
+		has, err := p.store.UserHasRole(userID, role.ID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case wanted[roleName] && !has:
+			err = p.store.AssignRoleToUser(userID, role.ID)
+		case !wanted[roleName] && has:
+			err = p.store.RevokeRoleFromUser(userID, role.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tlsConfig es la configuración TLS para ldaps:// y StartTLS
+func (p *LDAPProvider) tlsConfig() *tls.Config {
+	host := ""
+	if parsed, err := url.Parse(p.config.URL); err == nil {
+		host = parsed.Hostname()
+	}
+	return &tls.Config{ServerName: host, InsecureSkipVerify: p.config.InsecureSkipVerify}
+}
+
+// dial abre la conexión con timeout; ldaps:// usa TLS desde el inicio
+func (p *LDAPProvider) dial(address string) (ldap.Client, error) {
+	conn, err := ldap.DialURL(address,
+		ldap.DialWithDialer(&net.Dialer{Timeout: p.config.Timeout}),
+		ldap.DialWithTLSConfig(p.tlsConfig()))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(p.config.Timeout)
+	return conn, nil
+}
@@ -0,0 +1,365 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"semita/app/structs"
+	"sort"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	// utils.Logs escribe en storage/logs relativo al directorio del paquete
+	os.RemoveAll("storage")
+	os.Exit(code)
+}
+
+// memoryLocalStore guarda los usuarios y roles locales en memoria en lugar de la base de datos
+type memoryLocalStore struct {
+	users     []structs.UserStruct
+	roles     map[string]int       // Nombre del rol del guard web => ID
+	userRoles map[int]map[int]bool // ID del usuario => IDs de sus roles
+}
+
+func newMemoryLocalStore() *memoryLocalStore {
+	return &memoryLocalStore{
+		roles:     map[string]int{"admin": 1, "editor": 2, "support": 3},
+		userRoles: map[int]map[int]bool{},
+	}
+}
+
+func (s *memoryLocalStore) GetUserByEmail(email string) (structs.UserStruct, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return structs.UserStruct{}, sql.ErrNoRows
+}
+
+func (s *memoryLocalStore) StoreUser(user structs.StoreUserStruct) error {
+	s.users = append(s.users, structs.UserStruct{ID: len(s.users) + 1, Name: user.Name, Email: user.Email, Password: user.Password})
+	return nil
+}
+
+func (s *memoryLocalStore) MarkEmailVerified(userID int) error {
+	for i := range s.users {
+		if s.users[i].ID == userID {
+			s.users[i].EmailVerifiedAt = "2025-07-15 10:00:00"
+		}
+	}
+	return nil
+}
+
+func (s *memoryLocalStore) GetRoleByName(name string, guardName string) (*structs.RoleStruct, error) {
+	id, ok := s.roles[name]
+	if !ok || guardName != "web" {
+		return nil, sql.ErrNoRows
+	}
+	return &structs.RoleStruct{ID: id, Name: name, GuardName: guardName}, nil
+}
+
+func (s *memoryLocalStore) UserHasRole(userID int, roleID int) (bool, error) {
+	return s.userRoles[userID][roleID], nil
+}
+
+func (s *memoryLocalStore) AssignRoleToUser(userID int, roleID int) error {
+	if s.userRoles[userID] == nil {
+		s.userRoles[userID] = map[int]bool{}
+	}
+	s.userRoles[userID][roleID] = true
+	return nil
+}
+
+func (s *memoryLocalStore) RevokeRoleFromUser(userID int, roleID int) error {
+	delete(s.userRoles[userID], roleID)
+	return nil
+}
+
+// roleNames devuelve los nombres de los roles del usuario ordenados
+func (s *memoryLocalStore) roleNames(userID int) []string {
+	names := []string{}
+	for name, id := range s.roles {
+		if s.userRoles[userID][id] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newTestLDAPProvider crea el proveedor contra el servidor en memoria con la cuenta de servicio
+func newTestLDAPProvider(t *testing.T, server *testLDAPServer, store *memoryLocalStore, modify func(config *LDAPConfig)) *LDAPProvider {
+	t.Helper()
+
+	config := LDAPConfig{
+		URL:            "ldap://directory.example:389",
+		BindDN:         testServiceDN,
+		BindPassword:   testServicePass,
+		BaseDN:         testBaseDN,
+		UserFilter:     "(&(objectClass=person)(mail={login}))",
+		EmailAttribute: "mail",
+		NameAttribute:  "displayName",
+		GroupAttribute: "memberOf",
+		GroupFilter:    "(&(objectClass=groupOfNames)(member={dn}))",
+		GroupRoles:     map[string]string{},
+		Provision:      true,
+		Dial:           server.dial,
+	}
+	if modify != nil {
+		modify(&config)
+	}
+
+	provider, err := NewLDAPProvider(config)
+	if err != nil {
+		t.Fatalf("NewLDAPProvider: %v", err)
+	}
+	provider.store = store
+	return provider
+}
+
+func TestLDAPAuthenticateBindsAsTheUser(t *testing.T) {
+	server := newTestLDAPServer(t)
+	store := newMemoryLocalStore()
+	store.users = []structs.UserStruct{{ID: 7, Name: "Ana", Email: "ana@example.com"}}
+	provider := newTestLDAPProvider(t, server, store, nil)
+
+	user, err := provider.Authenticate(context.Background(), Credentials{Login: "ana@example.com", Password: "ana-secret"})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.ID != 7 {
+		t.Errorf("user ID = %d, want the existing local user 7", user.ID)
+	}
+
+	// El directorio es la fuente de verdad del email: el usuario local queda verificado
+	if stored, _ := store.GetUserByEmail("ana@example.com"); !stored.HasVerifiedEmail() {
+		t.Error("the local user email was not marked as verified")
+	}
+	if len(store.users) != 1 {
+		t.Errorf("%d local users, want the existing one only", len(store.users))
+	}
+
+	want := []string{testServiceDN, "uid=ana,ou=people,dc=example,dc=com"}
+	if binds := server.bindDNs(); !equalStrings(binds, want) {
+		t.Errorf("binds = %v, want %v", binds, want)
+	}
+}
+
+func TestLDAPAuthenticateRejectsWrongPassword(t *testing.T) {
+	server := newTestLDAPServer(t)
+	store := newMemoryLocalStore()
+	provider := newTestLDAPProvider(t, server, store, nil)
+
+	_, err := provider.Authenticate(context.Background(), Credentials{Login: "ana@example.com", Password: "wrong"})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if len(store.users) != 0 {
+		t.Error("a local user was provisioned after a failed bind")
+	}
+}
+
+func TestLDAPAuthenticateFailsWithWrongServiceAccount(t *testing.T) {
+	server := newTestLDAPServer(t)
+	provider := newTestLDAPProvider(t, server, newMemoryLocalStore(), func(config *LDAPConfig) {
+		config.BindPassword = "wrong"
+	})
+
+	_, err := provider.Authenticate(context.Background(), Credentials{Login: "ana@example.com", Password: "ana-secret"})
+	if err == nil || errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("err = %v, want a provider error", err)
+	}
+}
+
+func TestLDAPAuthenticateReportsUnknownUser(t *testing.T) {
+	server := newTestLDAPServer(t)
+	provider := newTestLDAPProvider(t, server, newMemoryLocalStore(), nil)
+
+	// El login se escapa: un filtro inyectado no encuentra a nadie
+	for _, login := range []string{"nobody@example.com", "*", "ana@example.com)(objectClass=*"} {
+		_, err := provider.Authenticate(context.Background(), Credentials{Login: login, Password: "ana-secret"})
+		if !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("login %q: err = %v, want %v", login, err, ErrUserNotFound)
+		}
+	}
+}
+
+func TestLDAPAuthenticateRejectsAmbiguousFilter(t *testing.T) {
+	server := newTestLDAPServer(t)
+	provider := newTestLDAPProvider(t, server, newMemoryLocalStore(), func(config *LDAPConfig) {
+		config.UserFilter = "(|(objectClass=person)(mail={login}))"
+	})
+
+	_, err := provider.Authenticate(context.Background(), Credentials{Login: "ana@example.com", Password: "ana-secret"})
+	if err == nil || errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("err = %v, want an error for a filter that matches several users", err)
+	}
+}
+
+func TestLDAPAuthenticateProvisionsLocalUser(t *testing.T) {
+	server := newTestLDAPServer(t)
+	store := newMemoryLocalStore()
+	provider := newTestLDAPProvider(t, server, store, nil)
+
+	user, err := provider.Authenticate(context.Background(), Credentials{Login: "ana@example.com", Password: "ana-secret"})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Email != "ana@example.com" || user.Name != "Ana Pérez" {
+		t.Errorf("user = %+v", user)
+	}
+
+	stored, err := store.GetUserByEmail("ana@example.com")
+	if err != nil {
+		t.Fatalf("the local user was not created: %v", err)
+	}
+	if !stored.HasVerifiedEmail() {
+		t.Error("the provisioned user email is not verified")
+	}
+	// La contraseña local es un hash aleatorio, nunca la del directorio
+	if stored.Password == "" || stored.Password == "ana-secret" {
+		t.Errorf("local password = %q, want a random bcrypt hash", stored.Password)
+	}
+
+	// Sin displayName, el nombre sale del email
+	if _, err := provider.Authenticate(context.Background(), Credentials{Login: "eva@example.com", Password: "eva-secret"}); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if stored, _ := store.GetUserByEmail("eva@example.com"); stored.Name != "eva" {
+		t.Errorf("provisioned name = %q, want eva", stored.Name)
+	}
+}
+
+func TestLDAPAuthenticateWithoutProvisioning(t *testing.T) {
+	server := newTestLDAPServer(t)
+	store := newMemoryLocalStore()
+	provider := newTestLDAPProvider(t, server, store, func(config *LDAPConfig) {
+		config.Provision = false
+	})
+
+	_, err := provider.Authenticate(context.Background(), Credentials{Login: "ana@example.com", Password: "ana-secret"})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("err = %v, want %v", err, ErrUserNotFound)
+	}
+	if len(store.users) != 0 {
+		t.Error("a local user was created with provisioning disabled")
+	}
+}
+
+func TestLDAPAuthenticateSyncsGroupRoles(t *testing.T) {
+	server := newTestLDAPServer(t)
+	store := newMemoryLocalStore()
+	store.users = []structs.UserStruct{{ID: 7, Email: "ana@example.com"}, {ID: 8, Email: "eva@example.com"}}
+	// Eva tenía admin por un grupo del que ya salió, y support asignado a mano
+	store.userRoles[8] = map[int]bool{1: true, 3: true}
+
+	provider := newTestLDAPProvider(t, server, store, func(config *LDAPConfig) {
+		config.GroupBaseDN = testGroupBaseDN
+		config.GroupRoles = ParseGroupRoles("cn=admins,ou=groups,dc=example,dc=com:admin; CN=Editors,OU=Groups,DC=example,DC=com:editor; cn=ghosts,ou=groups,dc=example,dc=com:missing")
+	})
+
+	for _, login := range []Credentials{{Login: "ana@example.com", Password: "ana-secret"}, {Login: "eva@example.com", Password: "eva-secret"}} {
+		if _, err := provider.Authenticate(context.Background(), login); err != nil {
+			t.Fatalf("Authenticate %s: %v", login.Login, err)
+		}
+	}
+
+	// Ana es admin por memberOf y editor por la búsqueda de grupos
+	if roles := store.roleNames(7); !equalStrings(roles, []string{"admin", "editor"}) {
+		t.Errorf("ana roles = %v, want [admin editor]", roles)
+	}
+	// A Eva se le revoca admin y se conserva support, que no está mapeado
+	if roles := store.roleNames(8); !equalStrings(roles, []string{"editor", "support"}) {
+		t.Errorf("eva roles = %v, want [editor support]", roles)
+	}
+
+	// Los grupos se leen después de volver a hacer bind con la cuenta de servicio
+	want := []string{testServiceDN, "uid=ana,ou=people,dc=example,dc=com", testServiceDN}
+	if binds := server.bindDNs()[:3]; !equalStrings(binds, want) {
+		t.Errorf("binds = %v, want %v", binds, want)
+	}
+}
+
+func TestAttemptFallsThroughProviders(t *testing.T) {
+	server := newTestLDAPServer(t)
+	store := newMemoryLocalStore()
+	store.users = []structs.UserStruct{{ID: 7, Email: "ana@example.com"}}
+	ldapProvider := newTestLDAPProvider(t, server, store, nil)
+
+	fallback := &recordingProvider{user: structs.UserStruct{ID: 99, Email: "local@example.com"}}
+	rejecting := &recordingProvider{user: structs.UserStruct{ID: 42}, err: ErrInvalidCredentials}
+
+	cases := []struct {
+		name        string
+		providers   []Provider
+		credentials Credentials
+		dialFails   bool
+		wantID      int
+		wantErr     error
+		wantCalls   int // Llamadas al último proveedor
+	}{
+		{"ldap accepts first", []Provider{ldapProvider, fallback}, Credentials{"ana@example.com", "ana-secret"}, false, 7, nil, 0},
+		{"unknown ldap user falls through", []Provider{ldapProvider, fallback}, Credentials{"local@example.com", "secret"}, false, 99, nil, 1},
+		{"wrong ldap password falls through", []Provider{ldapProvider, fallback}, Credentials{"ana@example.com", "secret"}, false, 99, nil, 1},
+		{"unavailable ldap falls through", []Provider{ldapProvider, fallback}, Credentials{"ana@example.com", "ana-secret"}, true, 99, nil, 1},
+		{"earlier provider wins", []Provider{fallback, ldapProvider}, Credentials{"ana@example.com", "ana-secret"}, false, 99, nil, 0},
+		{"all reject with the known user", []Provider{rejecting, ldapProvider}, Credentials{"ana@example.com", "wrong"}, false, 42, ErrInvalidCredentials, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server.mutex.Lock()
+			server.failDial = tc.dialFails
+			server.mutex.Unlock()
+			fallback.calls = 0
+
+			user, err := attempt(context.Background(), tc.providers, tc.credentials)
+			if !errors.Is(err, tc.wantErr) || user.ID != tc.wantID {
+				t.Fatalf("user %d, err %v; want user %d, err %v", user.ID, err, tc.wantID, tc.wantErr)
+			}
+			if last, ok := tc.providers[len(tc.providers)-1].(*recordingProvider); ok && last.calls != tc.wantCalls {
+				t.Errorf("last provider called %d times, want %d", last.calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAttemptRejectsEmptyPasswordBeforeProviders(t *testing.T) {
+	// Un bind con contraseña vacía es anónimo y el directorio lo aceptaría
+	if _, err := Attempt(context.Background(), Credentials{Login: "ana@example.com"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidCredentials)
+	}
+}
+
+// recordingProvider devuelve siempre el mismo resultado y cuenta sus llamadas
+type recordingProvider struct {
+	user  structs.UserStruct
+	err   error
+	calls int
+}
+
+func (p *recordingProvider) Name() string {
+	return "recording"
+}
+
+func (p *recordingProvider) Authenticate(context.Context, Credentials) (structs.UserStruct, error) {
+	p.calls++
+	return p.user, p.err
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
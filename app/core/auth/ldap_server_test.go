@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// testDirectoryEntry es una entrada del directorio de prueba; userPassword es la contraseña del bind
+type testDirectoryEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// testLDAPServer es un servidor LDAP en memoria que habla el protocolo real con go-ldap a través de
+// un net.Pipe. Implementa bind simple, búsquedas con filtros de igualdad y presencia y unbind; los
+// grupos (ou=groups) solo los puede leer la cuenta de servicio, como en un directorio con ACLs.
+type testLDAPServer struct {
+	t       *testing.T
+	entries []testDirectoryEntry
+
+	mutex    sync.Mutex
+	binds    []string // DN de cada bind, en orden; "" es un bind anónimo
+	failDial bool
+}
+
+const (
+	testBaseDN      = "dc=example,dc=com"
+	testGroupBaseDN = "ou=groups,dc=example,dc=com"
+	testServiceDN   = "cn=service,dc=example,dc=com"
+	testServicePass = "service-secret"
+)
+
+func newTestLDAPServer(t *testing.T) *testLDAPServer {
+	return &testLDAPServer{t: t, entries: []testDirectoryEntry{
+		{DN: testServiceDN, Attributes: map[string][]string{"objectClass": {"person"}, "userPassword": {testServicePass}}},
+		{DN: "uid=ana,ou=people,dc=example,dc=com", Attributes: map[string][]string{
+			"objectClass":  {"person"},
+			"mail":         {"ana@example.com"},
+			"displayName":  {"Ana Pérez"},
+			"userPassword": {"ana-secret"},
+			"memberOf":     {"cn=Admins,ou=groups,dc=example,dc=com"},
+		}},
+		{DN: "uid=eva,ou=people,dc=example,dc=com", Attributes: map[string][]string{
+			"objectClass":  {"person"},
+			"mail":         {"eva@example.com"},
+			"userPassword": {"eva-secret"},
+		}},
+		{DN: "cn=admins,ou=groups,dc=example,dc=com", Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"member":      {"uid=ana,ou=people,dc=example,dc=com"},
+		}},
+		{DN: "cn=editors,ou=groups,dc=example,dc=com", Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"member":      {"uid=ana,ou=people,dc=example,dc=com", "uid=eva,ou=people,dc=example,dc=com"},
+		}},
+	}}
+}
+
+// dial es el hook LDAPConfig.Dial: cada conexión se atiende en una goroutine propia
+func (s *testLDAPServer) dial(string) (ldap.Client, error) {
+	s.mutex.Lock()
+	failDial := s.failDial
+	s.mutex.Unlock()
+	if failDial {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: net.ErrClosed}
+	}
+
+	client, server := net.Pipe()
+	go s.serve(server)
+
+	conn := ldap.NewConn(client, false)
+	conn.Start()
+	return conn, nil
+}
+
+func (s *testLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	bound := ""
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil || len(request.Children) < 2 {
+			return
+		}
+		messageID := request.Children[0].Value
+		operation := request.Children[1]
+
+		switch operation.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := packetString(operation.Children[1])
+			password := string(operation.Children[2].Data.Bytes())
+			code := s.bind(dn, password)
+			if code == ldap.LDAPResultSuccess {
+				bound = dn
+			}
+			s.write(conn, messageID, ldapResult(ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			for _, response := range s.search(bound, operation) {
+				s.write(conn, messageID, response)
+			}
+		case ldap.ApplicationUnbindRequest:
+			return
+		default:
+			s.write(conn, messageID, ldapResult(ldap.ApplicationExtendedResponse, ldap.LDAPResultUnwillingToPerform))
+		}
+	}
+}
+
+// bind valida la contraseña del DN; una contraseña vacía es un bind no autenticado (RFC 4513 5.1.2)
+func (s *testLDAPServer) bind(dn string, password string) uint16 {
+	s.mutex.Lock()
+	s.binds = append(s.binds, dn)
+	s.mutex.Unlock()
+
+	if password == "" {
+		return ldap.LDAPResultSuccess
+	}
+	entry, ok := s.entry(dn)
+	if !ok || len(entry.Attributes["userPassword"]) == 0 || entry.Attributes["userPassword"][0] != password {
+		return ldap.LDAPResultInvalidCredentials
+	}
+	return ldap.LDAPResultSuccess
+}
+
+func (s *testLDAPServer) search(bound string, request *ber.Packet) []*ber.Packet {
+	baseDN := packetString(request.Children[0])
+	sizeLimit := int(request.Children[3].Value.(int64))
+	filter := request.Children[6]
+
+	var responses []*ber.Packet
+	for _, entry := range s.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), strings.ToLower(baseDN)) || !matchesFilter(entry, filter) {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(entry.DN), testGroupBaseDN) && !strings.EqualFold(bound, testServiceDN) {
+			continue
+		}
+		if sizeLimit > 0 && len(responses) == sizeLimit {
+			return append(responses, ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSizeLimitExceeded))
+		}
+		responses = append(responses, searchEntry(entry, request.Children[7]))
+	}
+	return append(responses, ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+}
+
+func (s *testLDAPServer) entry(dn string) (testDirectoryEntry, bool) {
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry.DN, dn) {
+			return entry, true
+		}
+	}
+	return testDirectoryEntry{}, false
+}
+
+// bindDNs devuelve los DN con los que se hizo bind, en orden
+func (s *testLDAPServer) bindDNs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.binds...)
+}
+
+func (s *testLDAPServer) write(conn net.Conn, messageID any, operation *ber.Packet) {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	envelope.AppendChild(operation)
+	if _, err := conn.Write(envelope.Bytes()); err != nil {
+		s.t.Logf("test LDAP server: %v", err)
+	}
+}
+
+// matchesFilter evalúa los filtros and, or, not, igualdad (sin distinguir mayúsculas) y presencia
+func matchesFilter(entry testDirectoryEntry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchesFilter(entry, child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchesFilter(entry, child) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchesFilter(entry, filter.Children[0])
+	case ldap.FilterEqualityMatch:
+		for _, value := range entryValues(entry, packetString(filter.Children[0])) {
+			if strings.EqualFold(value, packetString(filter.Children[1])) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		return len(entryValues(entry, string(filter.Data.Bytes()))) > 0
+	}
+	return false
+}
+
+func entryValues(entry testDirectoryEntry, attribute string) []string {
+	for name, values := range entry.Attributes {
+		if strings.EqualFold(name, attribute) {
+			return values
+		}
+	}
+	return nil
+}
+
+func searchEntry(entry testDirectoryEntry, requested *ber.Packet) *ber.Packet {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "Object Name"))
+
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for _, child := range requested.Children {
+		name := packetString(child)
+		values := entryValues(entry, name)
+		if len(values) == 0 || strings.EqualFold(name, "userPassword") {
+			continue
+		}
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	response.AppendChild(attributes)
+	return response
+}
+
+func ldapResult(tag ber.Tag, code uint16) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return result
+}
+
+func packetString(packet *ber.Packet) string {
+	if value, ok := packet.Value.(string); ok {
+		return value
+	}
+	return string(packet.Data.Bytes())
+}
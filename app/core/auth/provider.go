@@ -0,0 +1,126 @@
+// Package auth define los proveedores de autenticación por usuario y contraseña que usan el login web,
+// el login de la API y el grant password de OAuth. Los proveedores se prueban en el orden de
+// AUTH_PROVIDERS; el de base de datos (bcrypt) es el que se usa por defecto.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrInvalidCredentials indica que el usuario existe en el proveedor pero la contraseña no es válida
+	ErrInvalidCredentials = errors.New("auth: credenciales inválidas")
+	// ErrUserNotFound indica que el proveedor no conoce al usuario; se prueba el siguiente
+	ErrUserNotFound = errors.New("auth: usuario no encontrado")
+)
+
+// Credentials son los datos que envía el usuario en el formulario o en la API
+type Credentials struct {
+	Login    string // Email o nombre de usuario
+	Password string
+}
+
+// Provider valida credenciales y devuelve el usuario local correspondiente. Con
+// ErrInvalidCredentials puede devolver además el usuario encontrado, para registrar el intento.
+type Provider interface {
+	Name() string
+	Authenticate(ctx context.Context, credentials Credentials) (structs.UserStruct, error)
+}
+
+// ProviderFactory construye un proveedor a partir de su configuración
+type ProviderFactory func() (Provider, error)
+
+var (
+	factoriesMutex sync.Mutex
+	factories      = map[string]ProviderFactory{
+		"database": func() (Provider, error) { return DatabaseProvider{}, nil },
+		"ldap":     func() (Provider, error) { return NewLDAPProvider(LDAPConfigFromEnv()) },
+	}
+
+	providersOnce sync.Once
+	providers     []Provider
+	providersErr  error
+)
+
+// RegisterProvider agrega un proveedor propio que luego se habilita por nombre en AUTH_PROVIDERS.
+// Debe llamarse al arrancar, antes del primer login.
+func RegisterProvider(name string, factory ProviderFactory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	factories[name] = factory
+}
+
+// Providers devuelve los proveedores habilitados en AUTH_PROVIDERS (por defecto "database")
+func Providers() ([]Provider, error) {
+	providersOnce.Do(func() {
+		factoriesMutex.Lock()
+		defer factoriesMutex.Unlock()
+
+		names := os.Getenv("AUTH_PROVIDERS")
+		if strings.TrimSpace(names) == "" {
+			names = "database"
+		}
+		for _, name := range strings.Split(names, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			factory, ok := factories[name]
+			if !ok {
+				providersErr = fmt.Errorf("auth: proveedor %q desconocido", name)
+				return
+			}
+			provider, err := factory()
+			if err != nil {
+				providersErr = fmt.Errorf("auth: proveedor %s: %w", name, err)
+				return
+			}
+			providers = append(providers, provider)
+		}
+	})
+	return providers, providersErr
+}
+
+// Attempt valida las credenciales con cada proveedor en orden y devuelve el primer usuario válido.
+// Un proveedor que no conoce al usuario, rechaza la contraseña o no está disponible (p. ej. el
+// servidor LDAP caído) deja paso al siguiente. Si ninguno acepta, se devuelve ErrInvalidCredentials
+// junto con el usuario local que se haya identificado, si hubo alguno.
+func Attempt(ctx context.Context, credentials Credentials) (structs.UserStruct, error) {
+	if credentials.Login == "" || credentials.Password == "" {
+		return structs.UserStruct{}, ErrInvalidCredentials
+	}
+
+	enabled, err := Providers()
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+	return attempt(ctx, enabled, credentials)
+}
+
+// attempt recorre los proveedores en orden; está separada de Attempt para probarla sin AUTH_PROVIDERS
+func attempt(ctx context.Context, enabled []Provider, credentials Credentials) (structs.UserStruct, error) {
+	var known structs.UserStruct
+	for _, provider := range enabled {
+		user, err := provider.Authenticate(ctx, credentials)
+		switch {
+		case err == nil:
+			return user, nil
+		case errors.Is(err, ErrInvalidCredentials):
+			if known.ID == 0 {
+				known = user
+			}
+		case errors.Is(err, ErrUserNotFound):
+		default:
+			utils.Logs("ERROR", fmt.Sprintf("Error en el proveedor de autenticación %s: %v", provider.Name(), err))
+		}
+	}
+
+	return known, ErrInvalidCredentials
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TeamHeader es la cabecera con la que un cliente elige el equipo de la petición
+const TeamHeader = "X-Team-ID"
+
+// teamKey es la clave del contexto donde se guarda el equipo fijado por la ruta
+const teamKey = "auth_team_id"
+
+// SetTeam fija el equipo de la petición, por ejemplo desde un parámetro de la ruta, y descarta la
+// identidad ya resuelta para que sus roles y permisos se vuelvan a cargar en ese equipo
+func SetTeam(context *gin.Context, teamID int) {
+	context.Set(teamKey, teamID)
+	Forget(context)
+}
+
+// TeamID devuelve el equipo pedido para la petición: el fijado por la ruta, la cabecera X-Team-ID o,
+// con guards de sesión, el guardado en la sesión. Devuelve 0 si no hay. El equipo solo se aplica si el
+// usuario es miembro; ver Team.
+func TeamID(context *gin.Context) int {
+	if teamID, ok := context.Get(teamKey); ok {
+		if id, ok := teamID.(int); ok {
+			return id
+		}
+	}
+	if header := context.GetHeader(TeamHeader); header != "" {
+		teamID, _ := strconv.Atoi(header)
+		return teamID
+	}
+	if !ResolveGuard(context).Stateless() {
+		return utils.CurrentTeam(context.Request)
+	}
+	return 0
+}
+
+// Team devuelve el equipo actual de la petición si el usuario autenticado es miembro
+func Team(context *gin.Context) (*structs.TeamStruct, bool) {
+	identity, _ := Resolve(context)
+	if identity == nil || identity.Team == nil {
+		return nil, false
+	}
+	return identity.Team, true
+}
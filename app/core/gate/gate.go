@@ -0,0 +1,175 @@
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"semita/app/core/auth"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrUnauthenticated = errors.New("gate: no hay usuario autenticado")
+	ErrForbidden       = errors.New("gate: acción no autorizada")
+)
+
+// Callback decide si el actor puede realizar una habilidad sobre el recurso, que puede ser nil
+type Callback func(actor *auth.Identity, resource any) bool
+
+// BeforeHook se ejecuta antes de cualquier regla. Si decided es true su resultado es definitivo;
+// si no, la verificación sigue con la policy o la habilidad definida.
+type BeforeHook func(actor *auth.Identity, ability string, resource any) (allowed bool, decided bool)
+
+var (
+	mutex       sync.RWMutex
+	abilities   = map[string]Callback{}
+	policies    = map[reflect.Type]map[string]reflect.Value{}
+	beforeHooks []BeforeHook
+	identityPtr = reflect.TypeOf((*auth.Identity)(nil))
+)
+
+// Define registra una habilidad que no depende de una policy, por ejemplo "update-post"
+func Define(ability string, callback Callback) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	abilities[ability] = callback
+}
+
+// Before registra un hook que se ejecuta antes de todas las verificaciones, en orden de registro
+func Before(hook BeforeHook) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	beforeHooks = append(beforeHooks, hook)
+}
+
+// Policy asocia una policy al tipo del modelo. Cada método exportado con la firma
+// func(actor *auth.Identity, target Modelo) bool autoriza la habilidad con su nombre en kebab-case:
+// Update responde a "update" y ViewAny a "view-any". Debe llamarse al arrancar.
+func Policy(model any, policy any) {
+	modelType := indirectType(reflect.TypeOf(model))
+	policyValue := reflect.ValueOf(policy)
+
+	methods := map[string]reflect.Value{}
+	for i := 0; i < policyValue.NumMethod(); i++ {
+		method := policyValue.Method(i)
+		methodType := method.Type()
+		if methodType.NumIn() != 2 || methodType.NumOut() != 1 ||
+			methodType.In(0) != identityPtr || methodType.In(1) != modelType || methodType.Out(0).Kind() != reflect.Bool {
+			continue
+		}
+		methods[kebabCase(policyValue.Type().Method(i).Name)] = method
+	}
+	if len(methods) == 0 {
+		panic(fmt.Sprintf("gate: la policy %T no tiene métodos func(*auth.Identity, %s) bool", policy, modelType))
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	policies[modelType] = methods
+}
+
+// Check indica si el actor puede realizar la habilidad. Se aplican los hooks Before, luego la
+// policy del tipo del recurso y luego la habilidad definida con Define. Si ninguna regla responde a
+// la habilidad, se la trata como el nombre de un permiso del actor.
+func Check(actor *auth.Identity, ability string, resource ...any) bool {
+	if actor == nil {
+		return false
+	}
+
+	var target any
+	if len(resource) > 0 {
+		target = resource[0]
+	}
+
+	mutex.RLock()
+	hooks := beforeHooks
+	method, hasPolicy := policyMethod(target, ability)
+	callback, hasAbility := abilities[ability]
+	mutex.RUnlock()
+
+	for _, hook := range hooks {
+		if allowed, decided := hook(actor, ability, target); decided {
+			return allowed
+		}
+	}
+
+	if hasPolicy {
+		value := reflect.ValueOf(target)
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				return false
+			}
+			value = value.Elem()
+		}
+		return method.Call([]reflect.Value{reflect.ValueOf(actor), value})[0].Bool()
+	}
+	if hasAbility {
+		return callback(actor, target)
+	}
+	return actor.HasPermission(ability)
+}
+
+// Allows indica si el usuario autenticado de la petición puede realizar la habilidad
+func Allows(context *gin.Context, ability string, resource ...any) bool {
+	identity, err := auth.Resolve(context)
+	if err != nil {
+		return false
+	}
+	return Check(identity, ability, resource...)
+}
+
+// Denies es lo contrario de Allows
+func Denies(context *gin.Context, ability string, resource ...any) bool {
+	return !Allows(context, ability, resource...)
+}
+
+// Authorize devuelve ErrUnauthenticated si no hay usuario y ErrForbidden si no puede realizar la habilidad
+func Authorize(context *gin.Context, ability string, resource ...any) error {
+	identity, err := auth.Resolve(context)
+	if identity == nil {
+		return ErrUnauthenticated
+	}
+	if err != nil || !Check(identity, ability, resource...) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// policyMethod busca el método de la policy registrada para el tipo del recurso
+func policyMethod(target any, ability string) (reflect.Value, bool) {
+	if target == nil {
+		return reflect.Value{}, false
+	}
+	methods, ok := policies[indirectType(reflect.TypeOf(target))]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	method, ok := methods[ability]
+	return method, ok
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// kebabCase convierte el nombre de un método en el de la habilidad: ViewAny -> view-any
+func kebabCase(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				builder.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,211 @@
+package rbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"semita/app/models"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultGuard es el guard de los roles y permisos del manifiesto que no indican uno
+const DefaultGuard = "web"
+
+// Manifest describe de forma declarativa los roles, los permisos y qué permisos tiene cada rol
+type Manifest struct {
+	Permissions []PermissionManifest `yaml:"permissions" json:"permissions"`
+	Roles       []RoleManifest       `yaml:"roles" json:"roles"`
+}
+
+// PermissionManifest es un permiso del manifiesto
+type PermissionManifest struct {
+	Name        string `yaml:"name" json:"name"`
+	GuardName   string `yaml:"guard_name,omitempty" json:"guard_name,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// RoleManifest es un rol del manifiesto, con los nombres de sus permisos y de sus roles padre, que
+// deben estar en el manifiesto con el mismo guard
+type RoleManifest struct {
+	Name              string   `yaml:"name" json:"name"`
+	GuardName         string   `yaml:"guard_name,omitempty" json:"guard_name,omitempty"`
+	Description       string   `yaml:"description,omitempty" json:"description,omitempty"`
+	RequiresTwoFactor bool     `yaml:"requires_two_factor,omitempty" json:"requires_two_factor,omitempty"`
+	Permissions       []string `yaml:"permissions,omitempty" json:"permissions,omitempty"`
+	Parents           []string `yaml:"parents,omitempty" json:"parents,omitempty"`
+}
+
+// Export lee de la base de datos todos los roles y permisos como manifiesto
+func Export() (*Manifest, error) {
+	permissions, err := models.GetAllPermissions()
+	if err != nil {
+		return nil, err
+	}
+	roles, err := models.GetAllRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Permissions: []PermissionManifest{}, Roles: []RoleManifest{}}
+	for _, permission := range permissions {
+		manifest.Permissions = append(manifest.Permissions, PermissionManifest{
+			Name:        permission.Name,
+			GuardName:   permission.GuardName,
+			Description: permission.Description,
+		})
+	}
+
+	for _, role := range roles {
+		entry := RoleManifest{
+			Name:              role.Name,
+			GuardName:         role.GuardName,
+			Description:       role.Description,
+			RequiresTwoFactor: role.RequiresTwoFactor,
+		}
+
+		rolePermissions, err := models.GetRolePermissions(role.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range rolePermissions {
+			entry.Permissions = append(entry.Permissions, permission.Name)
+		}
+
+		parents, err := models.GetRoleParents(role.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			entry.Parents = append(entry.Parents, parent.Name)
+		}
+
+		manifest.Roles = append(manifest.Roles, entry)
+	}
+
+	return manifest, nil
+}
+
+// Encode escribe el manifiesto en formato yaml o json
+func (m *Manifest) Encode(writer io.Writer, format string) error {
+	switch format {
+	case "yaml", "yml":
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(m); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(m)
+	default:
+		return fmt.Errorf("unsupported manifest format %q (use yaml or json)", format)
+	}
+}
+
+// Load lee y valida un manifiesto en YAML o JSON; con la ruta "-" lo lee de la entrada estándar
+func Load(path string) (*Manifest, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(content)
+}
+
+// Parse interpreta un manifiesto en YAML o JSON (JSON es YAML válido). Los campos desconocidos son
+// un error, para que una clave mal escrita no se ignore en silencio.
+func Parse(content []byte) (*Manifest, error) {
+	var manifest Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("manifest is empty")
+		}
+		return nil, err
+	}
+
+	if err := manifest.normalize(); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// normalize completa el guard por defecto y verifica que no haya nombres repetidos ni referencias
+// a permisos o roles que no están en el manifiesto o que son de otro guard
+func (m *Manifest) normalize() error {
+	if len(m.Permissions) == 0 && len(m.Roles) == 0 {
+		return errors.New("manifest is empty")
+	}
+
+	permissions := map[string]bool{}
+	permissionGuards := map[string][]string{}
+	for i := range m.Permissions {
+		permission := &m.Permissions[i]
+		if permission.Name == "" {
+			return fmt.Errorf("permission #%d has no name", i+1)
+		}
+		if permission.GuardName == "" {
+			permission.GuardName = DefaultGuard
+		}
+		id := key(permission.GuardName, permission.Name)
+		if permissions[id] {
+			return fmt.Errorf("permission %s is declared twice", id)
+		}
+		permissions[id] = true
+		permissionGuards[permission.Name] = append(permissionGuards[permission.Name], permission.GuardName)
+	}
+
+	roles := map[string]bool{}
+	roleGuards := map[string][]string{}
+	for i := range m.Roles {
+		role := &m.Roles[i]
+		if role.Name == "" {
+			return fmt.Errorf("role #%d has no name", i+1)
+		}
+		if role.GuardName == "" {
+			role.GuardName = DefaultGuard
+		}
+		id := key(role.GuardName, role.Name)
+		if roles[id] {
+			return fmt.Errorf("role %s is declared twice", id)
+		}
+		roles[id] = true
+		roleGuards[role.Name] = append(roleGuards[role.Name], role.GuardName)
+	}
+
+	for _, role := range m.Roles {
+		for _, name := range role.Permissions {
+			if !permissions[key(role.GuardName, name)] {
+				if guards := permissionGuards[name]; len(guards) > 0 {
+					return fmt.Errorf("role %s/%s references permission %s of guard %s; a role and its permissions must share a guard",
+						role.GuardName, role.Name, name, strings.Join(guards, ", "))
+				}
+				return fmt.Errorf("role %s/%s references undeclared permission %s", role.GuardName, role.Name, name)
+			}
+		}
+		for _, name := range role.Parents {
+			if !roles[key(role.GuardName, name)] {
+				if guards := roleGuards[name]; len(guards) > 0 {
+					return fmt.Errorf("role %s/%s references parent role %s of guard %s; a role and its parents must share a guard",
+						role.GuardName, role.Name, name, strings.Join(guards, ", "))
+				}
+				return fmt.Errorf("role %s/%s references undeclared parent role %s", role.GuardName, role.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,410 @@
+package rbac
+
+import (
+	"fmt"
+	"maps"
+	"semita/app/models"
+	"semita/app/structs"
+	"slices"
+	"strings"
+)
+
+// Acciones de los cambios de un plan
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionAttach = "attach"
+	ActionDetach = "detach"
+	ActionDelete = "delete"
+)
+
+// Change es un cambio del plan que lleva la base de datos al estado del manifiesto
+type Change struct {
+	Action      string
+	Description string
+	apply       func() error
+}
+
+// String muestra el cambio con el símbolo de su acción: + agrega, ~ modifica y - quita
+func (c Change) String() string {
+	symbol := "+"
+	switch c.Action {
+	case ActionUpdate:
+		symbol = "~"
+	case ActionDetach, ActionDelete:
+		symbol = "-"
+	}
+	return symbol + " " + c.Description
+}
+
+// Plan compara el estado actual (Export) con el manifiesto deseado. Sin prune solo crea, actualiza y
+// asigna; con prune además quita las asignaciones, los roles y los permisos que no están en el
+// manifiesto. Los roles y permisos se identifican por guard y nombre. Devuelve un error, sin
+// cambios, si el estado final no se puede aplicar completo (ver Validate).
+func Plan(current *Manifest, desired *Manifest, prune bool) ([]Change, error) {
+	if err := Validate(current, desired, prune); err != nil {
+		return nil, err
+	}
+
+	currentPermissions := map[string]PermissionManifest{}
+	for _, permission := range current.Permissions {
+		currentPermissions[key(permission.GuardName, permission.Name)] = permission
+	}
+	currentRoles := map[string]RoleManifest{}
+	for _, role := range current.Roles {
+		currentRoles[key(role.GuardName, role.Name)] = role
+	}
+	desiredPermissions := map[string]bool{}
+	for _, permission := range desired.Permissions {
+		desiredPermissions[key(permission.GuardName, permission.Name)] = true
+	}
+	desiredRoles := map[string]RoleManifest{}
+	for _, role := range desired.Roles {
+		desiredRoles[key(role.GuardName, role.Name)] = role
+	}
+
+	// Cada grupo se aplica en orden: las herencias se quitan antes de agregar las nuevas para no
+	// formar un ciclo transitorio, y los roles y permisos se eliminan al final
+	var creates, updates, roleCreates, roleUpdates, detaches, unlinks, attaches, links, roleDeletes, deletes []Change
+
+	for _, permission := range desired.Permissions {
+		existing, ok := currentPermissions[key(permission.GuardName, permission.Name)]
+		if !ok {
+			creates = append(creates, createPermission(permission))
+		} else if existing.Description != permission.Description {
+			updates = append(updates, updatePermission(permission))
+		}
+	}
+
+	for _, role := range desired.Roles {
+		existing, ok := currentRoles[key(role.GuardName, role.Name)]
+		if !ok {
+			roleCreates = append(roleCreates, createRole(role))
+		} else if fields := changedRoleFields(existing, role); len(fields) > 0 {
+			roleUpdates = append(roleUpdates, updateRole(role, fields))
+		}
+
+		for _, permission := range role.Permissions {
+			if !slices.Contains(existing.Permissions, permission) {
+				attaches = append(attaches, attachPermission(role, permission))
+			}
+		}
+		for _, parent := range role.Parents {
+			if !slices.Contains(existing.Parents, parent) {
+				links = append(links, attachParent(role, parent))
+			}
+		}
+
+		if prune && ok {
+			for _, permission := range existing.Permissions {
+				if !slices.Contains(role.Permissions, permission) {
+					detaches = append(detaches, detachPermission(role, permission))
+				}
+			}
+			for _, parent := range existing.Parents {
+				if !slices.Contains(role.Parents, parent) {
+					unlinks = append(unlinks, detachParent(role, parent))
+				}
+			}
+		}
+	}
+
+	if prune {
+		for _, role := range current.Roles {
+			if _, ok := desiredRoles[key(role.GuardName, role.Name)]; !ok {
+				roleDeletes = append(roleDeletes, deleteRole(role))
+			}
+		}
+		for _, permission := range current.Permissions {
+			if !desiredPermissions[key(permission.GuardName, permission.Name)] {
+				deletes = append(deletes, deletePermission(permission))
+			}
+		}
+	}
+
+	return slices.Concat(creates, updates, roleCreates, roleUpdates, detaches, unlinks, attaches, links, roleDeletes, deletes), nil
+}
+
+// Validate comprueba, antes del primer cambio, que el manifiesto se puede aplicar sobre el estado
+// actual: que cada rol solo referencia permisos y roles padre declarados con su mismo guard, y que
+// la herencia resultante no tiene ciclos. Para los ciclos cuenta también las herencias actuales que
+// se conservan: sin prune, las de los roles del manifiesto y las de los roles que no aparecen en él.
+func Validate(current *Manifest, desired *Manifest, prune bool) error {
+	if err := desired.normalize(); err != nil {
+		return err
+	}
+
+	parents := map[string][]string{}
+	if !prune {
+		for _, role := range current.Roles {
+			for _, parent := range role.Parents {
+				parents[key(role.GuardName, role.Name)] = append(parents[key(role.GuardName, role.Name)], key(role.GuardName, parent))
+			}
+		}
+	}
+	for _, role := range desired.Roles {
+		id := key(role.GuardName, role.Name)
+		if prune {
+			parents[id] = nil
+		}
+		for _, parent := range role.Parents {
+			if !slices.Contains(parents[id], key(role.GuardName, parent)) {
+				parents[id] = append(parents[id], key(role.GuardName, parent))
+			}
+		}
+	}
+
+	if cycle := inheritanceCycle(parents); cycle != nil {
+		return fmt.Errorf("%w: %s", models.ErrRoleParentCycle, strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// inheritanceCycle devuelve los roles de un ciclo de herencia, empezando y terminando en el mismo,
+// o nil si no hay ninguno. Los roles se recorren ordenados para que el error sea estable.
+func inheritanceCycle(parents map[string][]string) []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+
+	var visit func(role string) []string
+	visit = func(role string) []string {
+		switch state[role] {
+		case visiting:
+			start := slices.Index(path, role)
+			return append(slices.Clone(path[start:]), role)
+		case visited:
+			return nil
+		}
+
+		state[role] = visiting
+		path = append(path, role)
+		for _, parent := range parents[role] {
+			if cycle := visit(parent); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[role] = visited
+		return nil
+	}
+
+	roles := slices.Sorted(maps.Keys(parents))
+	for _, role := range roles {
+		if cycle := visit(role); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// Apply ejecuta los cambios en orden y se detiene en el primero que falla. Devuelve cuántos se
+// aplicaron. Los modelos abren una conexión por operación, así que los cambios no corren en una
+// transacción: Plan valida el estado final antes de generarlos para que un manifiesto inválido no
+// quede aplicado a medias.
+func Apply(changes []Change) (int, error) {
+	for i, change := range changes {
+		if err := change.apply(); err != nil {
+			return i, fmt.Errorf("%s: %w", change.Description, err)
+		}
+	}
+	return len(changes), nil
+}
+
+func createPermission(permission PermissionManifest) Change {
+	return Change{
+		Action:      ActionCreate,
+		Description: "create permission " + key(permission.GuardName, permission.Name),
+		apply: func() error {
+			_, err := models.CreatePermission(structs.CreatePermissionStruct{
+				Name:        permission.Name,
+				GuardName:   permission.GuardName,
+				Description: permission.Description,
+			})
+			return err
+		},
+	}
+}
+
+func updatePermission(permission PermissionManifest) Change {
+	return Change{
+		Action:      ActionUpdate,
+		Description: "update permission " + key(permission.GuardName, permission.Name) + " (description)",
+		apply: func() error {
+			existing, err := models.GetPermissionByName(permission.Name, permission.GuardName)
+			if err != nil {
+				return err
+			}
+			_, err = models.UpdatePermission(existing.ID, structs.CreatePermissionStruct{
+				Name:        permission.Name,
+				GuardName:   permission.GuardName,
+				Description: permission.Description,
+			})
+			return err
+		},
+	}
+}
+
+func deletePermission(permission PermissionManifest) Change {
+	return Change{
+		Action:      ActionDelete,
+		Description: "delete permission " + key(permission.GuardName, permission.Name),
+		apply: func() error {
+			existing, err := models.GetPermissionByName(permission.Name, permission.GuardName)
+			if err != nil {
+				return err
+			}
+			return models.DeletePermission(existing.ID)
+		},
+	}
+}
+
+func createRole(role RoleManifest) Change {
+	return Change{
+		Action:      ActionCreate,
+		Description: "create role " + key(role.GuardName, role.Name),
+		apply: func() error {
+			_, err := models.CreateRole(roleData(role))
+			return err
+		},
+	}
+}
+
+func updateRole(role RoleManifest, fields []string) Change {
+	return Change{
+		Action:      ActionUpdate,
+		Description: "update role " + key(role.GuardName, role.Name) + " (" + strings.Join(fields, ", ") + ")",
+		apply: func() error {
+			existing, err := models.GetRoleByName(role.Name, role.GuardName)
+			if err != nil {
+				return err
+			}
+			_, err = models.UpdateRole(existing.ID, roleData(role))
+			return err
+		},
+	}
+}
+
+func deleteRole(role RoleManifest) Change {
+	return Change{
+		Action:      ActionDelete,
+		Description: "delete role " + key(role.GuardName, role.Name),
+		apply: func() error {
+			existing, err := models.GetRoleByName(role.Name, role.GuardName)
+			if err != nil {
+				return err
+			}
+			return models.DeleteRole(existing.ID)
+		},
+	}
+}
+
+func attachPermission(role RoleManifest, permission string) Change {
+	return Change{
+		Action:      ActionAttach,
+		Description: "attach permission " + permission + " to role " + key(role.GuardName, role.Name),
+		apply: func() error {
+			roleID, permissionID, err := rolePermissionIDs(role, permission)
+			if err != nil {
+				return err
+			}
+			return models.AssignPermissionToRole(roleID, permissionID)
+		},
+	}
+}
+
+func detachPermission(role RoleManifest, permission string) Change {
+	return Change{
+		Action:      ActionDetach,
+		Description: "detach permission " + permission + " from role " + key(role.GuardName, role.Name),
+		apply: func() error {
+			roleID, permissionID, err := rolePermissionIDs(role, permission)
+			if err != nil {
+				return err
+			}
+			return models.RevokePermissionFromRole(roleID, permissionID)
+		},
+	}
+}
+
+func attachParent(role RoleManifest, parent string) Change {
+	return Change{
+		Action:      ActionAttach,
+		Description: "attach parent role " + parent + " to role " + key(role.GuardName, role.Name),
+		apply: func() error {
+			roleID, parentID, err := roleParentIDs(role, parent)
+			if err != nil {
+				return err
+			}
+			return models.AddRoleParent(roleID, parentID)
+		},
+	}
+}
+
+func detachParent(role RoleManifest, parent string) Change {
+	return Change{
+		Action:      ActionDetach,
+		Description: "detach parent role " + parent + " from role " + key(role.GuardName, role.Name),
+		apply: func() error {
+			roleID, parentID, err := roleParentIDs(role, parent)
+			if err != nil {
+				return err
+			}
+			return models.RemoveRoleParent(roleID, parentID)
+		},
+	}
+}
+
+// rolePermissionIDs busca los IDs al aplicar el cambio, porque el rol o el permiso pueden haberse
+// creado en un cambio anterior del mismo plan
+func rolePermissionIDs(role RoleManifest, permission string) (int, int, error) {
+	existingRole, err := models.GetRoleByName(role.Name, role.GuardName)
+	if err != nil {
+		return 0, 0, err
+	}
+	existingPermission, err := models.GetPermissionByName(permission, role.GuardName)
+	if err != nil {
+		return 0, 0, err
+	}
+	return existingRole.ID, existingPermission.ID, nil
+}
+
+func roleParentIDs(role RoleManifest, parent string) (int, int, error) {
+	existingRole, err := models.GetRoleByName(role.Name, role.GuardName)
+	if err != nil {
+		return 0, 0, err
+	}
+	existingParent, err := models.GetRoleByName(parent, role.GuardName)
+	if err != nil {
+		return 0, 0, err
+	}
+	return existingRole.ID, existingParent.ID, nil
+}
+
+// changedRoleFields devuelve los atributos del rol que difieren del manifiesto
+func changedRoleFields(existing RoleManifest, role RoleManifest) []string {
+	var fields []string
+	if existing.Description != role.Description {
+		fields = append(fields, "description")
+	}
+	if existing.RequiresTwoFactor != role.RequiresTwoFactor {
+		fields = append(fields, "requires_two_factor")
+	}
+	return fields
+}
+
+func roleData(role RoleManifest) structs.CreateRoleStruct {
+	return structs.CreateRoleStruct{
+		Name:              role.Name,
+		GuardName:         role.GuardName,
+		Description:       role.Description,
+		RequiresTwoFactor: role.RequiresTwoFactor,
+	}
+}
+
+func key(guardName string, name string) string {
+	return guardName + "/" + name
+}
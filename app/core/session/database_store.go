@@ -0,0 +1,162 @@
+package session
+
+import (
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"net"
+	"net/http"
+	"semita/app/models"
+	"semita/app/utils"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+func init() {
+	utils.RegisterSessionDriver("database", func(options *sessions.Options, keyPairs ...[]byte) sessions.Store {
+		return NewDatabaseStore(options, keyPairs...)
+	})
+}
+
+var sessionIDEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// DatabaseStore guarda las sesiones en la tabla sessions; la cookie solo lleva el ID firmado y cifrado
+type DatabaseStore struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+}
+
+// NewDatabaseStore crea el store del driver database
+func NewDatabaseStore(options *sessions.Options, keyPairs ...[]byte) *DatabaseStore {
+	store := &DatabaseStore{
+		Codecs:  securecookie.CodecsFromPairs(keyPairs...),
+		Options: options,
+	}
+
+	for _, codec := range store.Codecs {
+		if secureCookie, ok := codec.(*securecookie.SecureCookie); ok {
+			secureCookie.MaxAge(options.MaxAge)
+			secureCookie.MaxLength(0)
+		}
+	}
+
+	return store
+}
+
+// Get devuelve la sesión registrada para la petición
+func (s *DatabaseStore) Get(request *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(request).Get(s, name)
+}
+
+// New carga la sesión indicada por la cookie o crea una nueva si no existe o expiró
+func (s *DatabaseStore) New(request *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := *s.Options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := request.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, cookie.Value, &session.ID, s.Codecs...); err != nil {
+		session.ID = ""
+		return session, err
+	}
+
+	record, err := models.GetSession(session.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			session.ID = ""
+			return session, nil
+		}
+		return session, err
+	}
+
+	if time.Since(record.LastActivity) > time.Duration(s.Options.MaxAge)*time.Second {
+		session.ID = ""
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, record.Payload, &session.Values, s.Codecs...); err != nil {
+		return session, err
+	}
+
+	// Registrar la actividad como mucho una vez por minuto para no escribir en cada petición
+	if time.Since(record.LastActivity) > time.Minute {
+		_ = models.TouchSession(session.ID)
+	}
+
+	session.IsNew = false
+	return session, nil
+}
+
+// Save guarda la sesión en la base de datos y escribe la cookie con su ID.
+// Con MaxAge negativo la sesión se elimina.
+func (s *DatabaseStore) Save(request *http.Request, response http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if err := s.Destroy(session.ID); err != nil {
+			return err
+		}
+		http.SetCookie(response, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = sessionIDEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+
+	payload, err := securecookie.EncodeMulti(session.Name(), session.Values, s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	var userID int64
+	if id, ok := session.Values["user_id"].(int); ok {
+		userID = int64(id)
+	}
+
+	err = models.SaveSession(models.Session{
+		ID:           session.ID,
+		UserID:       userID,
+		IPAddress:    clientIP(request),
+		UserAgent:    request.UserAgent(),
+		Payload:      payload,
+		LastActivity: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(response, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Destroy elimina una sesión por su ID
+func (s *DatabaseStore) Destroy(id string) error {
+	if id == "" {
+		return nil
+	}
+	return models.DeleteSession(id)
+}
+
+// GC elimina las sesiones sin actividad desde hace más de maxLifetime
+func (s *DatabaseStore) GC(maxLifetime time.Duration) (int64, error) {
+	return models.PurgeSessions(maxLifetime, 1000)
+}
+
+// clientIP devuelve la IP remota de la petición sin el puerto
+func clientIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,261 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxResponseSize limita lo que se lee de las respuestas del proveedor
+const maxResponseSize = 1 << 20
+
+// AuthCodeURL arma la URL de autorización a la que se redirige al usuario.
+// El nonce solo se envía a proveedores OpenID Connect.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, verifier string, nonce string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+	if p.config.AuthURL == "" {
+		return "", fmt.Errorf("%w: authorization", ErrMissingEndpoint)
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("state", state)
+	query.Set("code_challenge", CodeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	if len(p.config.Scopes) > 0 {
+		query.Set("scope", strings.Join(p.config.Scopes, " "))
+	}
+	if p.OIDC() && nonce != "" {
+		query.Set("nonce", nonce)
+	}
+
+	separator := "?"
+	if strings.Contains(p.config.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.config.AuthURL + separator + query.Encode(), nil
+}
+
+// Exchange canjea el código de autorización por los tokens del usuario
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string) (Token, error) {
+	if err := p.discover(ctx); err != nil {
+		return Token{}, err
+	}
+	if p.config.TokenURL == "" {
+		return Token{}, fmt.Errorf("%w: token", ErrMissingEndpoint)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("client_secret", p.config.ClientSecret)
+	form.Set("code_verifier", verifier)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	var body struct {
+		AccessToken      string          `json:"access_token"`
+		TokenType        string          `json:"token_type"`
+		RefreshToken     string          `json:"refresh_token"`
+		IDToken          string          `json:"id_token"`
+		ExpiresIn        json.RawMessage `json:"expires_in"`
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	status, err := p.doJSON(request, &body)
+	if err != nil {
+		return Token{}, err
+	}
+	// GitHub responde 200 con el error en el cuerpo
+	if body.Error != "" {
+		return Token{}, fmt.Errorf("social: error del endpoint de tokens: %s %s", body.Error, body.ErrorDescription)
+	}
+	if status >= 300 {
+		return Token{}, fmt.Errorf("social: el endpoint de tokens respondió %d", status)
+	}
+	if body.AccessToken == "" {
+		return Token{}, fmt.Errorf("social: el endpoint de tokens no devolvió access_token")
+	}
+
+	token := Token{
+		AccessToken:  body.AccessToken,
+		TokenType:    body.TokenType,
+		RefreshToken: body.RefreshToken,
+		IDToken:      body.IDToken,
+	}
+	// Algunos proveedores envían expires_in como texto
+	if seconds, err := strconv.Atoi(strings.Trim(string(body.ExpiresIn), `"`)); err == nil && seconds > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
+
+// User obtiene el perfil del usuario. En proveedores OpenID Connect se valida el id_token (firma,
+// emisor, audiencia, expiración y nonce) y se completa con el endpoint userinfo; en OAuth2 plano
+// se usa solo userinfo.
+func (p *Provider) User(ctx context.Context, token Token, nonce string) (User, error) {
+	claims := map[string]any{}
+
+	if p.OIDC() && token.IDToken != "" {
+		idClaims, err := p.verifyIDToken(ctx, token.IDToken, nonce)
+		if err != nil {
+			return User{}, err
+		}
+		claims = idClaims
+	}
+
+	if p.config.UserInfoURL != "" {
+		info := map[string]any{}
+		if err := p.getJSON(ctx, p.config.UserInfoURL, token.AccessToken, &info); err != nil {
+			return User{}, err
+		}
+		// El sub de userinfo debe coincidir con el del id_token (OpenID Connect Core 5.3.2)
+		if sub, ok := claims["sub"]; ok && stringClaim(info, "sub") != "" && stringClaim(info, "sub") != fmt.Sprint(sub) {
+			return User{}, fmt.Errorf("%w: el sub de userinfo no coincide", ErrInvalidIDToken)
+		}
+		for key, value := range info {
+			if _, exists := claims[key]; !exists {
+				claims[key] = value
+			}
+		}
+	}
+
+	user := userFromClaims(claims)
+	if user.Subject == "" {
+		return User{}, ErrMissingSubject
+	}
+
+	if p.config.EmailsURL != "" && !user.EmailVerified {
+		email, err := p.primaryVerifiedEmail(ctx, token.AccessToken)
+		if err != nil {
+			return User{}, err
+		}
+		if email != "" {
+			user.Email = email
+			user.EmailVerified = true
+		}
+	}
+
+	return user, nil
+}
+
+// primaryVerifiedEmail consulta la lista de emails del usuario y devuelve el principal si está verificado
+func (p *Provider) primaryVerifiedEmail(ctx context.Context, accessToken string) (string, error) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(ctx, p.config.EmailsURL, accessToken, &emails); err != nil {
+		return "", err
+	}
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			return email.Email, nil
+		}
+	}
+	return "", nil
+}
+
+// userFromClaims normaliza los claims estándar de OpenID Connect y los campos habituales de
+// proveedores OAuth2 (GitHub usa id, login y avatar_url)
+func userFromClaims(claims map[string]any) User {
+	user := User{
+		Subject:   stringClaim(claims, "sub"),
+		Email:     stringClaim(claims, "email"),
+		Name:      stringClaim(claims, "name"),
+		AvatarURL: stringClaim(claims, "picture"),
+	}
+	if user.Subject == "" {
+		user.Subject = stringClaim(claims, "id")
+	}
+	if user.Name == "" {
+		user.Name = stringClaim(claims, "preferred_username")
+	}
+	if user.Name == "" {
+		user.Name = stringClaim(claims, "login")
+	}
+	if user.AvatarURL == "" {
+		user.AvatarURL = stringClaim(claims, "avatar_url")
+	}
+
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		user.EmailVerified = verified
+	case string:
+		user.EmailVerified = verified == "true"
+	}
+	return user
+}
+
+// stringClaim devuelve un claim como texto; los números se formatean sin exponente
+func stringClaim(claims map[string]any, key string) string {
+	switch value := claims[key].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case json.Number:
+		return value.String()
+	}
+	return ""
+}
+
+// getJSON hace un GET autenticado con el access token y decodifica la respuesta
+func (p *Provider) getJSON(ctx context.Context, endpoint string, accessToken string, target any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	status, err := p.doJSON(request, target)
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("social: %s respondió %d", request.URL.Host, status)
+	}
+	return nil
+}
+
+// doJSON ejecuta la petición y decodifica el JSON aunque el estado sea de error, porque el endpoint
+// de tokens informa los errores con error y error_description en el cuerpo
+func (p *Provider) doJSON(request *http.Request, target any) (int, error) {
+	response, err := p.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if err != nil {
+		return response.StatusCode, err
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		if response.StatusCode >= 300 {
+			return response.StatusCode, fmt.Errorf("social: %s respondió %d", request.URL.Host, response.StatusCode)
+		}
+		return response.StatusCode, fmt.Errorf("social: respuesta inválida de %s: %w", request.URL.Host, err)
+	}
+	return response.StatusCode, nil
+}
@@ -0,0 +1,299 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	// Ejemplo del apéndice B de RFC 7636
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	if got, want := CodeChallenge(verifier), "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Fatalf("CodeChallenge = %q, want %q", got, want)
+	}
+}
+
+func TestAuthCodeURLUsesDiscoveryAndPKCE(t *testing.T) {
+	provider := newTestProvider(t)
+	client := New(provider.oidcConfig(), provider.server.Client())
+
+	authURL, err := client.AuthCodeURL(context.Background(), "the-state", "the-verifier", "the-nonce")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := parsed.Scheme+"://"+parsed.Host+parsed.Path, provider.issuer()+"/authorize"; got != want {
+		t.Errorf("authorization endpoint = %q, want %q", got, want)
+	}
+
+	query := parsed.Query()
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"state":                 "the-state",
+		"code_challenge":        CodeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+		"scope":                 "openid email profile",
+		"nonce":                 "the-nonce",
+	}
+	for key, want := range expected {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if query.Has("code_verifier") {
+		t.Error("the code_verifier must not be sent to the authorization endpoint")
+	}
+}
+
+func TestAuthCodeURLOmitsNonceForOAuth2Providers(t *testing.T) {
+	provider := newTestProvider(t)
+	client := New(provider.oauth2Config(), provider.server.Client())
+
+	authURL, err := client.AuthCodeURL(context.Background(), "the-state", "the-verifier", "the-nonce")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if strings.Contains(authURL, "nonce=") {
+		t.Errorf("AuthCodeURL = %q, want no nonce", authURL)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.discoveredIssuer = "https://evil.example"
+	client := New(provider.oidcConfig(), provider.server.Client())
+
+	if _, err := client.AuthCodeURL(context.Background(), "state", "verifier", "nonce"); err == nil {
+		t.Fatal("AuthCodeURL succeeded with a discovery document for another issuer")
+	}
+}
+
+func TestExchangeSendsCodeVerifier(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.idTokenClaims = provider.defaultIDTokenClaims("nonce")
+	client := New(provider.oidcConfig(), provider.server.Client())
+
+	verifier, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := client.AuthCodeURL(context.Background(), "state", verifier, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.authorize(t, authURL)
+
+	token, err := client.Exchange(context.Background(), testCode, verifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if token.AccessToken != testAccessToken || token.RefreshToken != "provider-refresh-token" {
+		t.Errorf("token = %+v", token)
+	}
+	if remaining := time.Until(token.ExpiresAt); remaining < 59*time.Minute || remaining > time.Hour {
+		t.Errorf("ExpiresAt in %v, want about one hour", remaining)
+	}
+
+	user, err := client.User(context.Background(), token, "nonce")
+	if err != nil {
+		t.Fatalf("User: %v", err)
+	}
+	if user.Subject != "external-42" || user.Email != "ana@example.com" || !user.EmailVerified {
+		t.Errorf("User = %+v", user)
+	}
+}
+
+func TestExchangeRejectsWrongVerifierAndCode(t *testing.T) {
+	provider := newTestProvider(t)
+	client := New(provider.oidcConfig(), provider.server.Client())
+
+	authURL, err := client.AuthCodeURL(context.Background(), "state", "the-verifier", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.authorize(t, authURL)
+
+	if _, err := client.Exchange(context.Background(), testCode, "another-verifier"); !hasErrorText(err, "invalid_grant") {
+		t.Errorf("Exchange with another verifier: err = %v, want invalid_grant", err)
+	}
+	if _, err := client.Exchange(context.Background(), "another-code", "the-verifier"); !hasErrorText(err, "invalid_grant") {
+		t.Errorf("Exchange with another code: err = %v, want invalid_grant", err)
+	}
+}
+
+func TestExchangeReportsErrorsInBody(t *testing.T) {
+	provider := newTestProvider(t)
+	// GitHub responde 200 con el error en el cuerpo
+	provider.tokenBody = map[string]any{"error": "bad_verification_code", "error_description": "The code passed is incorrect or expired."}
+	client := New(provider.oauth2Config(), provider.server.Client())
+
+	if _, err := client.Exchange(context.Background(), testCode, "verifier"); !hasErrorText(err, "bad_verification_code") {
+		t.Fatalf("err = %v, want bad_verification_code", err)
+	}
+}
+
+func TestExchangeAcceptsExpiresInAsText(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.tokenBody = map[string]any{"access_token": testAccessToken, "token_type": "bearer", "expires_in": "600"}
+	client := New(provider.oauth2Config(), provider.server.Client())
+
+	token, err := client.Exchange(context.Background(), testCode, "verifier")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if remaining := time.Until(token.ExpiresAt); remaining < 9*time.Minute || remaining > 10*time.Minute {
+		t.Errorf("ExpiresAt in %v, want about ten minutes", remaining)
+	}
+}
+
+func TestExchangeRequiresAccessToken(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.tokenBody = map[string]any{"token_type": "bearer"}
+	client := New(provider.oauth2Config(), provider.server.Client())
+
+	if _, err := client.Exchange(context.Background(), testCode, "verifier"); err == nil {
+		t.Fatal("Exchange succeeded without access_token")
+	}
+}
+
+func TestUserMergesIDTokenAndUserInfo(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.userInfo = map[string]any{"sub": "external-42", "email": "other@example.com", "name": "Ana Pérez", "picture": "https://cdn.example/ana.png"}
+	client := New(provider.oidcConfig(), provider.server.Client())
+
+	idToken, err := provider.signIDToken(provider.defaultIDTokenClaims("the-nonce"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := client.User(context.Background(), Token{AccessToken: testAccessToken, IDToken: idToken}, "the-nonce")
+	if err != nil {
+		t.Fatalf("User: %v", err)
+	}
+
+	// Los claims del id_token firmado tienen prioridad sobre los de userinfo
+	want := User{Subject: "external-42", Email: "ana@example.com", EmailVerified: true, Name: "Ana Pérez", AvatarURL: "https://cdn.example/ana.png"}
+	if user != want {
+		t.Errorf("User = %+v, want %+v", user, want)
+	}
+}
+
+func TestUserRejectsInvalidIDTokens(t *testing.T) {
+	provider := newTestProvider(t)
+	otherKey := newTestProvider(t).key
+
+	cases := []struct {
+		name   string
+		modify func(claims map[string]any)
+		key    bool // Firmar con una llave que no está en el JWKS
+		nonce  string
+	}{
+		{name: "other nonce", nonce: "another-nonce"},
+		{name: "other audience", modify: func(claims map[string]any) { claims["aud"] = "another-client" }},
+		{name: "other issuer", modify: func(claims map[string]any) { claims["iss"] = "https://evil.example" }},
+		{name: "expired", modify: func(claims map[string]any) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{name: "without expiration", modify: func(claims map[string]any) { delete(claims, "exp") }},
+		{name: "unknown key", key: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := provider.defaultIDTokenClaims("the-nonce")
+			if tc.modify != nil {
+				tc.modify(claims)
+			}
+			provider.idTokenKey = nil
+			if tc.key {
+				provider.idTokenKey = otherKey
+			}
+			idToken, err := provider.signIDToken(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nonce := "the-nonce"
+			if tc.nonce != "" {
+				nonce = tc.nonce
+			}
+			client := New(provider.oidcConfig(), provider.server.Client())
+			_, err = client.User(context.Background(), Token{AccessToken: testAccessToken, IDToken: idToken}, nonce)
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("err = %v, want %v", err, ErrInvalidIDToken)
+			}
+		})
+	}
+}
+
+func TestUserRejectsUserInfoForAnotherSubject(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.userInfo = map[string]any{"sub": "someone-else", "email": "ana@example.com"}
+	client := New(provider.oidcConfig(), provider.server.Client())
+
+	idToken, err := provider.signIDToken(provider.defaultIDTokenClaims("the-nonce"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.User(context.Background(), Token{AccessToken: testAccessToken, IDToken: idToken}, "the-nonce")
+	if !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidIDToken)
+	}
+}
+
+func TestUserParsesOAuth2Profiles(t *testing.T) {
+	provider := newTestProvider(t)
+	// Perfil al estilo de GitHub: id numérico, login y avatar_url, sin email verificado en el perfil
+	provider.userInfo = map[string]any{"id": 12345678, "login": "ana", "email": "public@example.com", "avatar_url": "https://cdn.example/ana.png"}
+	provider.emails = []map[string]any{
+		{"email": "old@example.com", "primary": false, "verified": true},
+		{"email": "ana@example.com", "primary": true, "verified": true},
+	}
+	client := New(provider.oauth2Config(), provider.server.Client())
+
+	user, err := client.User(context.Background(), Token{AccessToken: testAccessToken}, "")
+	if err != nil {
+		t.Fatalf("User: %v", err)
+	}
+
+	want := User{Subject: "12345678", Email: "ana@example.com", EmailVerified: true, Name: "ana", AvatarURL: "https://cdn.example/ana.png"}
+	if user != want {
+		t.Errorf("User = %+v, want %+v", user, want)
+	}
+}
+
+func TestUserKeepsUnverifiedEmailWithoutVerifiedPrimary(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.userInfo = map[string]any{"id": 7, "login": "ana", "email": "ana@example.com"}
+	provider.emails = []map[string]any{{"email": "ana@example.com", "primary": true, "verified": false}}
+	client := New(provider.oauth2Config(), provider.server.Client())
+
+	user, err := client.User(context.Background(), Token{AccessToken: testAccessToken}, "")
+	if err != nil {
+		t.Fatalf("User: %v", err)
+	}
+	if user.EmailVerified {
+		t.Errorf("EmailVerified = true for a provider email that is not verified")
+	}
+}
+
+func TestUserRequiresSubject(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.userInfo = map[string]any{"email": "ana@example.com"}
+	config := provider.oauth2Config()
+	config.EmailsURL = ""
+	client := New(config, provider.server.Client())
+
+	if _, err := client.User(context.Background(), Token{AccessToken: testAccessToken}, ""); !errors.Is(err, ErrMissingSubject) {
+		t.Fatalf("err = %v, want %v", err, ErrMissingSubject)
+	}
+}
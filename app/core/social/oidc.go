@@ -0,0 +1,201 @@
+package social
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// discover completa los endpoints que falten con /.well-known/openid-configuration del issuer.
+// Solo se consulta una vez por proveedor; si falla, el error se repite en cada uso.
+func (p *Provider) discover(ctx context.Context) error {
+	if !p.OIDC() {
+		return nil
+	}
+
+	p.discoverOnce.Do(func() {
+		if p.config.AuthURL != "" && p.config.TokenURL != "" && p.config.JWKSURL != "" {
+			return
+		}
+
+		var document struct {
+			Issuer                string `json:"issuer"`
+			AuthorizationEndpoint string `json:"authorization_endpoint"`
+			TokenEndpoint         string `json:"token_endpoint"`
+			UserInfoEndpoint      string `json:"userinfo_endpoint"`
+			JWKSURI               string `json:"jwks_uri"`
+		}
+		endpoint := strings.TrimRight(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := p.getJSON(ctx, endpoint, "", &document); err != nil {
+			p.discoverErr = fmt.Errorf("social: descubrimiento de %s: %w", p.config.Name, err)
+			return
+		}
+		if document.Issuer != p.config.Issuer {
+			p.discoverErr = fmt.Errorf("social: el issuer descubierto %q no coincide con %q", document.Issuer, p.config.Issuer)
+			return
+		}
+
+		if p.config.AuthURL == "" {
+			p.config.AuthURL = document.AuthorizationEndpoint
+		}
+		if p.config.TokenURL == "" {
+			p.config.TokenURL = document.TokenEndpoint
+		}
+		if p.config.UserInfoURL == "" {
+			p.config.UserInfoURL = document.UserInfoEndpoint
+		}
+		if p.config.JWKSURL == "" {
+			p.config.JWKSURL = document.JWKSURI
+		}
+	})
+	return p.discoverErr
+}
+
+// verifyIDToken valida la firma del id_token con las llaves JWKS del proveedor y sus claims
+func (p *Provider) verifyIDToken(ctx context.Context, raw string, nonce string) (map[string]any, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if nonce != "" && claims["nonce"] != nonce {
+		return nil, fmt.Errorf("%w: nonce no coincide", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// signingKey busca la llave por kid y vuelve a descargar el JWKS si no la encuentra,
+// para soportar la rotación de llaves del proveedor
+func (p *Provider) signingKey(ctx context.Context, kid string) (any, error) {
+	p.keysMutex.Lock()
+	defer p.keysMutex.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if err := p.fetchKeys(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("llave %q no encontrada en el JWKS", kid)
+}
+
+// lookupKey devuelve la llave con ese kid; sin kid solo vale si el JWKS tiene una única llave
+func (p *Provider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// jsonWebKey es una llave pública JWK (RFC 7517) de tipo RSA o EC
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys descarga el JWKS del proveedor; las llaves con formato desconocido se ignoran
+func (p *Provider) fetchKeys(ctx context.Context) error {
+	if p.config.JWKSURL == "" {
+		return fmt.Errorf("%w: jwks", ErrMissingEndpoint)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.config.JWKSURL, "", &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+	return nil
+}
+
+// publicKey convierte la JWK en una llave de crypto/rsa o crypto/ecdsa
+func (jwk jsonWebKey) publicKey() (any, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("exponente RSA inválido")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curva %q no soportada", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("punto EC inválido")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("tipo de llave %q no soportado", jwk.Kty)
+}
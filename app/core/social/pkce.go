@@ -0,0 +1,27 @@
+package social
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// RandomString genera un valor aleatorio de 32 bytes en base64url, útil para state y nonce
+func RandomString() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// NewCodeVerifier genera el code_verifier de PKCE (RFC 7636)
+func NewCodeVerifier() (string, error) {
+	return RandomString()
+}
+
+// CodeChallenge calcula el code_challenge S256 de un code_verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,227 @@
+package social
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testClientID y testClientSecret son las credenciales del cliente registrado en el proveedor de prueba
+const (
+	testClientID     = "semita-client"
+	testClientSecret = "semita-secret"
+	testRedirectURL  = "https://app.example/auth/social/test/callback"
+	testCode         = "authorization-code"
+	testAccessToken  = "provider-access-token"
+)
+
+// testProvider es un proveedor OAuth2/OpenID Connect en un httptest.Server. Valida el código, el
+// secreto del cliente y el code_verifier de PKCE igual que uno real; cada campo permite alterar sus
+// respuestas para probar los rechazos.
+type testProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mutex            sync.Mutex
+	challenge        string          // code_challenge recibido en la autorización
+	idTokenClaims    jwt.MapClaims   // Claims del id_token; nil para no emitirlo
+	idTokenKey       *rsa.PrivateKey // Llave con la que se firma el id_token; nil usa la del JWKS
+	tokenBody        map[string]any  // Reemplaza la respuesta del endpoint de tokens
+	userInfo         map[string]any
+	emails           []map[string]any
+	discoveredIssuer string // Issuer del documento de descubrimiento; vacío usa el del servidor
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &testProvider{key: key, kid: "test-key"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/token", provider.token)
+	mux.HandleFunc("/userinfo", provider.userinfo)
+	mux.HandleFunc("/emails", provider.emailList)
+	mux.HandleFunc("/jwks", provider.jwks)
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+
+	provider.userInfo = map[string]any{"sub": "external-42", "email": "ana@example.com", "email_verified": true, "name": "Ana"}
+	return provider
+}
+
+// issuer es la URL del proveedor, que también es el issuer de OpenID Connect
+func (p *testProvider) issuer() string {
+	return p.server.URL
+}
+
+// oidcConfig configura el proveedor como OpenID Connect: los endpoints salen del descubrimiento
+func (p *testProvider) oidcConfig() Config {
+	return Config{
+		Name:         "test",
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Issuer:       p.issuer(),
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+// oauth2Config configura el proveedor como OAuth2 plano, al estilo de GitHub
+func (p *testProvider) oauth2Config() Config {
+	return Config{
+		Name:         "test",
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		AuthURL:      p.issuer() + "/authorize",
+		TokenURL:     p.issuer() + "/token",
+		UserInfoURL:  p.issuer() + "/userinfo",
+		EmailsURL:    p.issuer() + "/emails",
+	}
+}
+
+// authorize simula que el usuario aprobó la autorización: el proveedor recuerda el code_challenge
+// de la URL a la que se lo redirigió
+func (p *testProvider) authorize(t *testing.T, authURL string) {
+	t.Helper()
+
+	request := httptest.NewRequest(http.MethodGet, authURL, nil)
+	query := request.URL.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.challenge = query.Get("code_challenge")
+}
+
+// signIDToken firma un id_token con la llave del JWKS o con la indicada en idTokenKey
+func (p *testProvider) signIDToken(claims jwt.MapClaims) (string, error) {
+	key := p.key
+	if p.idTokenKey != nil {
+		key = p.idTokenKey
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	return token.SignedString(key)
+}
+
+// defaultIDTokenClaims son los claims de un id_token válido para el cliente de prueba
+func (p *testProvider) defaultIDTokenClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            p.issuer(),
+		"aud":            testClientID,
+		"sub":            "external-42",
+		"email":          "ana@example.com",
+		"email_verified": true,
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func (p *testProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := p.discoveredIssuer
+	if issuer == "" {
+		issuer = p.issuer()
+	}
+	writeTestJSON(w, http.StatusOK, map[string]any{
+		"issuer":                 issuer,
+		"authorization_endpoint": p.issuer() + "/authorize",
+		"token_endpoint":         p.issuer() + "/token",
+		"userinfo_endpoint":      p.issuer() + "/userinfo",
+		"jwks_uri":               p.issuer() + "/jwks",
+	})
+}
+
+func (p *testProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.tokenBody != nil {
+		writeTestJSON(w, http.StatusOK, p.tokenBody)
+		return
+	}
+
+	if r.Method != http.MethodPost || r.PostFormValue("grant_type") != "authorization_code" {
+		writeTestJSON(w, http.StatusBadRequest, map[string]any{"error": "unsupported_grant_type"})
+		return
+	}
+	if r.PostFormValue("client_id") != testClientID || r.PostFormValue("client_secret") != testClientSecret {
+		writeTestJSON(w, http.StatusUnauthorized, map[string]any{"error": "invalid_client"})
+		return
+	}
+	if r.PostFormValue("code") != testCode || r.PostFormValue("redirect_uri") != testRedirectURL {
+		writeTestJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+		return
+	}
+	if p.challenge == "" || CodeChallenge(r.PostFormValue("code_verifier")) != p.challenge {
+		writeTestJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	body := map[string]any{"access_token": testAccessToken, "token_type": "Bearer", "refresh_token": "provider-refresh-token", "expires_in": 3600}
+	if p.idTokenClaims != nil {
+		idToken, err := p.signIDToken(p.idTokenClaims)
+		if err != nil {
+			writeTestJSON(w, http.StatusInternalServerError, map[string]any{"error": "server_error"})
+			return
+		}
+		body["id_token"] = idToken
+	}
+	writeTestJSON(w, http.StatusOK, body)
+}
+
+func (p *testProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+		writeTestJSON(w, http.StatusUnauthorized, map[string]any{"error": "invalid_token"})
+		return
+	}
+	writeTestJSON(w, http.StatusOK, p.userInfo)
+}
+
+func (p *testProvider) emailList(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+		writeTestJSON(w, http.StatusUnauthorized, map[string]any{"error": "invalid_token"})
+		return
+	}
+	writeTestJSON(w, http.StatusOK, p.emails)
+}
+
+func (p *testProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeTestJSON(w, http.StatusOK, map[string]any{"keys": []map[string]any{{
+		"kid": p.kid,
+		"kty": "RSA",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// hasErrorText indica si el error menciona el texto, para los errores del proveedor que no son centinelas
+func hasErrorText(err error, text string) bool {
+	return err != nil && strings.Contains(err.Error(), text)
+}
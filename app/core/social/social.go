@@ -0,0 +1,92 @@
+// Package social implementa un cliente OAuth2/OpenID Connect genérico para iniciar sesión con
+// proveedores externos (Google, GitHub, Microsoft, Keycloak, ...). Cubre la redirección con state,
+// nonce y PKCE, el intercambio del código y la obtención del perfil del usuario externo.
+package social
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidState    = errors.New("social: state inválido o expirado")
+	ErrProviderDenied  = errors.New("social: el usuario o el proveedor rechazó la autorización")
+	ErrMissingSubject  = errors.New("social: el proveedor no devolvió el identificador del usuario")
+	ErrInvalidIDToken  = errors.New("social: id_token inválido")
+	ErrMissingEndpoint = errors.New("social: el proveedor no tiene configurado el endpoint")
+)
+
+// Config describe un proveedor. Si Issuer está definido y faltan endpoints, se completan con el
+// documento de descubrimiento de OpenID Connect; con Issuer también se valida el id_token.
+type Config struct {
+	Name         string // Nombre usado en las rutas y en user_identities.provider
+	Label        string // Texto del botón de inicio de sesión
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Issuer       string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+	EmailsURL    string // Endpoint estilo GitHub con la lista de emails verificados del usuario
+	Scopes       []string
+}
+
+// Provider es un proveedor listo para usar. Guarda en memoria el descubrimiento y las llaves JWKS.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	discoverOnce sync.Once
+	discoverErr  error
+
+	keysMutex sync.Mutex
+	keys      map[string]any
+}
+
+// New crea un proveedor. Con client nil se usa un cliente HTTP con timeout de 10 segundos.
+func New(config Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{config: config, client: client}
+}
+
+// Name devuelve el nombre del proveedor
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// Label devuelve el texto para mostrar en la interfaz
+func (p *Provider) Label() string {
+	if p.config.Label != "" {
+		return p.config.Label
+	}
+	return p.config.Name
+}
+
+// OIDC indica si el proveedor es OpenID Connect, es decir, si emite id_token validables
+func (p *Provider) OIDC() bool {
+	return p.config.Issuer != ""
+}
+
+// Token es la respuesta del endpoint de tokens
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	IDToken      string
+	ExpiresAt    time.Time // Cero si el proveedor no informa expires_in
+}
+
+// User es el perfil del usuario en el proveedor externo
+type User struct {
+	Subject       string // Identificador estable del usuario en el proveedor
+	Email         string
+	EmailVerified bool
+	Name          string
+	AvatarURL     string
+}
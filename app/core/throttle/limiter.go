@@ -0,0 +1,104 @@
+package throttle
+
+import (
+	"fmt"
+	"semita/app/utils"
+	"time"
+)
+
+// Limiter limita la cantidad de intentos por clave dentro de una ventana de tiempo
+type Limiter struct {
+	store Store
+}
+
+// New crea un limitador sobre el store indicado
+func New(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Default devuelve el limitador sobre el store configurado en THROTTLE_DRIVER
+func Default() *Limiter {
+	return New(DefaultStore())
+}
+
+// Attempt registra un intento y devuelve false, junto con el tiempo restante de la ventana,
+// si se superó maxAttempts. Si el store falla, se deja pasar la petición para no bloquear la aplicación.
+func (l *Limiter) Attempt(key string, maxAttempts int, decay time.Duration) (bool, time.Duration) {
+	attempts, resetAt, err := l.store.Hit(key, decay)
+	if err != nil {
+		logStoreError(err)
+		return true, 0
+	}
+	if attempts > maxAttempts {
+		return false, remaining(resetAt)
+	}
+	return true, 0
+}
+
+// TooManyAttempts indica si la clave ya alcanzó maxAttempts, sin registrar un intento nuevo
+func (l *Limiter) TooManyAttempts(key string, maxAttempts int) (bool, time.Duration) {
+	attempts, resetAt, err := l.store.Get(key)
+	if err != nil {
+		logStoreError(err)
+		return false, 0
+	}
+	if attempts >= maxAttempts {
+		return true, remaining(resetAt)
+	}
+	return false, 0
+}
+
+// Hit suma un intento a la clave y devuelve el total de la ventana
+func (l *Limiter) Hit(key string, decay time.Duration) int {
+	attempts, _, err := l.store.Hit(key, decay)
+	if err != nil {
+		logStoreError(err)
+	}
+	return attempts
+}
+
+// Attempts devuelve los intentos de la ventana vigente
+func (l *Limiter) Attempts(key string) int {
+	attempts, _, err := l.store.Get(key)
+	if err != nil {
+		logStoreError(err)
+	}
+	return attempts
+}
+
+// Clear reinicia el contador de la clave
+func (l *Limiter) Clear(key string) {
+	if err := l.store.Clear(key); err != nil {
+		logStoreError(err)
+	}
+}
+
+// Attempt registra un intento en el limitador por defecto
+func Attempt(key string, maxAttempts int, decay time.Duration) (bool, time.Duration) {
+	return Default().Attempt(key, maxAttempts, decay)
+}
+
+// GC elimina los contadores vencidos del store por defecto
+func GC() (int64, error) {
+	return DefaultStore().GC()
+}
+
+// RetryAfterSeconds redondea hacia arriba el tiempo restante para la cabecera Retry-After
+func RetryAfterSeconds(retryAfter time.Duration) int {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+func remaining(resetAt time.Time) time.Duration {
+	if wait := time.Until(resetAt); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+func logStoreError(err error) {
+	utils.Logs("ERROR", fmt.Sprintf("Error en el store de throttle: %v", err))
+}
@@ -0,0 +1,51 @@
+package throttle
+
+import (
+	"strconv"
+	"time"
+)
+
+// Result es el estado de un limitador tras registrar una petición
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAt    time.Time     // Fin de la ventana actual
+	RetryAfter time.Duration // Tiempo de espera cuando Allowed es false
+}
+
+// SlidingAttempt registra una petición en una ventana deslizante de duración window.
+// Se aproxima con dos ventanas fijas: los intentos de la ventana anterior se ponderan por
+// la parte de ella que todavía cae dentro de la ventana deslizante.
+func (l *Limiter) SlidingAttempt(key string, maxAttempts int, window time.Duration) Result {
+	now := time.Now()
+	current := now.UnixNano() / int64(window)
+	windowStart := time.Unix(0, current*int64(window))
+	resetAt := windowStart.Add(window)
+
+	result := Result{Allowed: true, Limit: maxAttempts, Remaining: maxAttempts, ResetAt: resetAt}
+
+	// La ventana actual se guarda el doble de tiempo para poder leerla como "anterior"
+	attempts, _, err := l.store.Hit(key+":"+strconv.FormatInt(current, 10), 2*window)
+	if err != nil {
+		logStoreError(err)
+		return result
+	}
+	previous, _, err := l.store.Get(key + ":" + strconv.FormatInt(current-1, 10))
+	if err != nil {
+		logStoreError(err)
+	}
+
+	weight := 1 - float64(now.Sub(windowStart))/float64(window)
+	estimated := int(float64(previous)*weight) + attempts
+
+	if estimated > maxAttempts {
+		result.Allowed = false
+		result.Remaining = 0
+		result.RetryAfter = resetAt.Sub(now)
+		return result
+	}
+
+	result.Remaining = maxAttempts - estimated
+	return result
+}
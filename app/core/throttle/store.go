@@ -0,0 +1,152 @@
+package throttle
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"semita/app/models"
+	"semita/app/utils"
+	"sync"
+	"time"
+)
+
+// Store guarda los contadores de intentos por clave dentro de una ventana de tiempo
+type Store interface {
+	// Hit suma un intento y devuelve el total de la ventana y cuándo termina
+	Hit(key string, decay time.Duration) (int, time.Time, error)
+	// Get devuelve los intentos de la ventana vigente; 0 si no hay ninguna
+	Get(key string) (int, time.Time, error)
+	// Clear reinicia el contador de la clave
+	Clear(key string) error
+	// GC elimina los contadores vencidos
+	GC() (int64, error)
+}
+
+var (
+	defaultStore     Store
+	defaultStoreOnce sync.Once
+)
+
+// DefaultStore devuelve el store configurado en THROTTLE_DRIVER: memory (por defecto) o database.
+// Con varias instancias de la aplicación se debe usar database para compartir los contadores.
+func DefaultStore() Store {
+	defaultStoreOnce.Do(func() {
+		switch driver := os.Getenv("THROTTLE_DRIVER"); driver {
+		case "", "memory":
+			defaultStore = NewMemoryStore()
+		case "database":
+			defaultStore = NewDatabaseStore()
+		default:
+			utils.Logs("ERROR", fmt.Sprintf("Driver de throttle desconocido %q, se usará memory", driver))
+			defaultStore = NewMemoryStore()
+		}
+	})
+	return defaultStore
+}
+
+// memoryWindow cuenta los intentos de una clave dentro de una ventana fija
+type memoryWindow struct {
+	attempts int
+	resetAt  time.Time
+}
+
+// memorySweepInterval es cada cuánto Hit elimina los contadores vencidos del MemoryStore, sin
+// depender de que el scheduler ejecute GC
+const memorySweepInterval = time.Minute
+
+// MemoryStore guarda los contadores en memoria; se pierden al reiniciar y no se comparten entre instancias
+type MemoryStore struct {
+	mutex     sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+// NewMemoryStore crea un store en memoria vacío
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: map[string]*memoryWindow{}, lastSweep: time.Now()}
+}
+
+func (s *MemoryStore) Hit(key string, decay time.Duration) (int, time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		s.sweep(now)
+	}
+
+	window, ok := s.windows[key]
+	if !ok || !now.Before(window.resetAt) {
+		window = &memoryWindow{resetAt: now.Add(decay)}
+		s.windows[key] = window
+	}
+
+	window.attempts++
+	return window.attempts, window.resetAt, nil
+}
+
+func (s *MemoryStore) Get(key string) (int, time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	window, ok := s.windows[key]
+	if !ok || !time.Now().Before(window.resetAt) {
+		return 0, time.Time{}, nil
+	}
+	return window.attempts, window.resetAt, nil
+}
+
+func (s *MemoryStore) Clear(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.windows, key)
+	return nil
+}
+
+func (s *MemoryStore) GC() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sweep(time.Now()), nil
+}
+
+// sweep elimina los contadores vencidos; se llama con el mutex tomado
+func (s *MemoryStore) sweep(now time.Time) int64 {
+	var deleted int64
+	for key, window := range s.windows {
+		if !now.Before(window.resetAt) {
+			delete(s.windows, key)
+			deleted++
+		}
+	}
+	s.lastSweep = now
+	return deleted
+}
+
+// DatabaseStore guarda los contadores en la tabla rate_limits para compartirlos entre instancias
+type DatabaseStore struct{}
+
+// NewDatabaseStore crea el store de base de datos
+func NewDatabaseStore() *DatabaseStore {
+	return &DatabaseStore{}
+}
+
+func (s *DatabaseStore) Hit(key string, decay time.Duration) (int, time.Time, error) {
+	return models.HitRateLimit(key, decay)
+}
+
+func (s *DatabaseStore) Get(key string) (int, time.Time, error) {
+	attempts, resetAt, err := models.GetRateLimit(key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, nil
+	}
+	return attempts, resetAt, err
+}
+
+func (s *DatabaseStore) Clear(key string) error {
+	return models.DeleteRateLimit(key)
+}
+
+func (s *DatabaseStore) GC() (int64, error) {
+	return models.PurgeRateLimits(1000)
+}
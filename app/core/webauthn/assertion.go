@@ -0,0 +1,86 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// AssertionResponse es el PublicKeyCredential que devuelve navigator.credentials.get(),
+// con los campos binarios en base64url
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// CredentialID devuelve el ID de la credencial usada, para buscarla antes de verificar
+func (r AssertionResponse) CredentialID() ([]byte, error) {
+	return decodeBase64URL(r.RawID)
+}
+
+// UserHandle devuelve el ID de usuario que guardó el autenticador; vacío si no lo envió
+func (r AssertionResponse) UserHandle() []byte {
+	handle, _ := decodeBase64URL(r.Response.UserHandle)
+	return handle
+}
+
+// VerifyAssertion valida la ceremonia de autenticación con la credencial guardada y devuelve
+// el nuevo contador de firmas. Un contador que no avanza indica un autenticador clonado.
+func (rp RelyingParty) VerifyAssertion(challenge string, credential Credential, response AssertionResponse, requireUserVerification bool) (uint32, error) {
+	if response.Type != "public-key" {
+		return 0, ErrInvalidClientData
+	}
+
+	rawID, err := response.CredentialID()
+	if err != nil || !bytes.Equal(rawID, credential.ID) {
+		return 0, ErrCredentialMismatch
+	}
+
+	clientDataJSON, err := decodeBase64URL(response.Response.ClientDataJSON)
+	if err != nil {
+		return 0, ErrInvalidClientData
+	}
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := decodeBase64URL(response.Response.AuthenticatorData)
+	if err != nil {
+		return 0, ErrInvalidAuthenticatorData
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if err := authData.verify(rp, requireUserVerification); err != nil {
+		return 0, err
+	}
+
+	signature, err := decodeBase64URL(response.Response.Signature)
+	if err != nil {
+		return 0, ErrInvalidSignature
+	}
+	publicKey, algorithm, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := verifySignature(publicKey, algorithm, signedData, signature); err != nil {
+		return 0, err
+	}
+
+	// Los autenticadores que no llevan contador (p. ej. passkeys sincronizadas) envían siempre 0
+	if (authData.SignCount != 0 || credential.SignCount != 0) && authData.SignCount <= credential.SignCount {
+		return 0, ErrClonedAuthenticator
+	}
+
+	return authData.SignCount, nil
+}
@@ -0,0 +1,148 @@
+package webauthn
+
+import (
+	"errors"
+	"testing"
+)
+
+// registerTestCredential registra la credencial del autenticador y devuelve la que se guardaría
+func registerTestCredential(t *testing.T, authenticator *softAuthenticator) Credential {
+	t.Helper()
+
+	challenge := testChallenge(t)
+	credential, err := testRP.VerifyRegistration(challenge, authenticator.register(t, challenge, testRP.Origin, "none", nil))
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	return credential
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	for _, algorithm := range []int{AlgES256, AlgRS256} {
+		authenticator := newSoftAuthenticator(t, algorithm)
+		credential := registerTestCredential(t, authenticator)
+
+		challenge := testChallenge(t)
+		signCount, err := testRP.VerifyAssertion(challenge, credential, authenticator.assert(t, challenge, testRP.Origin), true)
+		if err != nil {
+			t.Fatalf("algorithm %d: VerifyAssertion: %v", algorithm, err)
+		}
+		if signCount != authenticator.signCount {
+			t.Errorf("algorithm %d: sign count = %d, want %d", algorithm, signCount, authenticator.signCount)
+		}
+	}
+}
+
+func TestVerifyAssertionRejectsTamperedCeremonies(t *testing.T) {
+	challenge := testChallenge(t)
+
+	cases := []struct {
+		name   string
+		modify func(t *testing.T, authenticator *softAuthenticator) AssertionResponse
+		want   error
+	}{
+		{
+			name: "bad challenge",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AssertionResponse {
+				return authenticator.assert(t, testChallenge(t), testRP.Origin)
+			},
+			want: ErrChallengeMismatch,
+		},
+		{
+			name: "bad origin",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AssertionResponse {
+				return authenticator.assert(t, challenge, "https://evil.example")
+			},
+			want: ErrOriginMismatch,
+		},
+		{
+			name: "bad rpIdHash",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AssertionResponse {
+				authenticator.rpID = "evil.example"
+				return authenticator.assert(t, challenge, testRP.Origin)
+			},
+			want: ErrRPIDMismatch,
+		},
+		{
+			name: "user not verified",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AssertionResponse {
+				authenticator.flags = flagUserPresent
+				return authenticator.assert(t, challenge, testRP.Origin)
+			},
+			want: ErrUserNotVerified,
+		},
+		{
+			name: "tampered signature",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AssertionResponse {
+				response := authenticator.assert(t, challenge, testRP.Origin)
+				other := authenticator.assert(t, challenge, testRP.Origin)
+				response.Response.Signature = other.Response.Signature
+				return response
+			},
+			want: ErrInvalidSignature,
+		},
+		{
+			name: "other credential",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AssertionResponse {
+				response := authenticator.assert(t, challenge, testRP.Origin)
+				response.RawID = encodeTestBase64([]byte("another-credential"))
+				return response
+			},
+			want: ErrCredentialMismatch,
+		},
+		{
+			name: "registration ceremony",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AssertionResponse {
+				response := authenticator.assert(t, challenge, testRP.Origin)
+				response.Response.ClientDataJSON = encodeTestBase64(testClientData(t, "webauthn.create", challenge, testRP.Origin))
+				return response
+			},
+			want: ErrInvalidClientData,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t, AlgES256)
+			credential := registerTestCredential(t, authenticator)
+
+			_, err := testRP.VerifyAssertion(challenge, credential, tc.modify(t, authenticator), true)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionRejectsSignCountRegression(t *testing.T) {
+	authenticator := newSoftAuthenticator(t, AlgES256)
+	credential := registerTestCredential(t, authenticator)
+
+	challenge := testChallenge(t)
+	signCount, err := testRP.VerifyAssertion(challenge, credential, authenticator.assert(t, challenge, testRP.Origin), true)
+	if err != nil {
+		t.Fatalf("VerifyAssertion: %v", err)
+	}
+	credential.SignCount = signCount
+
+	// Un clon parte del mismo contador que el original y repite un valor ya usado
+	authenticator.signCount = signCount - 1
+	challenge = testChallenge(t)
+	_, err = testRP.VerifyAssertion(challenge, credential, authenticator.assert(t, challenge, testRP.Origin), true)
+	if !errors.Is(err, ErrClonedAuthenticator) {
+		t.Fatalf("err = %v, want %v", err, ErrClonedAuthenticator)
+	}
+}
+
+func TestVerifyAssertionAcceptsAuthenticatorsWithoutCounter(t *testing.T) {
+	authenticator := newSoftAuthenticator(t, AlgES256)
+	credential := registerTestCredential(t, authenticator)
+
+	// Las passkeys sincronizadas envían siempre 0; assert suma uno antes de firmar
+	authenticator.signCount = ^uint32(0)
+	challenge := testChallenge(t)
+	signCount, err := testRP.VerifyAssertion(challenge, credential, authenticator.assert(t, challenge, testRP.Origin), true)
+	if err != nil || signCount != 0 {
+		t.Fatalf("signCount = %d, err = %v; want 0, nil", signCount, err)
+	}
+}
@@ -0,0 +1,83 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Banderas de authenticatorData
+const (
+	flagUserPresent                = 0x01
+	flagUserVerified               = 0x04
+	flagBackupEligible             = 0x08
+	flagAttestedCredential         = 0x40
+	authenticatorDataMinimumLength = 37
+)
+
+// authenticatorData es la estructura binaria que el autenticador firma en cada ceremonia
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte // Llave COSE, solo en el registro
+}
+
+// parseAuthenticatorData decodifica authenticatorData y, si viene, la credencial atestada
+func parseAuthenticatorData(raw []byte) (authenticatorData, error) {
+	if len(raw) < authenticatorDataMinimumLength {
+		return authenticatorData{}, ErrInvalidAuthenticatorData
+	}
+
+	data := authenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	if data.Flags&flagAttestedCredential == 0 {
+		return data, nil
+	}
+
+	rest := raw[authenticatorDataMinimumLength:]
+	if len(rest) < 18 {
+		return authenticatorData{}, ErrInvalidAuthenticatorData
+	}
+	data.AAGUID = rest[:16]
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLength {
+		return authenticatorData{}, ErrInvalidAuthenticatorData
+	}
+	data.CredentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	// La llave COSE no trae su longitud: se decodifica un único elemento CBOR y se conservan sus bytes
+	var publicKey cbor.RawMessage
+	decoder := cbor.NewDecoder(bytes.NewReader(rest))
+	if err := decoder.Decode(&publicKey); err != nil {
+		return authenticatorData{}, ErrInvalidAuthenticatorData
+	}
+	data.PublicKey = publicKey
+
+	return data, nil
+}
+
+// verify comprueba que la credencial es de este relying party y las banderas exigidas
+func (d authenticatorData) verify(rp RelyingParty, requireUserVerification bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(d.RPIDHash, rpIDHash[:]) {
+		return ErrRPIDMismatch
+	}
+	if d.Flags&flagUserPresent == 0 {
+		return ErrUserNotPresent
+	}
+	if requireUserVerification && d.Flags&flagUserVerified == 0 {
+		return ErrUserNotVerified
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// testRP es el relying party de las pruebas
+var testRP = RelyingParty{ID: "example.com", Name: "Semita", Origin: "https://example.com"}
+
+// softAuthenticator es un autenticador por software: genera su llave y firma las ceremonias como
+// lo haría uno físico, con la posibilidad de alterar cada dato para probar los rechazos
+type softAuthenticator struct {
+	rpID         string
+	credentialID []byte
+	aaguid       []byte
+	algorithm    int
+	signer       crypto.Signer
+	signCount    uint32
+	flags        byte
+}
+
+func newSoftAuthenticator(t *testing.T, algorithm int) *softAuthenticator {
+	t.Helper()
+
+	var signer crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		t.Fatalf("algoritmo no soportado por el autenticador de prueba: %d", algorithm)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatal(err)
+	}
+
+	return &softAuthenticator{
+		rpID:         testRP.ID,
+		credentialID: credentialID,
+		aaguid:       []byte("semita-test-auth"),
+		algorithm:    algorithm,
+		signer:       signer,
+		flags:        flagUserPresent | flagUserVerified,
+	}
+}
+
+// coseKey codifica la llave pública en formato COSE
+func (a *softAuthenticator) coseKey(t *testing.T) []byte {
+	t.Helper()
+
+	var key any
+	switch publicKey := a.signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		key = coseKey{
+			KeyType:   coseKeyTypeEC2,
+			Algorithm: AlgES256,
+			Curve:     coseCurveP256,
+			X:         publicKey.X.FillBytes(make([]byte, 32)),
+			Y:         publicKey.Y.FillBytes(make([]byte, 32)),
+		}
+	case *rsa.PublicKey:
+		key = coseRSAKey{
+			KeyType:   coseKeyTypeRSA,
+			Algorithm: AlgRS256,
+			N:         publicKey.N.Bytes(),
+			E:         big.NewInt(int64(publicKey.E)).Bytes(),
+		}
+	}
+
+	encoded, err := cbor.Marshal(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+// authenticatorData arma los datos del autenticador; en el registro incluye la credencial atestada
+func (a *softAuthenticator) authenticatorData(t *testing.T, attested bool) []byte {
+	t.Helper()
+
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	flags := a.flags
+	if attested {
+		flags |= flagAttestedCredential
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+
+	if attested {
+		data = append(data, a.aaguid...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey(t)...)
+	}
+	return data
+}
+
+// sign firma el mensaje con la llave de la credencial
+func (a *softAuthenticator) sign(t *testing.T, message []byte) []byte {
+	t.Helper()
+	return signWith(t, a.signer, message)
+}
+
+func signWith(t *testing.T, signer crypto.Signer, message []byte) []byte {
+	t.Helper()
+
+	digest := sha256.Sum256(message)
+	var signature []byte
+	var err error
+	switch key := signer.(type) {
+	case *ecdsa.PrivateKey:
+		signature, err = ecdsa.SignASN1(rand.Reader, key, digest[:])
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+// register ejecuta navigator.credentials.create() con el formato de atestación indicado. El
+// statement, si no es nil, reemplaza la declaración que generaría el autenticador.
+func (a *softAuthenticator) register(t *testing.T, challenge string, origin string, format string, statement any) AttestationResponse {
+	t.Helper()
+
+	clientDataJSON := testClientData(t, "webauthn.create", challenge, origin)
+	authData := a.authenticatorData(t, true)
+
+	if statement == nil {
+		switch format {
+		case "none":
+			statement = map[string]any{}
+		case "packed":
+			clientDataHash := sha256.Sum256(clientDataJSON)
+			statement = packedStatement{
+				Algorithm: a.algorithm,
+				Signature: a.sign(t, append(append([]byte{}, authData...), clientDataHash[:]...)),
+			}
+		}
+	}
+	rawStatement, err := cbor.Marshal(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawAttestation, err := cbor.Marshal(attestationObject{Format: format, Statement: rawStatement, AuthData: authData})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var response AttestationResponse
+	response.ID = encodeTestBase64(a.credentialID)
+	response.RawID = response.ID
+	response.Type = "public-key"
+	response.Response.ClientDataJSON = encodeTestBase64(clientDataJSON)
+	response.Response.AttestationObject = encodeTestBase64(rawAttestation)
+	return response
+}
+
+// assert ejecuta navigator.credentials.get(); cada aserción avanza el contador de firmas
+func (a *softAuthenticator) assert(t *testing.T, challenge string, origin string) AssertionResponse {
+	t.Helper()
+
+	a.signCount++
+	clientDataJSON := testClientData(t, "webauthn.get", challenge, origin)
+	authData := a.authenticatorData(t, false)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+
+	var response AssertionResponse
+	response.ID = encodeTestBase64(a.credentialID)
+	response.RawID = response.ID
+	response.Type = "public-key"
+	response.Response.ClientDataJSON = encodeTestBase64(clientDataJSON)
+	response.Response.AuthenticatorData = encodeTestBase64(authData)
+	response.Response.Signature = encodeTestBase64(a.sign(t, append(append([]byte{}, authData...), clientDataHash[:]...)))
+	response.Response.UserHandle = encodeTestBase64([]byte("1"))
+	return response
+}
+
+func testClientData(t *testing.T, ceremony string, challenge string, origin string) []byte {
+	t.Helper()
+
+	encoded, err := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func testChallenge(t *testing.T) string {
+	t.Helper()
+
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+func encodeTestBase64(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
@@ -0,0 +1,36 @@
+package webauthn
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+)
+
+// clientData es el contenido de clientDataJSON que firma el autenticador
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// verifyClientData comprueba el tipo de ceremonia, el desafío y el origen
+func (rp RelyingParty) verifyClientData(raw []byte, ceremony string, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Type != ceremony {
+		return ErrInvalidClientData
+	}
+
+	expected, err := decodeBase64URL(challenge)
+	if err != nil {
+		return ErrChallengeMismatch
+	}
+	received, err := decodeBase64URL(data.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(expected, received) != 1 {
+		return ErrChallengeMismatch
+	}
+
+	if data.Origin != rp.Origin {
+		return ErrOriginMismatch
+	}
+
+	return nil
+}
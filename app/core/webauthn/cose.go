@@ -0,0 +1,87 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Parámetros de las llaves COSE (RFC 8152)
+const (
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+	coseCurveP256  = 1
+)
+
+// coseKey reúne los campos de una llave COSE EC2 o RSA
+type coseKey struct {
+	KeyType   int    `cbor:"1,keyasint"`
+	Algorithm int    `cbor:"3,keyasint"`
+	Curve     int    `cbor:"-1,keyasint,omitempty"`
+	X         []byte `cbor:"-2,keyasint,omitempty"`
+	Y         []byte `cbor:"-3,keyasint,omitempty"`
+}
+
+// coseRSAKey usa las mismas etiquetas negativas que EC2 con otro significado: -1 es n y -2 es e
+type coseRSAKey struct {
+	KeyType   int    `cbor:"1,keyasint"`
+	Algorithm int    `cbor:"3,keyasint"`
+	N         []byte `cbor:"-1,keyasint"`
+	E         []byte `cbor:"-2,keyasint"`
+}
+
+// parsePublicKey convierte una llave COSE en una llave pública de Go y devuelve su algoritmo
+func parsePublicKey(raw []byte) (crypto.PublicKey, int, error) {
+	var header struct {
+		KeyType   int `cbor:"1,keyasint"`
+		Algorithm int `cbor:"3,keyasint"`
+	}
+	if err := cbor.Unmarshal(raw, &header); err != nil {
+		return nil, 0, ErrUnsupportedAlgorithm
+	}
+
+	switch {
+	case header.KeyType == coseKeyTypeEC2 && header.Algorithm == AlgES256:
+		var key coseKey
+		if err := cbor.Unmarshal(raw, &key); err != nil || key.Curve != coseCurveP256 || len(key.X) != 32 || len(key.Y) != 32 {
+			return nil, 0, ErrUnsupportedAlgorithm
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(key.X), Y: new(big.Int).SetBytes(key.Y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, 0, ErrUnsupportedAlgorithm
+		}
+		return publicKey, AlgES256, nil
+
+	case header.KeyType == coseKeyTypeRSA && header.Algorithm == AlgRS256:
+		var key coseRSAKey
+		if err := cbor.Unmarshal(raw, &key); err != nil || len(key.N) == 0 || len(key.E) == 0 || len(key.E) > 4 {
+			return nil, 0, ErrUnsupportedAlgorithm
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(key.N), E: int(new(big.Int).SetBytes(key.E).Int64())}, AlgRS256, nil
+	}
+
+	return nil, 0, ErrUnsupportedAlgorithm
+}
+
+// verifySignature valida una firma ES256 (DER) o RS256 (PKCS#1 v1.5) sobre el mensaje
+func verifySignature(publicKey crypto.PublicKey, algorithm int, message []byte, signature []byte) error {
+	digest := sha256.Sum256(message)
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if algorithm == AlgES256 && ecdsa.VerifyASN1(key, digest[:], signature) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if algorithm == AlgRS256 && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
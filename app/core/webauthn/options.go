@@ -0,0 +1,98 @@
+package webauthn
+
+import "encoding/base64"
+
+// Algoritmos COSE soportados
+const (
+	AlgES256 = -7
+	AlgRS256 = -257
+)
+
+// CredentialParameter es un algoritmo aceptado para las credenciales nuevas
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// CredentialDescriptor identifica una credencial existente; el ID va en base64url
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// User es el usuario para el que se registra la credencial; el ID va en base64url
+type User struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CreationOptions son las opciones de navigator.credentials.create().
+// Los campos binarios van en base64url y el JavaScript los convierte a ArrayBuffer.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     map[string]string      `json:"rp"`
+	User                   User                   `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection map[string]string      `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions son las opciones de navigator.credentials.get()
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int                    `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// ceremonyTimeout es el tiempo que el navegador espera al autenticador, en milisegundos
+const ceremonyTimeout = 120000
+
+// NewCreationOptions arma las opciones de registro de una passkey (credencial residente).
+// Las credenciales existentes se excluyen para no registrar dos veces el mismo autenticador.
+func (rp RelyingParty) NewCreationOptions(challenge string, userID []byte, name string, displayName string, existing []Credential) CreationOptions {
+	return CreationOptions{
+		Challenge: challenge,
+		RP:        map[string]string{"id": rp.ID, "name": rp.Name},
+		User: User{
+			ID:          base64.RawURLEncoding.EncodeToString(userID),
+			Name:        name,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            ceremonyTimeout,
+		ExcludeCredentials: descriptors(existing),
+		AuthenticatorSelection: map[string]string{
+			"residentKey":      "required",
+			"userVerification": "preferred",
+		},
+		Attestation: "none",
+	}
+}
+
+// NewRequestOptions arma las opciones de autenticación. Sin credenciales permitidas el
+// navegador ofrece las passkeys guardadas para el dominio (login sin usuario ni contraseña).
+func (rp RelyingParty) NewRequestOptions(challenge string, allowed []Credential) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.ID,
+		Timeout:          ceremonyTimeout,
+		AllowCredentials: descriptors(allowed),
+		UserVerification: "required",
+	}
+}
+
+func descriptors(credentials []Credential) []CredentialDescriptor {
+	list := make([]CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		list = append(list, CredentialDescriptor{Type: "public-key", ID: base64.RawURLEncoding.EncodeToString(credential.ID)})
+	}
+	return list
+}
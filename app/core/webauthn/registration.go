@@ -0,0 +1,165 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// AttestationResponse es el PublicKeyCredential que devuelve navigator.credentials.create(),
+// con los campos binarios en base64url
+type AttestationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// attestationObject es el objeto CBOR con los datos del autenticador y su declaración de atestación
+type attestationObject struct {
+	Format    string          `cbor:"fmt"`
+	Statement cbor.RawMessage `cbor:"attStmt"`
+	AuthData  []byte          `cbor:"authData"`
+}
+
+// packedStatement es la declaración de atestación del formato packed
+type packedStatement struct {
+	Algorithm    int      `cbor:"alg"`
+	Signature    []byte   `cbor:"sig"`
+	Certificates [][]byte `cbor:"x5c,omitempty"`
+}
+
+// oidFIDOGenCEAAGUID es la extensión del certificado de atestación que declara el AAGUID
+var oidFIDOGenCEAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// VerifyRegistration valida la ceremonia de registro y devuelve la credencial a guardar.
+// Acepta atestación none y packed (autoatestación o con certificado x5c); no valida la cadena
+// del certificado contra metadatos de fabricantes.
+func (rp RelyingParty) VerifyRegistration(challenge string, response AttestationResponse) (Credential, error) {
+	if response.Type != "public-key" {
+		return Credential{}, ErrInvalidClientData
+	}
+
+	clientDataJSON, err := decodeBase64URL(response.Response.ClientDataJSON)
+	if err != nil {
+		return Credential{}, ErrInvalidClientData
+	}
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return Credential{}, err
+	}
+
+	rawAttestation, err := decodeBase64URL(response.Response.AttestationObject)
+	if err != nil {
+		return Credential{}, ErrInvalidAttestation
+	}
+	var attestation attestationObject
+	if err := cbor.Unmarshal(rawAttestation, &attestation); err != nil {
+		return Credential{}, ErrInvalidAttestation
+	}
+
+	authData, err := parseAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return Credential{}, err
+	}
+	if err := authData.verify(rp, false); err != nil {
+		return Credential{}, err
+	}
+	if authData.Flags&flagAttestedCredential == 0 || len(authData.CredentialID) == 0 {
+		return Credential{}, ErrInvalidAuthenticatorData
+	}
+
+	rawID, err := decodeBase64URL(response.RawID)
+	if err != nil || !bytes.Equal(rawID, authData.CredentialID) {
+		return Credential{}, ErrCredentialMismatch
+	}
+
+	publicKey, algorithm, err := parsePublicKey(authData.PublicKey)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, attestation.AuthData...), clientDataHash[:]...)
+
+	switch attestation.Format {
+	case "none":
+		var statement map[string]any
+		if err := cbor.Unmarshal(attestation.Statement, &statement); err != nil || len(statement) != 0 {
+			return Credential{}, ErrInvalidAttestation
+		}
+
+	case "packed":
+		var statement packedStatement
+		if err := cbor.Unmarshal(attestation.Statement, &statement); err != nil {
+			return Credential{}, ErrInvalidAttestation
+		}
+		if err := verifyPacked(statement, authData, publicKey, algorithm, signedData); err != nil {
+			return Credential{}, err
+		}
+
+	default:
+		return Credential{}, ErrUnsupportedAttestation
+	}
+
+	return Credential{
+		ID:                authData.CredentialID,
+		PublicKey:         authData.PublicKey,
+		SignCount:         authData.SignCount,
+		AAGUID:            authData.AAGUID,
+		AttestationFormat: attestation.Format,
+		BackupEligible:    authData.Flags&flagBackupEligible != 0,
+	}, nil
+}
+
+// verifyPacked valida la atestación packed. Sin x5c es autoatestación y la firma se hace con
+// la misma llave de la credencial; con x5c se valida con el certificado del autenticador.
+func verifyPacked(statement packedStatement, authData authenticatorData, credentialKey any, credentialAlgorithm int, signedData []byte) error {
+	if len(statement.Certificates) == 0 {
+		if statement.Algorithm != credentialAlgorithm {
+			return ErrInvalidAttestation
+		}
+		if verifySignature(credentialKey, statement.Algorithm, signedData, statement.Signature) != nil {
+			return ErrInvalidAttestation
+		}
+		return nil
+	}
+
+	certificate, err := x509.ParseCertificate(statement.Certificates[0])
+	if err != nil {
+		return ErrInvalidAttestation
+	}
+
+	// Requisitos del certificado de atestación packed (WebAuthn §8.2.1)
+	if certificate.Version != 3 || certificate.IsCA {
+		return ErrInvalidAttestation
+	}
+	validOU := false
+	for _, unit := range certificate.Subject.OrganizationalUnit {
+		if unit == "Authenticator Attestation" {
+			validOU = true
+		}
+	}
+	if !validOU {
+		return ErrInvalidAttestation
+	}
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(oidFIDOGenCEAAGUID) {
+			continue
+		}
+		var aaguid []byte
+		if _, err := asn1.Unmarshal(extension.Value, &aaguid); err != nil || !bytes.Equal(aaguid, authData.AAGUID) {
+			return ErrInvalidAttestation
+		}
+	}
+
+	if verifySignature(certificate.PublicKey, statement.Algorithm, signedData, statement.Signature) != nil {
+		return ErrInvalidAttestation
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestVerifyRegistrationAcceptsNoneAndPackedSelfAttestation(t *testing.T) {
+	cases := []struct {
+		name      string
+		algorithm int
+		format    string
+	}{
+		{"none ES256", AlgES256, "none"},
+		{"none RS256", AlgRS256, "none"},
+		{"packed ES256", AlgES256, "packed"},
+		{"packed RS256", AlgRS256, "packed"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t, tc.algorithm)
+			challenge := testChallenge(t)
+
+			credential, err := testRP.VerifyRegistration(challenge, authenticator.register(t, challenge, testRP.Origin, tc.format, nil))
+			if err != nil {
+				t.Fatalf("VerifyRegistration: %v", err)
+			}
+			if !bytes.Equal(credential.ID, authenticator.credentialID) {
+				t.Errorf("credential ID = %x, want %x", credential.ID, authenticator.credentialID)
+			}
+			if !bytes.Equal(credential.AAGUID, authenticator.aaguid) {
+				t.Errorf("AAGUID = %x, want %x", credential.AAGUID, authenticator.aaguid)
+			}
+			if credential.AttestationFormat != tc.format {
+				t.Errorf("attestation format = %q, want %q", credential.AttestationFormat, tc.format)
+			}
+			if _, algorithm, err := parsePublicKey(credential.PublicKey); err != nil || algorithm != tc.algorithm {
+				t.Errorf("stored public key algorithm = %d (%v), want %d", algorithm, err, tc.algorithm)
+			}
+		})
+	}
+}
+
+func TestVerifyRegistrationAcceptsPackedCertificate(t *testing.T) {
+	authenticator := newSoftAuthenticator(t, AlgES256)
+	challenge := testChallenge(t)
+
+	attestationKey, certificate := testAttestationCertificate(t, authenticator.aaguid)
+	clientDataHash := sha256.Sum256(testClientData(t, "webauthn.create", challenge, testRP.Origin))
+	signedData := append(authenticator.authenticatorData(t, true), clientDataHash[:]...)
+	statement := packedStatement{
+		Algorithm:    AlgES256,
+		Signature:    signWith(t, attestationKey, signedData),
+		Certificates: [][]byte{certificate},
+	}
+
+	credential, err := testRP.VerifyRegistration(challenge, authenticator.register(t, challenge, testRP.Origin, "packed", statement))
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	if credential.AttestationFormat != "packed" {
+		t.Errorf("attestation format = %q, want packed", credential.AttestationFormat)
+	}
+}
+
+func TestVerifyRegistrationRejectsPackedCertificateWithOtherAAGUID(t *testing.T) {
+	authenticator := newSoftAuthenticator(t, AlgES256)
+	challenge := testChallenge(t)
+
+	attestationKey, certificate := testAttestationCertificate(t, []byte("another-aaguid!!"))
+	clientDataHash := sha256.Sum256(testClientData(t, "webauthn.create", challenge, testRP.Origin))
+	signedData := append(authenticator.authenticatorData(t, true), clientDataHash[:]...)
+	statement := packedStatement{
+		Algorithm:    AlgES256,
+		Signature:    signWith(t, attestationKey, signedData),
+		Certificates: [][]byte{certificate},
+	}
+
+	_, err := testRP.VerifyRegistration(challenge, authenticator.register(t, challenge, testRP.Origin, "packed", statement))
+	if !errors.Is(err, ErrInvalidAttestation) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidAttestation)
+	}
+}
+
+func TestVerifyRegistrationRejectsPackedSelfAttestationWithOtherKey(t *testing.T) {
+	authenticator := newSoftAuthenticator(t, AlgES256)
+	other := newSoftAuthenticator(t, AlgES256)
+	challenge := testChallenge(t)
+
+	clientDataHash := sha256.Sum256(testClientData(t, "webauthn.create", challenge, testRP.Origin))
+	signedData := append(authenticator.authenticatorData(t, true), clientDataHash[:]...)
+	statement := packedStatement{Algorithm: AlgES256, Signature: other.sign(t, signedData)}
+
+	_, err := testRP.VerifyRegistration(challenge, authenticator.register(t, challenge, testRP.Origin, "packed", statement))
+	if !errors.Is(err, ErrInvalidAttestation) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidAttestation)
+	}
+}
+
+func TestVerifyRegistrationRejectsTamperedCeremonies(t *testing.T) {
+	challenge := testChallenge(t)
+
+	cases := []struct {
+		name   string
+		modify func(t *testing.T, authenticator *softAuthenticator) AttestationResponse
+		want   error
+	}{
+		{
+			name: "bad challenge",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AttestationResponse {
+				return authenticator.register(t, testChallenge(t), testRP.Origin, "none", nil)
+			},
+			want: ErrChallengeMismatch,
+		},
+		{
+			name: "bad origin",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AttestationResponse {
+				return authenticator.register(t, challenge, "https://evil.example", "none", nil)
+			},
+			want: ErrOriginMismatch,
+		},
+		{
+			name: "bad rpIdHash",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AttestationResponse {
+				authenticator.rpID = "evil.example"
+				return authenticator.register(t, challenge, testRP.Origin, "none", nil)
+			},
+			want: ErrRPIDMismatch,
+		},
+		{
+			name: "assertion ceremony",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AttestationResponse {
+				response := authenticator.register(t, challenge, testRP.Origin, "none", nil)
+				response.Response.ClientDataJSON = encodeTestBase64(testClientData(t, "webauthn.get", challenge, testRP.Origin))
+				return response
+			},
+			want: ErrInvalidClientData,
+		},
+		{
+			name: "user not present",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AttestationResponse {
+				authenticator.flags = 0
+				return authenticator.register(t, challenge, testRP.Origin, "none", nil)
+			},
+			want: ErrUserNotPresent,
+		},
+		{
+			name: "other credential ID",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AttestationResponse {
+				response := authenticator.register(t, challenge, testRP.Origin, "none", nil)
+				response.RawID = encodeTestBase64([]byte("another-credential"))
+				return response
+			},
+			want: ErrCredentialMismatch,
+		},
+		{
+			name: "unsupported format",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AttestationResponse {
+				return authenticator.register(t, challenge, testRP.Origin, "tpm", map[string]any{})
+			},
+			want: ErrUnsupportedAttestation,
+		},
+		{
+			name: "none with statement",
+			modify: func(t *testing.T, authenticator *softAuthenticator) AttestationResponse {
+				return authenticator.register(t, challenge, testRP.Origin, "none", map[string]any{"alg": AlgES256})
+			},
+			want: ErrInvalidAttestation,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t, AlgES256)
+			_, err := testRP.VerifyRegistration(challenge, tc.modify(t, authenticator))
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// testAttestationCertificate crea un certificado de atestación packed autofirmado con el AAGUID
+func testAttestationCertificate(t *testing.T, aaguid []byte) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extension, err := asn1.Marshal(aaguid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"CL"},
+			Organization:       []string{"Semita"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "Semita Test Authenticator",
+		},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: oidFIDOGenCEAAGUID, Value: extension}},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, certificate
+}
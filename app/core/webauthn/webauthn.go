@@ -0,0 +1,83 @@
+// Package webauthn implementa las ceremonias de registro y autenticación de WebAuthn (passkeys)
+// para el guard web. No depende de la base de datos ni de la sesión: recibe el desafío y las
+// credenciales guardadas, por lo que se puede probar con un autenticador por software.
+package webauthn
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/url"
+)
+
+var (
+	// ErrInvalidClientData se devuelve cuando el clientDataJSON no corresponde a la ceremonia
+	ErrInvalidClientData = errors.New("webauthn: clientDataJSON inválido")
+	// ErrChallengeMismatch se devuelve cuando el desafío firmado no es el emitido por el servidor
+	ErrChallengeMismatch = errors.New("webauthn: el desafío no coincide")
+	// ErrOriginMismatch se devuelve cuando la ceremonia se hizo desde otro origen
+	ErrOriginMismatch = errors.New("webauthn: el origen no coincide")
+	// ErrInvalidAuthenticatorData se devuelve cuando authenticatorData está mal formado
+	ErrInvalidAuthenticatorData = errors.New("webauthn: authenticatorData inválido")
+	// ErrRPIDMismatch se devuelve cuando la credencial pertenece a otro relying party
+	ErrRPIDMismatch = errors.New("webauthn: el RP ID no coincide")
+	// ErrUserNotPresent se devuelve cuando el autenticador no confirmó la presencia del usuario
+	ErrUserNotPresent = errors.New("webauthn: el usuario no está presente")
+	// ErrUserNotVerified se devuelve cuando se exigía verificación del usuario y no la hubo
+	ErrUserNotVerified = errors.New("webauthn: el usuario no fue verificado")
+	// ErrUnsupportedAttestation se devuelve con formatos de atestación distintos de none y packed
+	ErrUnsupportedAttestation = errors.New("webauthn: formato de atestación no soportado")
+	// ErrInvalidAttestation se devuelve cuando la atestación no es válida
+	ErrInvalidAttestation = errors.New("webauthn: atestación inválida")
+	// ErrUnsupportedAlgorithm se devuelve con llaves que no son ES256 ni RS256
+	ErrUnsupportedAlgorithm = errors.New("webauthn: algoritmo no soportado")
+	// ErrInvalidSignature se devuelve cuando la firma de la aserción no es válida
+	ErrInvalidSignature = errors.New("webauthn: firma inválida")
+	// ErrCredentialMismatch se devuelve cuando la aserción no corresponde a la credencial indicada
+	ErrCredentialMismatch = errors.New("webauthn: la credencial no coincide")
+	// ErrClonedAuthenticator se devuelve cuando el contador de firmas retrocede
+	ErrClonedAuthenticator = errors.New("webauthn: el contador de firmas retrocedió, el autenticador puede estar clonado")
+)
+
+// RelyingParty identifica a la aplicación ante los autenticadores
+type RelyingParty struct {
+	ID     string // Dominio, sin esquema ni puerto (p. ej. "example.com")
+	Name   string // Nombre que muestra el autenticador
+	Origin string // Origen exacto desde el que se ejecutan las ceremonias (p. ej. "https://example.com")
+}
+
+// NewRelyingParty crea el relying party a partir de la URL base de la aplicación
+func NewRelyingParty(name string, appURL string) (RelyingParty, error) {
+	u, err := url.Parse(appURL)
+	if err != nil || u.Host == "" {
+		return RelyingParty{}, errors.New("webauthn: URL de la aplicación inválida")
+	}
+	return RelyingParty{ID: u.Hostname(), Name: name, Origin: u.Scheme + "://" + u.Host}, nil
+}
+
+// Credential es una credencial registrada por un usuario
+type Credential struct {
+	ID                []byte // ID de la credencial generado por el autenticador
+	PublicKey         []byte // Llave pública en formato COSE
+	SignCount         uint32
+	AAGUID            []byte
+	AttestationFormat string
+	BackupEligible    bool // La credencial se puede sincronizar entre dispositivos (passkey)
+}
+
+// NewChallenge genera un desafío aleatorio de 32 bytes codificado en base64url
+func NewChallenge() (string, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(challenge), nil
+}
+
+// decodeBase64URL acepta base64url con o sin relleno, que es como lo envían los navegadores
+func decodeBase64URL(value string) ([]byte, error) {
+	if decoded, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.URLEncoding.DecodeString(value)
+}
@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"errors"
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"time"
+)
+
+// ErrSessionNotFound se devuelve cuando la sesión no existe o no pertenece al usuario
+var ErrSessionNotFound = errors.New("sesión no encontrada")
+
+// SessionsListable indica si el driver de sesión permite listar y cerrar sesiones remotas
+func SessionsListable() bool {
+	return utils.SessionDriver() == "database"
+}
+
+// ActiveSessions lista las sesiones web vigentes del usuario marcando la de la petición actual.
+// Solo el driver database guarda a qué usuario pertenece cada sesión.
+func ActiveSessions(userID int64, currentSessionID string) ([]structs.ActiveSessionStruct, error) {
+	if !SessionsListable() {
+		return nil, nil
+	}
+
+	records, err := models.GetUserSessions(userID, time.Now().Add(-utils.SessionLifetime()))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]structs.ActiveSessionStruct, 0, len(records))
+	for _, record := range records {
+		sessions = append(sessions, structs.ActiveSessionStruct{
+			Key:          sessionKey(record.ID),
+			IPAddress:    record.IPAddress,
+			UserAgent:    record.UserAgent,
+			LastActivity: record.LastActivity.Format("2006-01-02 15:04:05"),
+			Current:      record.ID == currentSessionID,
+		})
+	}
+
+	return sessions, nil
+}
+
+// TerminateSession cierra la sesión del usuario identificada por su clave pública
+func TerminateSession(userID int64, key string) error {
+	if !SessionsListable() {
+		return ErrSessionNotFound
+	}
+
+	records, err := models.GetUserSessions(userID, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if sessionKey(record.ID) == key {
+			return models.DeleteSession(record.ID)
+		}
+	}
+
+	return ErrSessionNotFound
+}
+
+// LogoutEverywhere cierra todas las sesiones web del usuario, revoca sus tokens OAuth
+// y elimina su token de "recordarme". Con los drivers que no listan sesiones (cookie y file) las
+// sesiones se invalidan al cambiar su versión, que AuthenticateSession compara en cada petición.
+func LogoutEverywhere(userID int64) error {
+	if err := models.IncrementSessionEpoch(int(userID)); err != nil {
+		return err
+	}
+
+	if SessionsListable() {
+		if err := models.DeleteUserSessions(userID, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := models.RevokeAllUserTokens(userID); err != nil {
+		return err
+	}
+
+	return models.UpdateRememberToken(int(userID), "")
+}
+
+// sessionKey deriva un identificador público del ID de sesión para no exponerlo en la interfaz
+func sessionKey(id string) string {
+	return utils.HashToken("session-key:" + id)[:32]
+}
@@ -1,13 +1,18 @@
 package helpers
 
 import (
-	"net/http"
+	"semita/app/core/auth"
+	"semita/app/core/gate"
 	"semita/app/structs"
 	"semita/app/utils"
+
+	"github.com/gin-gonic/gin"
 )
 
-func AuthSessionService(response http.ResponseWriter, request *http.Request, title string, data interface{}) structs.AuthSessionStruct {
-	user, isAuthenticated := utils.GetAuthenticatedUser(request)
+func AuthSessionService(context *gin.Context, title string, data interface{}) structs.AuthSessionStruct {
+	response, request := context.Writer, context.Request
+	user, isAuthenticated := auth.User(context)
+	team, _ := auth.Team(context)
 	alertId, alertMessage := utils.GetFlashNotifications(response, request)
 
 	lang := "es"
@@ -19,14 +24,22 @@ func AuthSessionService(response http.ResponseWriter, request *http.Request, tit
 		return utils.Translate(key, lang)
 	}
 
+	csrfToken := utils.CsrfToken(response, request)
+
 	return structs.AuthSessionStruct{
 		User:            user,
 		IsAuthenticated: isAuthenticated,
+		Team:            team,
 		Title:           title,
 		Data:            data,
 		AlertId:         alertId,
 		AlertMessage:    alertMessage,
 		Lang:            lang,
 		Translate:       translate,
+		CsrfToken:       csrfToken,
+		CsrfField:       utils.CsrfField(csrfToken),
+		Can: func(ability string, resource ...any) bool {
+			return gate.Allows(context, ability, resource...)
+		},
 	}
 }
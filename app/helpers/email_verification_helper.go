@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"semita/app/notifications"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+	"time"
+)
+
+// EmailVerificationExpiration es la vigencia del enlace de verificación (AUTH_VERIFICATION_EXPIRE, en minutos)
+func EmailVerificationExpiration() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("AUTH_VERIFICATION_EXPIRE"))
+	if err != nil || minutes <= 0 {
+		minutes = 60
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// EmailVerificationURL genera el enlace firmado de verificación para el usuario.
+// El hash del email invalida el enlace si el usuario cambia de correo antes de usarlo.
+func EmailVerificationURL(user structs.UserStruct) (string, error) {
+	path := "/api/v1/auth/email/verify/" + strconv.Itoa(user.ID) + "/" + emailVerificationHash(user.Email)
+	return utils.SignedURL(path, nil, EmailVerificationExpiration())
+}
+
+// ValidEmailVerificationHash comprueba que el hash del enlace corresponde al email actual del usuario
+func ValidEmailVerificationHash(user structs.UserStruct, hash string) bool {
+	return hmac.Equal([]byte(hash), []byte(emailVerificationHash(user.Email)))
+}
+
+// SendEmailVerification envía al usuario un nuevo enlace de verificación
+func SendEmailVerification(user structs.UserStruct) error {
+	verificationURL, err := EmailVerificationURL(user)
+	if err != nil {
+		return err
+	}
+	return notifications.SendEmailVerification(user.Email, verificationURL)
+}
+
+func emailVerificationHash(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return hex.EncodeToString(sum[:])
+}
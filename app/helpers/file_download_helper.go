@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"semita/app/utils"
+	"time"
+)
+
+// FilesPath es el directorio desde el que se sirven las descargas firmadas
+const FilesPath = "storage/files"
+
+// FileDownloadURL genera un enlace relativo y temporal para descargar un archivo de storage/files
+func FileDownloadURL(name string, ttl time.Duration) (string, error) {
+	return utils.Sign("/files/"+url.PathEscape(filepath.Base(name)), nil, ttl)
+}
+
+// FileDownloadURLIfExists devuelve el enlace de descarga solo si el archivo existe y se pudo firmar
+func FileDownloadURLIfExists(name string, ttl time.Duration) string {
+	if _, err := os.Stat(filepath.Join(FilesPath, filepath.Base(name))); err != nil {
+		return ""
+	}
+	link, err := FileDownloadURL(name, ttl)
+	if err != nil {
+		utils.Logs("ERROR", "No se pudo firmar el enlace de descarga: "+err.Error())
+		return ""
+	}
+	return link
+}
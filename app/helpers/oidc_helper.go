@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"semita/app/structs"
+	"semita/app/utils"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// idTokenLifetime es la vigencia de los id_token emitidos
+const idTokenLifetime = time.Hour
+
+// OIDCUserClaims devuelve los claims estándar del usuario permitidos por los scopes concedidos
+func OIDCUserClaims(user structs.UserStruct, scopes []string) map[string]any {
+	claims := map[string]any{
+		"sub": strconv.Itoa(user.ID),
+	}
+
+	if utils.HasScope(scopes, "profile") {
+		claims["name"] = user.Name
+		if updatedAt, err := time.ParseInLocation("2006-01-02 15:04:05", user.UpdatedAt, time.Local); err == nil {
+			claims["updated_at"] = updatedAt.Unix()
+		}
+	}
+
+	if utils.HasScope(scopes, "email") {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.HasVerifiedEmail()
+	}
+
+	return claims
+}
+
+// RequestsOpenID indica si se solicitó explícitamente el scope openid
+func RequestsOpenID(scopes []string) bool {
+	return slices.Contains(scopes, "openid")
+}
+
+// IssueIDToken emite un id_token firmado con RS256 para el cliente indicado
+func IssueIDToken(user structs.UserStruct, clientID string, scopes []string, nonce string, authTime time.Time) (string, error) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss":       utils.AppURL(),
+		"aud":       clientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(idTokenLifetime).Unix(),
+		"auth_time": authTime.Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	for name, value := range OIDCUserClaims(user, scopes) {
+		claims[name] = value
+	}
+
+	return utils.SignIDToken(claims)
+}
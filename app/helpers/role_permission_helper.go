@@ -1,9 +1,9 @@
 package helpers
 
 import (
-	"net/http"
-	"semita/app/models"
-	"semita/app/utils"
+	"semita/app/core/auth"
+
+	"github.com/gin-gonic/gin"
 )
 
 // RolePermissionHelper proporciona métodos auxiliares para verificar roles y permisos
@@ -14,261 +14,146 @@ func NewRolePermissionHelper() *RolePermissionHelper {
 	return &RolePermissionHelper{}
 }
 
-// getUserIDFromRequest obtiene el ID del usuario autenticado desde la request
-func (rph *RolePermissionHelper) getUserIDFromRequest(request *http.Request) (int, bool) {
-	user, authenticated := utils.GetAuthenticatedUser(request)
-	if !authenticated {
-		return 0, false
-	}
-	return user.ID, true
+// identity obtiene la identidad de la petición, con sus roles y permisos ya cargados
+func (rph *RolePermissionHelper) identity(context *gin.Context) *auth.Identity {
+	identity, _ := auth.Resolve(context)
+	return identity
 }
 
 // HasRole verifica si el usuario autenticado tiene un rol específico
-func (rph *RolePermissionHelper) HasRole(request *http.Request, roleName string, guardName ...string) bool {
-	userID, authenticated := rph.getUserIDFromRequest(request)
-	if !authenticated {
-		return false
-	}
-
-	guard := "web"
-	if len(guardName) > 0 && guardName[0] != "" {
-		guard = guardName[0]
-	}
-
-	hasRole, err := models.UserHasRoleByName(userID, roleName, guard)
-	if err != nil {
-		return false
-	}
-
-	return hasRole
+func (rph *RolePermissionHelper) HasRole(context *gin.Context, roleName string, guardName ...string) bool {
+	return rph.identity(context).HasRole(roleName, guardName...)
 }
 
 // HasAnyRole verifica si el usuario autenticado tiene al menos uno de los roles especificados
-func (rph *RolePermissionHelper) HasAnyRole(request *http.Request, roleNames []string, guardName ...string) bool {
-	userID, authenticated := rph.getUserIDFromRequest(request)
-	if !authenticated {
-		return false
-	}
-
-	guard := "web"
-	if len(guardName) > 0 && guardName[0] != "" {
-		guard = guardName[0]
-	}
-
-	hasAnyRole, err := models.UserHasAnyRole(userID, roleNames, guard)
-	if err != nil {
-		return false
-	}
-
-	return hasAnyRole
+func (rph *RolePermissionHelper) HasAnyRole(context *gin.Context, roleNames []string, guardName ...string) bool {
+	return rph.identity(context).HasAnyRole(roleNames, guardName...)
 }
 
 // HasAllRoles verifica si el usuario autenticado tiene todos los roles especificados
-func (rph *RolePermissionHelper) HasAllRoles(request *http.Request, roleNames []string, guardName ...string) bool {
-	userID, authenticated := rph.getUserIDFromRequest(request)
-	if !authenticated {
-		return false
-	}
-
-	guard := "web"
-	if len(guardName) > 0 && guardName[0] != "" {
-		guard = guardName[0]
-	}
-
-	hasAllRoles, err := models.UserHasAllRoles(userID, roleNames, guard)
-	if err != nil {
-		return false
-	}
-
-	return hasAllRoles
+func (rph *RolePermissionHelper) HasAllRoles(context *gin.Context, roleNames []string, guardName ...string) bool {
+	return rph.identity(context).HasAllRoles(roleNames, guardName...)
 }
 
 // HasPermission verifica si el usuario autenticado tiene un permiso específico
-func (rph *RolePermissionHelper) HasPermission(request *http.Request, permissionName string, guardName ...string) bool {
-	userID, authenticated := rph.getUserIDFromRequest(request)
-	if !authenticated {
-		return false
-	}
-
-	guard := "web"
-	if len(guardName) > 0 && guardName[0] != "" {
-		guard = guardName[0]
-	}
-
-	hasPermission, err := models.UserHasPermission(userID, permissionName, guard)
-	if err != nil {
-		return false
-	}
-
-	return hasPermission
+func (rph *RolePermissionHelper) HasPermission(context *gin.Context, permissionName string, guardName ...string) bool {
+	return rph.identity(context).HasPermission(permissionName, guardName...)
 }
 
 // HasAnyPermission verifica si el usuario autenticado tiene al menos uno de los permisos especificados
-func (rph *RolePermissionHelper) HasAnyPermission(request *http.Request, permissionNames []string, guardName ...string) bool {
-	userID, authenticated := rph.getUserIDFromRequest(request)
-	if !authenticated {
-		return false
-	}
-
-	guard := "web"
-	if len(guardName) > 0 && guardName[0] != "" {
-		guard = guardName[0]
-	}
-
-	hasAnyPermission, err := models.UserHasAnyPermission(userID, permissionNames, guard)
-	if err != nil {
-		return false
-	}
-
-	return hasAnyPermission
+func (rph *RolePermissionHelper) HasAnyPermission(context *gin.Context, permissionNames []string, guardName ...string) bool {
+	return rph.identity(context).HasAnyPermission(permissionNames, guardName...)
 }
 
 // HasAllPermissions verifica si el usuario autenticado tiene todos los permisos especificados
-func (rph *RolePermissionHelper) HasAllPermissions(request *http.Request, permissionNames []string, guardName ...string) bool {
-	userID, authenticated := rph.getUserIDFromRequest(request)
-	if !authenticated {
-		return false
-	}
-
-	guard := "web"
-	if len(guardName) > 0 && guardName[0] != "" {
-		guard = guardName[0]
-	}
-
-	hasAllPermissions, err := models.UserHasAllPermissions(userID, permissionNames, guard)
-	if err != nil {
-		return false
-	}
-
-	return hasAllPermissions
+func (rph *RolePermissionHelper) HasAllPermissions(context *gin.Context, permissionNames []string, guardName ...string) bool {
+	return rph.identity(context).HasAllPermissions(permissionNames, guardName...)
 }
 
 // GetUserRoles obtiene todos los roles del usuario autenticado
-func (rph *RolePermissionHelper) GetUserRoles(request *http.Request) ([]string, bool) {
-	userID, authenticated := rph.getUserIDFromRequest(request)
-	if !authenticated {
+func (rph *RolePermissionHelper) GetUserRoles(context *gin.Context) ([]string, bool) {
+	identity, err := auth.Resolve(context)
+	if identity == nil || err != nil {
 		return nil, false
 	}
-
-	roles, err := models.GetUserRoles(userID)
-	if err != nil {
-		return nil, false
-	}
-
-	roleNames := make([]string, len(roles))
-	for i, role := range roles {
-		roleNames[i] = role.Name
-	}
-
-	return roleNames, true
+	return identity.RoleNames(), true
 }
 
 // GetUserPermissions obtiene todos los permisos del usuario autenticado
-func (rph *RolePermissionHelper) GetUserPermissions(request *http.Request) ([]string, bool) {
-	userID, authenticated := rph.getUserIDFromRequest(request)
-	if !authenticated {
+func (rph *RolePermissionHelper) GetUserPermissions(context *gin.Context) ([]string, bool) {
+	identity, err := auth.Resolve(context)
+	if identity == nil || err != nil {
 		return nil, false
 	}
-
-	permissions, err := models.GetUserAllPermissions(userID)
-	if err != nil {
-		return nil, false
-	}
-
-	permissionNames := make([]string, len(permissions))
-	for i, permission := range permissions {
-		permissionNames[i] = permission.Name
-	}
-
-	return permissionNames, true
+	return identity.PermissionNames(), true
 }
 
 // IsUserAdmin verifica si el usuario es administrador (tiene rol admin o super-admin)
-func (rph *RolePermissionHelper) IsUserAdmin(request *http.Request) bool {
-	return rph.HasAnyRole(request, []string{"admin", "super-admin"})
+func (rph *RolePermissionHelper) IsUserAdmin(context *gin.Context) bool {
+	return rph.HasAnyRole(context, []string{"admin", "super-admin"})
 }
 
 // IsUserSuperAdmin verifica si el usuario es super administrador
-func (rph *RolePermissionHelper) IsUserSuperAdmin(request *http.Request) bool {
-	return rph.HasRole(request, "super-admin")
+func (rph *RolePermissionHelper) IsUserSuperAdmin(context *gin.Context) bool {
+	return rph.HasRole(context, "super-admin")
 }
 
 // CanManageUsers verifica si el usuario puede gestionar usuarios
-func (rph *RolePermissionHelper) CanManageUsers(request *http.Request) bool {
-	return rph.HasAnyPermission(request, []string{"create-users", "edit-users", "delete-users"})
+func (rph *RolePermissionHelper) CanManageUsers(context *gin.Context) bool {
+	return rph.HasAnyPermission(context, []string{"create-users", "edit-users", "delete-users"})
 }
 
 // CanManageRoles verifica si el usuario puede gestionar roles
-func (rph *RolePermissionHelper) CanManageRoles(request *http.Request) bool {
-	return rph.HasAnyPermission(request, []string{"create-roles", "edit-roles", "delete-roles", "assign-roles"})
+func (rph *RolePermissionHelper) CanManageRoles(context *gin.Context) bool {
+	return rph.HasAnyPermission(context, []string{"create-roles", "edit-roles", "delete-roles", "assign-roles"})
 }
 
 // CanManagePermissions verifica si el usuario puede gestionar permisos
-func (rph *RolePermissionHelper) CanManagePermissions(request *http.Request) bool {
-	return rph.HasAnyPermission(request, []string{"create-permissions", "edit-permissions", "delete-permissions", "assign-permissions"})
+func (rph *RolePermissionHelper) CanManagePermissions(context *gin.Context) bool {
+	return rph.HasAnyPermission(context, []string{"create-permissions", "edit-permissions", "delete-permissions", "assign-permissions"})
 }
 
 // CanAccessDashboard verifica si el usuario puede acceder al dashboard
-func (rph *RolePermissionHelper) CanAccessDashboard(request *http.Request) bool {
-	return rph.HasPermission(request, "view-dashboard")
+func (rph *RolePermissionHelper) CanAccessDashboard(context *gin.Context) bool {
+	return rph.HasPermission(context, "view-dashboard")
 }
 
 // Instancia global del helper para uso fácil
 var RolePermissionHelperInstance = NewRolePermissionHelper()
 
 // Funciones auxiliares globales para uso directo
-func HasRole(request *http.Request, roleName string, guardName ...string) bool {
-	return RolePermissionHelperInstance.HasRole(request, roleName, guardName...)
+func HasRole(context *gin.Context, roleName string, guardName ...string) bool {
+	return RolePermissionHelperInstance.HasRole(context, roleName, guardName...)
 }
 
-func HasAnyRole(request *http.Request, roleNames []string, guardName ...string) bool {
-	return RolePermissionHelperInstance.HasAnyRole(request, roleNames, guardName...)
+func HasAnyRole(context *gin.Context, roleNames []string, guardName ...string) bool {
+	return RolePermissionHelperInstance.HasAnyRole(context, roleNames, guardName...)
 }
 
-func HasAllRoles(request *http.Request, roleNames []string, guardName ...string) bool {
-	return RolePermissionHelperInstance.HasAllRoles(request, roleNames, guardName...)
+func HasAllRoles(context *gin.Context, roleNames []string, guardName ...string) bool {
+	return RolePermissionHelperInstance.HasAllRoles(context, roleNames, guardName...)
 }
 
-func HasPermission(request *http.Request, permissionName string, guardName ...string) bool {
-	return RolePermissionHelperInstance.HasPermission(request, permissionName, guardName...)
+func HasPermission(context *gin.Context, permissionName string, guardName ...string) bool {
+	return RolePermissionHelperInstance.HasPermission(context, permissionName, guardName...)
 }
 
-func HasAnyPermission(request *http.Request, permissionNames []string, guardName ...string) bool {
-	return RolePermissionHelperInstance.HasAnyPermission(request, permissionNames, guardName...)
+func HasAnyPermission(context *gin.Context, permissionNames []string, guardName ...string) bool {
+	return RolePermissionHelperInstance.HasAnyPermission(context, permissionNames, guardName...)
 }
 
-func HasAllPermissions(request *http.Request, permissionNames []string, guardName ...string) bool {
-	return RolePermissionHelperInstance.HasAllPermissions(request, permissionNames, guardName...)
+func HasAllPermissions(context *gin.Context, permissionNames []string, guardName ...string) bool {
+	return RolePermissionHelperInstance.HasAllPermissions(context, permissionNames, guardName...)
 }
 
-func GetUserRoles(request *http.Request) ([]string, bool) {
-	return RolePermissionHelperInstance.GetUserRoles(request)
+func GetUserRoles(context *gin.Context) ([]string, bool) {
+	return RolePermissionHelperInstance.GetUserRoles(context)
 }
 
-func GetUserPermissions(request *http.Request) ([]string, bool) {
-	return RolePermissionHelperInstance.GetUserPermissions(request)
+func GetUserPermissions(context *gin.Context) ([]string, bool) {
+	return RolePermissionHelperInstance.GetUserPermissions(context)
 }
 
-func IsUserAdmin(request *http.Request) bool {
-	return RolePermissionHelperInstance.IsUserAdmin(request)
+func IsUserAdmin(context *gin.Context) bool {
+	return RolePermissionHelperInstance.IsUserAdmin(context)
 }
 
-func IsUserSuperAdmin(request *http.Request) bool {
-	return RolePermissionHelperInstance.IsUserSuperAdmin(request)
+func IsUserSuperAdmin(context *gin.Context) bool {
+	return RolePermissionHelperInstance.IsUserSuperAdmin(context)
 }
 
-func CanManageUsers(request *http.Request) bool {
-	return RolePermissionHelperInstance.CanManageUsers(request)
+func CanManageUsers(context *gin.Context) bool {
+	return RolePermissionHelperInstance.CanManageUsers(context)
 }
 
-func CanManageRoles(request *http.Request) bool {
-	return RolePermissionHelperInstance.CanManageRoles(request)
+func CanManageRoles(context *gin.Context) bool {
+	return RolePermissionHelperInstance.CanManageRoles(context)
 }
 
-func CanManagePermissions(request *http.Request) bool {
-	return RolePermissionHelperInstance.CanManagePermissions(request)
+func CanManagePermissions(context *gin.Context) bool {
+	return RolePermissionHelperInstance.CanManagePermissions(context)
 }
 
-func CanAccessDashboard(request *http.Request) bool {
-	return RolePermissionHelperInstance.CanAccessDashboard(request)
+func CanAccessDashboard(context *gin.Context) bool {
+	return RolePermissionHelperInstance.CanAccessDashboard(context)
 }
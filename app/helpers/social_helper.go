@@ -0,0 +1,296 @@
+package helpers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"semita/app/core/social"
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"semita/config"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrSocialEmailTaken se devuelve cuando ya existe una cuenta local con ese email pero no se puede
+	// vincular automáticamente porque alguno de los dos emails no está verificado
+	ErrSocialEmailTaken = errors.New("ya existe una cuenta con ese email")
+	// ErrSocialEmailMissing se devuelve cuando el proveedor no comparte el email y no hay cuenta que vincular
+	ErrSocialEmailMissing = errors.New("el proveedor no devolvió un email")
+	// ErrSocialIdentityTaken se devuelve al vincular una identidad que ya pertenece a otro usuario
+	ErrSocialIdentityTaken = errors.New("la identidad ya está vinculada a otra cuenta")
+	// ErrSocialProviderLinked se devuelve si el usuario ya vinculó otra cuenta de ese proveedor
+	ErrSocialProviderLinked = errors.New("el usuario ya tiene una cuenta vinculada de ese proveedor")
+)
+
+// socialSessionKey es la clave de la sesión donde se guarda el state de la redirección en curso
+const socialSessionKey = "social_auth"
+
+// socialAuthState es lo que se guarda en la sesión entre la redirección y el callback
+type socialAuthState struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+var (
+	socialProvidersOnce sync.Once
+	socialProviders     []*social.Provider
+)
+
+// SocialProviders devuelve los proveedores configurados, en el orden de SOCIAL_PROVIDERS
+func SocialProviders() []*social.Provider {
+	socialProvidersOnce.Do(func() {
+		for _, providerConfig := range config.SocialProviders() {
+			socialProviders = append(socialProviders, social.New(providerConfig, nil))
+		}
+	})
+	return socialProviders
+}
+
+// SocialProvider busca un proveedor configurado por nombre
+func SocialProvider(name string) (*social.Provider, bool) {
+	for _, provider := range SocialProviders() {
+		if provider.Name() == name {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// StartSocialLogin guarda state, nonce y code_verifier en la sesión y devuelve la URL del proveedor
+func StartSocialLogin(context *gin.Context, provider *social.Provider) (string, error) {
+	var state socialAuthState
+	var err error
+
+	state.Provider = provider.Name()
+	if state.State, err = social.RandomString(); err != nil {
+		return "", err
+	}
+	if state.Verifier, err = social.NewCodeVerifier(); err != nil {
+		return "", err
+	}
+	if state.Nonce, err = social.RandomString(); err != nil {
+		return "", err
+	}
+
+	redirectURL, err := provider.AuthCodeURL(context.Request.Context(), state.State, state.Verifier, state.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.PutSessionValue(context.Writer, context.Request, socialSessionKey, string(encoded)); err != nil {
+		return "", err
+	}
+
+	return redirectURL, nil
+}
+
+// FinishSocialLogin valida el state del callback, canjea el código y obtiene el perfil externo.
+// El state guardado se consume aunque la validación falle.
+func FinishSocialLogin(context *gin.Context, provider *social.Provider) (social.User, social.Token, error) {
+	var state socialAuthState
+	stored := utils.PullSessionValue(context.Writer, context.Request, socialSessionKey)
+	if stored == "" || json.Unmarshal([]byte(stored), &state) != nil || state.Provider != provider.Name() {
+		return social.User{}, social.Token{}, social.ErrInvalidState
+	}
+	if subtle.ConstantTimeCompare([]byte(state.State), []byte(context.Query("state"))) != 1 {
+		return social.User{}, social.Token{}, social.ErrInvalidState
+	}
+	if context.Query("error") != "" || context.Query("code") == "" {
+		return social.User{}, social.Token{}, social.ErrProviderDenied
+	}
+
+	token, err := provider.Exchange(context.Request.Context(), context.Query("code"), state.Verifier)
+	if err != nil {
+		return social.User{}, social.Token{}, err
+	}
+
+	user, err := provider.User(context.Request.Context(), token, state.Nonce)
+	if err != nil {
+		return social.User{}, social.Token{}, err
+	}
+
+	return user, token, nil
+}
+
+// ResolveSocialUser aplica las reglas de vinculación y devuelve el usuario local:
+//
+//  1. Si la identidad ya está vinculada, se usa su usuario (y debe ser el actual si hay sesión).
+//  2. Con sesión iniciada, la identidad se vincula al usuario actual.
+//  3. Si existe un usuario con el mismo email, se vincula solo si el proveedor y la cuenta local lo
+//     tienen verificado; si no, ErrSocialEmailTaken, para evitar tomar cuentas ajenas.
+//  4. Si no, se crea un usuario nuevo con una contraseña aleatoria.
+func ResolveSocialUser(context *gin.Context, providerName string, external social.User, token social.Token, current *structs.UserStruct) (structs.UserStruct, error) {
+	identity, err := models.GetUserIdentity(providerName, external.Subject)
+	if err == nil {
+		if current != nil && current.ID != identity.UserID {
+			return structs.UserStruct{}, ErrSocialIdentityTaken
+		}
+		if err := models.UpdateUserIdentityLogin(socialIdentity(identity, external, token)); err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error actualizando la identidad %s: %v", providerName, err))
+		}
+		return models.GetUserByID(fmt.Sprint(identity.UserID))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return structs.UserStruct{}, err
+	}
+
+	if current != nil {
+		return *current, linkSocialIdentity(context, *current, providerName, external, token, "social.linked")
+	}
+
+	if external.Email == "" {
+		return structs.UserStruct{}, ErrSocialEmailMissing
+	}
+
+	user, err := models.GetUserByEmail(external.Email)
+	if err == nil {
+		if !socialEmailLinkable(external, user) {
+			return structs.UserStruct{}, ErrSocialEmailTaken
+		}
+		return user, linkSocialIdentity(context, user, providerName, external, token, "social.linked")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return structs.UserStruct{}, err
+	}
+
+	user, err = createSocialUser(external)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+	return user, linkSocialIdentity(context, user, providerName, external, token, "social.registered")
+}
+
+// socialEmailLinkable indica si la identidad externa se puede vincular a la cuenta local con el mismo
+// email: el proveedor y la cuenta local deben tenerlo verificado
+func socialEmailLinkable(external social.User, user structs.UserStruct) bool {
+	return external.EmailVerified && user.HasVerifiedEmail() && strings.EqualFold(external.Email, user.Email)
+}
+
+// DeleteSocialIdentity desvincula una identidad del usuario
+func DeleteSocialIdentity(context *gin.Context, userID int, id int64) error {
+	deleted, err := models.DeleteUserIdentity(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return sql.ErrNoRows
+	}
+
+	recordSocialEvent(context, userID, "social.unlinked", "")
+	return nil
+}
+
+// createSocialUser registra un usuario a partir del perfil externo. La contraseña es aleatoria:
+// si más adelante quiere entrar con email, puede usar la recuperación de contraseña.
+func createSocialUser(external social.User) (structs.UserStruct, error) {
+	password, err := social.RandomString()
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	name := external.Name
+	if name == "" {
+		name = strings.Split(external.Email, "@")[0]
+	}
+
+	if err := models.StoreUser(structs.StoreUserStruct{Name: name, Email: external.Email, Password: string(hashedPassword)}); err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	user, err := models.GetUserByEmail(external.Email)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+	if external.EmailVerified {
+		if err := models.MarkEmailVerified(user.ID); err != nil {
+			return structs.UserStruct{}, err
+		}
+		user.EmailVerifiedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+	return user, nil
+}
+
+// linkSocialIdentity guarda la identidad externa del usuario y registra el evento de seguridad
+func linkSocialIdentity(context *gin.Context, user structs.UserStruct, providerName string, external social.User, token social.Token, event string) error {
+	identities, err := models.GetUserIdentities(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if identity.Provider == providerName {
+			return ErrSocialProviderLinked
+		}
+	}
+
+	identity := socialIdentity(models.UserIdentity{UserID: user.ID, Provider: providerName}, external, token)
+	if err := models.CreateUserIdentity(identity); err != nil {
+		return err
+	}
+
+	recordSocialEvent(context, user.ID, event, providerName)
+	return nil
+}
+
+// socialIdentity copia el perfil y los tokens (cifrados) a la identidad
+func socialIdentity(identity models.UserIdentity, external social.User, token social.Token) models.UserIdentity {
+	identity.ProviderUserID = external.Subject
+	identity.Email = external.Email
+	identity.Name = external.Name
+	identity.AvatarURL = external.AvatarURL
+	identity.AccessToken = encryptSocialToken(token.AccessToken)
+	identity.RefreshToken = encryptSocialToken(token.RefreshToken)
+	identity.ExpiresAt = ""
+	if !token.ExpiresAt.IsZero() {
+		identity.ExpiresAt = token.ExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	return identity
+}
+
+// encryptSocialToken cifra un token del proveedor; si falla no se guarda, porque solo son informativos
+func encryptSocialToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	encrypted, err := utils.Encrypt(token)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error cifrando el token del proveedor: %v", err))
+		return ""
+	}
+	return encrypted
+}
+
+func recordSocialEvent(context *gin.Context, userID int, event string, provider string) {
+	details := ""
+	if provider != "" {
+		details = "Proveedor " + provider
+	}
+	err := models.CreateSecurityEvent(models.SecurityEvent{
+		UserID:    int64(userID),
+		Event:     event,
+		IPAddress: context.ClientIP(),
+		UserAgent: context.Request.UserAgent(),
+		Details:   details,
+	})
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error registrando el evento %s: %v", event, err))
+	}
+}
@@ -0,0 +1,237 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"semita/app/core/social"
+	"semita/app/structs"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	// El state del login social viaja en la sesión; el driver cookie la firma con APP_KEY
+	os.Setenv("APP_KEY", "test-app-key-with-enough-entropy-000")
+	os.Setenv("SESSION_DRIVER", "cookie")
+	gin.SetMode(gin.TestMode)
+
+	code := m.Run()
+
+	// utils.Logs escribe en storage/logs relativo al directorio del paquete
+	os.RemoveAll("storage")
+	os.Exit(code)
+}
+
+// stubSocialProvider es un proveedor OAuth2 en un httptest.Server que exige el code_verifier que
+// corresponde al code_challenge de la autorización
+type stubSocialProvider struct {
+	server *httptest.Server
+
+	mutex     sync.Mutex
+	challenge string
+	exchanges int
+	verifier  string
+}
+
+func newStubSocialProvider(t *testing.T) *stubSocialProvider {
+	t.Helper()
+
+	stub := &stubSocialProvider{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		stub.mutex.Lock()
+		defer stub.mutex.Unlock()
+
+		stub.exchanges++
+		stub.verifier = r.PostFormValue("code_verifier")
+		if r.PostFormValue("code") != "the-code" || social.CodeChallenge(stub.verifier) != stub.challenge {
+			writeStubJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+			return
+		}
+		writeStubJSON(w, http.StatusOK, map[string]any{"access_token": "the-access-token", "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer the-access-token" {
+			writeStubJSON(w, http.StatusUnauthorized, map[string]any{"error": "invalid_token"})
+			return
+		}
+		writeStubJSON(w, http.StatusOK, map[string]any{"sub": "external-42", "email": "ana@example.com", "email_verified": true, "name": "Ana"})
+	})
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+// provider devuelve el cliente del proveedor con el nombre indicado
+func (s *stubSocialProvider) provider(name string) *social.Provider {
+	return social.New(social.Config{
+		Name:         name,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://app.example/auth/social/" + name + "/callback",
+		AuthURL:      s.server.URL + "/authorize",
+		TokenURL:     s.server.URL + "/token",
+		UserInfoURL:  s.server.URL + "/userinfo",
+	}, s.server.Client())
+}
+
+func writeStubJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// startSocialTestLogin ejecuta la redirección al proveedor y devuelve los parámetros de la URL de
+// autorización y las cookies de la sesión con el state guardado
+func startSocialTestLogin(t *testing.T, stub *stubSocialProvider, provider *social.Provider) (url.Values, []*http.Cookie) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	context, _ := gin.CreateTestContext(recorder)
+	context.Request = httptest.NewRequest(http.MethodGet, "/auth/social/"+provider.Name(), nil)
+
+	redirectURL, err := StartSocialLogin(context, provider)
+	if err != nil {
+		t.Fatalf("StartSocialLogin: %v", err)
+	}
+	parsed, err := url.Parse(redirectURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub.mutex.Lock()
+	stub.challenge = parsed.Query().Get("code_challenge")
+	stub.mutex.Unlock()
+
+	return parsed.Query(), recorder.Result().Cookies()
+}
+
+// finishSocialTestLogin ejecuta el callback con los parámetros y cookies indicados y devuelve las
+// cookies de la respuesta
+func finishSocialTestLogin(provider *social.Provider, query url.Values, cookies []*http.Cookie) (social.User, []*http.Cookie, error) {
+	recorder := httptest.NewRecorder()
+	context, _ := gin.CreateTestContext(recorder)
+	context.Request = httptest.NewRequest(http.MethodGet, "/auth/social/"+provider.Name()+"/callback?"+query.Encode(), nil)
+	for _, cookie := range cookies {
+		context.Request.AddCookie(cookie)
+	}
+
+	user, _, err := FinishSocialLogin(context, provider)
+	return user, recorder.Result().Cookies(), err
+}
+
+func TestSocialLoginExchangesCodeWithPKCE(t *testing.T) {
+	stub := newStubSocialProvider(t)
+	provider := stub.provider("test")
+
+	authorization, cookies := startSocialTestLogin(t, stub, provider)
+	if authorization.Get("state") == "" || authorization.Get("code_challenge_method") != "S256" {
+		t.Fatalf("authorization parameters = %v", authorization)
+	}
+
+	callback := url.Values{"state": {authorization.Get("state")}, "code": {"the-code"}}
+	user, _, err := finishSocialTestLogin(provider, callback, cookies)
+	if err != nil {
+		t.Fatalf("FinishSocialLogin: %v", err)
+	}
+	if user.Subject != "external-42" || user.Email != "ana@example.com" || !user.EmailVerified {
+		t.Errorf("user = %+v", user)
+	}
+
+	stub.mutex.Lock()
+	defer stub.mutex.Unlock()
+	if stub.verifier == "" || social.CodeChallenge(stub.verifier) != authorization.Get("code_challenge") {
+		t.Errorf("code_verifier %q does not match the code_challenge of the authorization", stub.verifier)
+	}
+}
+
+func TestFinishSocialLoginRejectsStateMismatch(t *testing.T) {
+	stub := newStubSocialProvider(t)
+	provider := stub.provider("test")
+
+	authorization, cookies := startSocialTestLogin(t, stub, provider)
+
+	_, cookies, err := finishSocialTestLogin(provider, url.Values{"state": {"forged-state"}, "code": {"the-code"}}, cookies)
+	if !errors.Is(err, social.ErrInvalidState) {
+		t.Fatalf("err = %v, want %v", err, social.ErrInvalidState)
+	}
+
+	// El state se consume aunque no coincida: el callback legítimo ya no sirve
+	_, _, err = finishSocialTestLogin(provider, url.Values{"state": {authorization.Get("state")}, "code": {"the-code"}}, cookies)
+	if !errors.Is(err, social.ErrInvalidState) {
+		t.Fatalf("err after a failed callback = %v, want %v", err, social.ErrInvalidState)
+	}
+
+	if stub.exchanges != 0 {
+		t.Errorf("the code was exchanged %d times with an invalid state", stub.exchanges)
+	}
+}
+
+func TestFinishSocialLoginRequiresStartedLogin(t *testing.T) {
+	stub := newStubSocialProvider(t)
+	provider := stub.provider("test")
+
+	_, _, err := finishSocialTestLogin(provider, url.Values{"state": {"any-state"}, "code": {"the-code"}}, nil)
+	if !errors.Is(err, social.ErrInvalidState) {
+		t.Fatalf("err = %v, want %v", err, social.ErrInvalidState)
+	}
+}
+
+func TestFinishSocialLoginRejectsAnotherProvider(t *testing.T) {
+	stub := newStubSocialProvider(t)
+
+	authorization, cookies := startSocialTestLogin(t, stub, stub.provider("test"))
+
+	callback := url.Values{"state": {authorization.Get("state")}, "code": {"the-code"}}
+	_, _, err := finishSocialTestLogin(stub.provider("other"), callback, cookies)
+	if !errors.Is(err, social.ErrInvalidState) {
+		t.Fatalf("err = %v, want %v", err, social.ErrInvalidState)
+	}
+}
+
+func TestFinishSocialLoginReportsDeniedAuthorization(t *testing.T) {
+	stub := newStubSocialProvider(t)
+	provider := stub.provider("test")
+
+	authorization, cookies := startSocialTestLogin(t, stub, provider)
+
+	callback := url.Values{"state": {authorization.Get("state")}, "error": {"access_denied"}}
+	_, _, err := finishSocialTestLogin(provider, callback, cookies)
+	if !errors.Is(err, social.ErrProviderDenied) {
+		t.Fatalf("err = %v, want %v", err, social.ErrProviderDenied)
+	}
+	if stub.exchanges != 0 {
+		t.Errorf("the code was exchanged %d times after the provider denied the authorization", stub.exchanges)
+	}
+}
+
+func TestSocialEmailLinkable(t *testing.T) {
+	verifiedUser := structs.UserStruct{ID: 1, Email: "ana@example.com", EmailVerifiedAt: "2025-07-15 10:00:00"}
+	unverifiedUser := structs.UserStruct{ID: 1, Email: "ana@example.com"}
+
+	cases := []struct {
+		name     string
+		external social.User
+		user     structs.UserStruct
+		want     bool
+	}{
+		{"both verified", social.User{Email: "ana@example.com", EmailVerified: true}, verifiedUser, true},
+		{"different case", social.User{Email: "Ana@Example.com", EmailVerified: true}, verifiedUser, true},
+		{"provider email not verified", social.User{Email: "ana@example.com"}, verifiedUser, false},
+		{"local email not verified", social.User{Email: "ana@example.com", EmailVerified: true}, unverifiedUser, false},
+		{"neither verified", social.User{Email: "ana@example.com"}, unverifiedUser, false},
+		{"other email", social.User{Email: "eva@example.com", EmailVerified: true}, verifiedUser, false},
+	}
+
+	for _, tc := range cases {
+		if got := socialEmailLinkable(tc.external, tc.user); got != tc.want {
+			t.Errorf("%s: socialEmailLinkable = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
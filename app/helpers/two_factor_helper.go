@@ -0,0 +1,338 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"semita/app/models"
+	"semita/app/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+var (
+	// ErrTwoFactorNotPending se devuelve al confirmar sin haber iniciado la activación
+	ErrTwoFactorNotPending = errors.New("la autenticación en dos pasos no está pendiente de confirmar")
+	// ErrInvalidTwoFactorCode se devuelve cuando el código TOTP o de recuperación no es válido
+	ErrInvalidTwoFactorCode = errors.New("código de autenticación inválido")
+	// ErrInvalidMFAToken se devuelve cuando el mfa_token fue alterado o expiró
+	ErrInvalidMFAToken = errors.New("mfa_token inválido o expirado")
+)
+
+// recoveryCodeCount es la cantidad de códigos de recuperación que se emiten al activar 2FA
+const recoveryCodeCount = 8
+
+// mfaTokenLifetime es el tiempo que tiene un cliente de la API para responder al desafío de 2FA
+const mfaTokenLifetime = 5 * time.Minute
+
+// TwoFactorSetup son los datos para registrar el secreto en una app autenticadora
+type TwoFactorSetup struct {
+	Secret    string // Secreto en base32 para ingresarlo a mano
+	URI       string // URI otpauth://
+	QRDataURI string // PNG del QR en base64, listo para un <img src>
+}
+
+// TwoFactorEnabled indica si el usuario tiene la autenticación en dos pasos confirmada
+func TwoFactorEnabled(userID int) bool {
+	twoFactor, err := models.GetTwoFactor(userID)
+	return err == nil && twoFactor.Enabled()
+}
+
+// TwoFactorRequired indica si algún rol del usuario exige autenticación en dos pasos
+func TwoFactorRequired(userID int) bool {
+	required, err := models.UserRequiresTwoFactor(userID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error consultando la política de 2FA: %v", err))
+		return false
+	}
+	return required
+}
+
+// MustEnrollTwoFactor indica si el usuario debe activar 2FA antes de seguir usando la aplicación
+func MustEnrollTwoFactor(userID int) bool {
+	return TwoFactorRequired(userID) && !TwoFactorEnabled(userID)
+}
+
+// EnableTwoFactor genera un secreto nuevo sin confirmar para el usuario
+func EnableTwoFactor(userID int) error {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return err
+	}
+	encrypted, err := utils.Encrypt(secret)
+	if err != nil {
+		return err
+	}
+	return models.SaveTwoFactorSecret(userID, encrypted)
+}
+
+// PendingTwoFactorSetup devuelve el QR y el secreto de una activación sin confirmar
+func PendingTwoFactorSetup(userID int, email string) (TwoFactorSetup, error) {
+	twoFactor, err := models.GetTwoFactor(userID)
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+	if !twoFactor.Pending() {
+		return TwoFactorSetup{}, ErrTwoFactorNotPending
+	}
+
+	secret, err := utils.Decrypt(twoFactor.Secret)
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+
+	issuer := utils.GetEnv("APP_NAME")
+	if issuer == "" {
+		issuer = "Semita"
+	}
+	uri := utils.TOTPProvisioningURI(issuer, email, secret)
+
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+
+	return TwoFactorSetup{
+		Secret:    secret,
+		URI:       uri,
+		QRDataURI: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmTwoFactor activa 2FA si el código corresponde al secreto pendiente.
+// Devuelve los códigos de recuperación en claro; solo se guardan sus hashes.
+func ConfirmTwoFactor(userID int, code string) ([]string, error) {
+	twoFactor, err := models.GetTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !twoFactor.Pending() {
+		return nil, ErrTwoFactorNotPending
+	}
+
+	if !verifyTOTP(userID, twoFactor, code) {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := models.ConfirmTwoFactor(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	recordTwoFactorEvent(userID, "two_factor.enabled")
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes reemplaza los códigos de recuperación del usuario
+func RegenerateRecoveryCodes(userID int) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := models.UpdateTwoFactorRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	recordTwoFactorEvent(userID, "two_factor.recovery_codes_regenerated")
+	return codes, nil
+}
+
+// DisableTwoFactor desactiva la autenticación en dos pasos del usuario
+func DisableTwoFactor(userID int) error {
+	if err := models.DisableTwoFactor(userID); err != nil {
+		return err
+	}
+	recordTwoFactorEvent(userID, "two_factor.disabled")
+	return nil
+}
+
+// VerifyTwoFactorChallenge valida el segundo factor de un inicio de sesión: un código TOTP
+// o, si no se envía, un código de recuperación, que se consume al usarlo.
+func VerifyTwoFactorChallenge(userID int, code string, recoveryCode string) error {
+	twoFactor, err := models.GetTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Enabled() {
+		return ErrInvalidTwoFactorCode
+	}
+
+	if code != "" {
+		if verifyTOTP(userID, twoFactor, code) {
+			return nil
+		}
+		return ErrInvalidTwoFactorCode
+	}
+
+	if recoveryCode != "" && useRecoveryCode(userID, twoFactor, recoveryCode) {
+		return nil
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+// IssueMFAToken genera el token firmado que un cliente de la API devuelve junto con el código
+// para completar un password grant que exige segundo factor
+func IssueMFAToken(userID int, clientID string, scope string, nonce string) (string, error) {
+	expires := time.Now().Add(mfaTokenLifetime).Unix()
+	payload := strings.Join([]string{strconv.Itoa(userID), clientID, strconv.FormatInt(expires, 10), scope, nonce}, "|")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	signature, err := mfaTokenSignature(encoded)
+	if err != nil {
+		return "", err
+	}
+	return encoded + "." + signature, nil
+}
+
+// ParseMFAToken valida un mfa_token y devuelve el usuario, el cliente, los scopes y el nonce del login original
+func ParseMFAToken(token string) (userID int, clientID string, scope string, nonce string, err error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return 0, "", "", "", ErrInvalidMFAToken
+	}
+	expected, err := mfaTokenSignature(encoded)
+	if err != nil {
+		return 0, "", "", "", err
+	}
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return 0, "", "", "", ErrInvalidMFAToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, "", "", "", ErrInvalidMFAToken
+	}
+
+	parts := strings.SplitN(string(payload), "|", 5)
+	if len(parts) != 5 {
+		return 0, "", "", "", ErrInvalidMFAToken
+	}
+
+	userID, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", "", "", ErrInvalidMFAToken
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return 0, "", "", "", ErrInvalidMFAToken
+	}
+
+	return userID, parts[1], parts[3], parts[4], nil
+}
+
+// mfaTokenSignature firma el mfa_token con APP_KEY; sin APP_KEY no firma ni valida
+func mfaTokenSignature(encoded string) (string, error) {
+	key, err := utils.AppKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("mfa-token:" + encoded))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifyTOTP valida el código con el secreto del usuario y registra el paso para que no se reutilice
+func verifyTOTP(userID int, twoFactor models.TwoFactor, code string) bool {
+	secret, err := utils.Decrypt(twoFactor.Secret)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("No se pudo descifrar el secreto 2FA del usuario %d: %v", userID, err))
+		return false
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	claimed, err := models.ClaimTwoFactorStep(userID, step)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error registrando el código 2FA: %v", err))
+		return false
+	}
+	return claimed
+}
+
+// useRecoveryCode consume un código de recuperación si coincide con alguno de los guardados
+func useRecoveryCode(userID int, twoFactor models.TwoFactor, code string) bool {
+	var hashes []string
+	if err := json.Unmarshal([]byte(twoFactor.RecoveryCodes), &hashes); err != nil {
+		return false
+	}
+
+	hash := utils.HashToken(normalizeRecoveryCode(code))
+	for i, stored := range hashes {
+		if !hmac.Equal([]byte(stored), []byte(hash)) {
+			continue
+		}
+
+		// La actualización exige los códigos leídos: si una petición simultánea ya gastó este u otro
+		// código, no se modifica ninguna fila y el intento falla
+		remaining, _ := json.Marshal(append(hashes[:i:i], hashes[i+1:]...))
+		consumed, err := models.ConsumeTwoFactorRecoveryCodes(userID, twoFactor.RecoveryCodes, string(remaining))
+		if err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error consumiendo el código de recuperación: %v", err))
+			return false
+		}
+		if !consumed {
+			return false
+		}
+
+		recordTwoFactorEvent(userID, "two_factor.recovery_code_used")
+		return true
+	}
+
+	return false
+}
+
+// generateRecoveryCodes genera códigos con formato XXXXX-XXXXX y el JSON con sus hashes
+func generateRecoveryCodes() ([]string, string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		var builder strings.Builder
+		for i := 0; i < 10; i++ {
+			if i == 5 {
+				builder.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return nil, "", err
+			}
+			builder.WriteByte(alphabet[n.Int64()])
+		}
+
+		code := builder.String()
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	encoded, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+	return codes, string(encoded), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func recordTwoFactorEvent(userID int, event string) {
+	if err := models.CreateSecurityEvent(models.SecurityEvent{UserID: int64(userID), Event: event}); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error registrando el evento %s: %v", event, err))
+	}
+}
@@ -13,7 +13,7 @@ import (
 
 // View renderiza una vista con el layout principal y contexto de sesión usando gin.Context
 func View(context *gin.Context, viewPath string, title string, data interface{}) {
-	authData := AuthSessionService(context.Writer, context.Request, title, data)
+	authData := AuthSessionService(context, title, data)
 
 	fullViewPath := filepath.Join("resources", viewPath)
 	tmpl := template.Must(template.ParseFiles(fullViewPath, config.MainLayoutFilePath))
@@ -0,0 +1,228 @@
+package helpers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"semita/app/core/webauthn"
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrPasskeyChallengeMissing se devuelve cuando no hay un desafío pendiente en la sesión
+	ErrPasskeyChallengeMissing = errors.New("no hay un desafío de passkey pendiente")
+	// ErrPasskeyNotFound se devuelve cuando la passkey usada no está registrada
+	ErrPasskeyNotFound = errors.New("passkey no registrada")
+)
+
+// Claves de la sesión donde se guardan los desafíos; cada uno se usa una sola vez
+const (
+	passkeyRegistrationChallengeKey = "webauthn_registration_challenge"
+	passkeyLoginChallengeKey        = "webauthn_login_challenge"
+)
+
+// PasskeyRelyingParty devuelve el relying party de la aplicación a partir de APP_NAME y APP_URL
+func PasskeyRelyingParty() (webauthn.RelyingParty, error) {
+	name := utils.GetEnv("APP_NAME")
+	if name == "" {
+		name = "Semita"
+	}
+	return webauthn.NewRelyingParty(name, utils.AppURL())
+}
+
+// PasskeyCreationOptions genera las opciones para registrar una passkey y guarda el desafío en la sesión
+func PasskeyCreationOptions(context *gin.Context, user structs.UserStruct) (webauthn.CreationOptions, error) {
+	rp, err := PasskeyRelyingParty()
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	existing, err := userPasskeys(user.ID)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+	if err := utils.PutSessionValue(context.Writer, context.Request, passkeyRegistrationChallengeKey, challenge); err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	return rp.NewCreationOptions(challenge, passkeyUserHandle(user.ID), user.Email, user.Name, existing), nil
+}
+
+// RegisterPasskey valida la ceremonia de registro con el desafío de la sesión y guarda la passkey
+func RegisterPasskey(context *gin.Context, user structs.UserStruct, name string, attestation webauthn.AttestationResponse) error {
+	challenge := utils.PullSessionValue(context.Writer, context.Request, passkeyRegistrationChallengeKey)
+	if challenge == "" {
+		return ErrPasskeyChallengeMissing
+	}
+
+	rp, err := PasskeyRelyingParty()
+	if err != nil {
+		return err
+	}
+
+	credential, err := rp.VerifyRegistration(challenge, attestation)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = "Passkey"
+	}
+
+	err = models.CreateWebAuthnCredential(models.WebAuthnCredential{
+		UserID:            user.ID,
+		Name:              name,
+		CredentialID:      base64.RawURLEncoding.EncodeToString(credential.ID),
+		PublicKey:         credential.PublicKey,
+		SignCount:         credential.SignCount,
+		AAGUID:            formatAAGUID(credential.AAGUID),
+		AttestationFormat: credential.AttestationFormat,
+		BackupEligible:    credential.BackupEligible,
+	})
+	if err != nil {
+		return err
+	}
+
+	recordPasskeyEvent(context, user.ID, "webauthn.registered")
+	return nil
+}
+
+// DeletePasskey elimina una passkey del usuario
+func DeletePasskey(context *gin.Context, userID int, id int64) error {
+	deleted, err := models.DeleteUserWebAuthnCredential(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPasskeyNotFound
+	}
+
+	recordPasskeyEvent(context, userID, "webauthn.deleted")
+	return nil
+}
+
+// PasskeyRequestOptions genera las opciones para iniciar sesión con una passkey y guarda el desafío en la sesión
+func PasskeyRequestOptions(context *gin.Context) (webauthn.RequestOptions, error) {
+	rp, err := PasskeyRelyingParty()
+	if err != nil {
+		return webauthn.RequestOptions{}, err
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return webauthn.RequestOptions{}, err
+	}
+	if err := utils.PutSessionValue(context.Writer, context.Request, passkeyLoginChallengeKey, challenge); err != nil {
+		return webauthn.RequestOptions{}, err
+	}
+
+	return rp.NewRequestOptions(challenge, nil), nil
+}
+
+// AuthenticatePasskey valida la ceremonia de autenticación y devuelve el usuario dueño de la passkey.
+// Se exige verificación del usuario (PIN o biometría), por lo que la passkey reemplaza a la contraseña y al 2FA.
+func AuthenticatePasskey(context *gin.Context, assertion webauthn.AssertionResponse) (structs.UserStruct, error) {
+	challenge := utils.PullSessionValue(context.Writer, context.Request, passkeyLoginChallengeKey)
+	if challenge == "" {
+		return structs.UserStruct{}, ErrPasskeyChallengeMissing
+	}
+
+	rp, err := PasskeyRelyingParty()
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	credentialID, err := assertion.CredentialID()
+	if err != nil {
+		return structs.UserStruct{}, ErrPasskeyNotFound
+	}
+	stored, err := models.GetWebAuthnCredentialByCredentialID(base64.RawURLEncoding.EncodeToString(credentialID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return structs.UserStruct{}, ErrPasskeyNotFound
+	}
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	// El autenticador devuelve el ID de usuario con el que se registró la passkey
+	if handle := assertion.UserHandle(); len(handle) > 0 && string(handle) != string(passkeyUserHandle(stored.UserID)) {
+		return structs.UserStruct{}, ErrPasskeyNotFound
+	}
+
+	signCount, err := rp.VerifyAssertion(challenge, toWebAuthnCredential(stored), assertion, true)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrClonedAuthenticator) {
+			recordPasskeyEvent(context, stored.UserID, "webauthn.cloned_authenticator")
+		}
+		return structs.UserStruct{}, err
+	}
+
+	if err := models.TouchWebAuthnCredential(stored.ID, signCount); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error actualizando la passkey: %v", err))
+	}
+
+	return models.GetUserByID(strconv.Itoa(stored.UserID))
+}
+
+// userPasskeys devuelve las passkeys del usuario en el formato del paquete webauthn
+func userPasskeys(userID int) ([]webauthn.Credential, error) {
+	stored, err := models.GetUserWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(stored))
+	for _, credential := range stored {
+		credentials = append(credentials, toWebAuthnCredential(credential))
+	}
+	return credentials, nil
+}
+
+func toWebAuthnCredential(credential models.WebAuthnCredential) webauthn.Credential {
+	id, _ := base64.RawURLEncoding.DecodeString(credential.CredentialID)
+	return webauthn.Credential{
+		ID:                id,
+		PublicKey:         credential.PublicKey,
+		SignCount:         credential.SignCount,
+		AttestationFormat: credential.AttestationFormat,
+		BackupEligible:    credential.BackupEligible,
+	}
+}
+
+// passkeyUserHandle es el ID de usuario que se entrega al autenticador
+func passkeyUserHandle(userID int) []byte {
+	return []byte(strconv.Itoa(userID))
+}
+
+// formatAAGUID muestra el AAGUID del autenticador con formato UUID
+func formatAAGUID(aaguid []byte) string {
+	if len(aaguid) != 16 {
+		return ""
+	}
+	value := hex.EncodeToString(aaguid)
+	return value[0:8] + "-" + value[8:12] + "-" + value[12:16] + "-" + value[16:20] + "-" + value[20:]
+}
+
+func recordPasskeyEvent(context *gin.Context, userID int, event string) {
+	err := models.CreateSecurityEvent(models.SecurityEvent{
+		UserID:    int64(userID),
+		Event:     event,
+		IPAddress: context.ClientIP(),
+		UserAgent: context.Request.UserAgent(),
+	})
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error registrando el evento %s: %v", event, err))
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	authprovider "semita/app/core/auth"
+	"semita/app/helpers"
+	"semita/app/http/resources"
+	"semita/app/models"
+	"semita/app/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActiveSessionIndex lista las sesiones web y los tokens OAuth activos del usuario autenticado
+func ActiveSessionIndex(c *gin.Context) {
+	userID := int64(authprovider.ID(c))
+
+	sessions, err := helpers.ActiveSessions(userID, "")
+	if err != nil {
+		activeSessionError(c, err)
+		return
+	}
+
+	tokens, err := models.GetActiveUserTokens(userID)
+	if err != nil {
+		activeSessionError(c, err)
+		return
+	}
+
+	var currentTokenID int64
+	if current, exists := c.Get("token"); exists {
+		if token, ok := current.(*models.OAuthToken); ok {
+			currentTokenID = token.ID
+		}
+	}
+
+	webSessions := make([]resources.WebSessionResource, 0, len(sessions))
+	for _, session := range sessions {
+		webSessions = append(webSessions, resources.NewWebSessionResource(session))
+	}
+
+	accessTokens := make([]resources.AccessTokenResource, 0, len(tokens))
+	for _, token := range tokens {
+		accessTokens = append(accessTokens, resources.NewAccessTokenResource(token.ID, token.Name, token.ClientName,
+			token.GetScopesArray(), token.IPAddress, token.UserAgent, token.LastUsedAt, token.ExpiresAt, token.CreatedAt,
+			token.ID == currentTokenID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"web_sessions": webSessions,
+			"tokens":       accessTokens,
+		},
+		"meta": gin.H{
+			"session_driver":    utils.SessionDriver(),
+			"sessions_listable": helpers.SessionsListable(),
+		},
+	})
+}
+
+// ActiveSessionDestroy cierra una sesión web del usuario autenticado
+func ActiveSessionDestroy(c *gin.Context) {
+	userID := int64(authprovider.ID(c))
+
+	if err := helpers.TerminateSession(userID, c.Param("id")); err != nil {
+		activeSessionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ActiveTokenDestroy revoca un token OAuth del usuario autenticado
+func ActiveTokenDestroy(c *gin.Context) {
+	userID := int64(authprovider.ID(c))
+
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
+			"status": "400",
+			"title":  "Validation Error",
+			"detail": "Invalid token ID",
+		}}})
+		return
+	}
+
+	if err := models.RevokeUserToken(userID, tokenID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"errors": []gin.H{{
+			"status": "404",
+			"title":  "Not Found",
+			"detail": err.Error(),
+		}}})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ActiveSessionDestroyAll cierra todas las sesiones web y revoca todos los tokens, incluido el actual
+func ActiveSessionDestroyAll(c *gin.Context) {
+	userID := int64(authprovider.ID(c))
+
+	if err := helpers.LogoutEverywhere(userID); err != nil {
+		activeSessionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// activeSessionError responde según el tipo de error
+func activeSessionError(c *gin.Context, err error) {
+	if errors.Is(err, helpers.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"errors": []gin.H{{
+			"status": "404",
+			"title":  "Not Found",
+			"detail": err.Error(),
+		}}})
+		return
+	}
+
+	utils.Logs("ERROR", "Error managing active sessions: "+err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+		"status": "500",
+		"title":  "Server Error",
+		"detail": "No se pudieron consultar las sesiones",
+	}}})
+}
@@ -1,13 +1,13 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"semita/app/http/requests"
 	"semita/app/models"
 	"semita/app/notifications"
-	"semita/app/structs"
 	"semita/app/utils"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -23,25 +23,22 @@ func ForgotPassword(context *gin.Context) {
 		}}})
 		return
 	}
-	user, err := models.GetUserByEmail(req.Email)
-	if err != nil {
-		context.JSON(http.StatusOK, gin.H{"message": "Si el email existe, se enviará un enlace de recuperación"})
-		return
+	// La respuesta es la misma exista o no el email, esté limitada la solicitud o falle el envío,
+	// para no revelar qué cuentas existen
+	if user, err := models.GetUserByEmail(req.Email); err == nil {
+		token, err := models.CreatePasswordReset(user.Email)
+		if errors.Is(err, models.ErrPasswordResetThrottled) {
+			utils.Logs("INFO", fmt.Sprintf("Password reset request throttled for user %d", user.ID))
+		} else if err != nil {
+			utils.Logs("ERROR", "Error creating password reset: "+err.Error())
+		} else {
+			resetURL := utils.AppURL() + "/auth/reset-password?token=" + token
+			if err = notifications.SendPasswordReset(user.Email, resetURL); err != nil {
+				utils.Logs("ERROR", "Error sending password reset: "+err.Error())
+			}
+		}
 	}
 
-	token := utils.GenerateResetToken(user.Email)
-	resetURL := "http://" + utils.GetEnv("APP_URL") + "/auth/reset-password?token=" + token
-	_ = models.CreatePasswordReset(user.Email, token) // Guardar token en BD
-	err = notifications.SendPasswordReset(user.Email, resetURL)
-
-	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
-			"status": "500",
-			"title":  "Server Error",
-			"detail": "No se pudo enviar el correo de recuperación",
-		}}})
-		return
-	}
 	context.JSON(http.StatusOK, gin.H{"message": "Si el email existe, se enviará un enlace de recuperación"})
 }
 
@@ -57,7 +54,7 @@ func ResetPassword(context *gin.Context) {
 	}
 
 	pr, err := models.GetPasswordResetByToken(req.Token)
-	if err != nil {
+	if err != nil || pr.Email != req.Email {
 		context.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
 			"status": "400",
 			"title":  "Invalid Token",
@@ -66,8 +63,8 @@ func ResetPassword(context *gin.Context) {
 		return
 	}
 
-	if time.Since(pr.CreatedAt) > 2*time.Hour {
-		_ = models.DeletePasswordReset(req.Token)
+	if pr.Expired() {
+		_ = models.DeletePasswordResets(pr.Email)
 		context.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
 			"status": "400",
 			"title":  "Token Expired",
@@ -96,8 +93,8 @@ func ResetPassword(context *gin.Context) {
 		return
 	}
 
-	update := structs.UpdateUserStruct{ID: user.ID, Password: string(hashedPassword)}
-	err = models.UpdateUser(update)
+	// Guarda la contraseña y revoca los tokens OAuth y sesiones del usuario
+	err = models.ResetUserPassword(user.ID, user.Email, string(hashedPassword))
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
@@ -108,6 +105,5 @@ func ResetPassword(context *gin.Context) {
 		return
 	}
 
-	_ = models.DeletePasswordReset(req.Token)
 	context.JSON(http.StatusOK, gin.H{"message": "Contraseña restablecida"})
 }
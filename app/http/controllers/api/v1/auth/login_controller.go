@@ -2,12 +2,18 @@ package auth
 
 import (
 	"net/http"
+	authprovider "semita/app/core/auth"
+	"semita/app/core/throttle"
+	"semita/app/helpers"
 	"semita/app/http/requests"
 	"semita/app/http/resources"
 	"semita/app/models"
+	"semita/app/utils"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func Login(c *gin.Context) {
@@ -21,28 +27,25 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	storedUser, err := models.GetUserByEmail(req.Email)
+	if locked, retryAfter := throttle.LoginLockedOut(req.Email, c.ClientIP()); locked {
+		tooManyLoginAttempts(c, retryAfter)
+		return
+	}
+
+	storedUser, err := authprovider.Attempt(c.Request.Context(), authprovider.Credentials{Login: req.Email, Password: req.Password})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
-			"status": "401",
-			"title":  "Unauthorized",
-			"detail": "Invalid email or password",
-		}}})
+		loginFailed(c, req.Email, int64(storedUser.ID))
 		return
 	}
 
-	errPassword := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(req.Password))
-	if errPassword != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
-			"status": "401",
-			"title":  "Unauthorized",
-			"detail": "Invalid email or password",
-		}}})
+	if !verifyLoginSecondFactor(c, storedUser.ID, req.Otp, req.RecoveryCode) {
 		return
 	}
 
-	clients, err := models.GetAllClients()
-	if err != nil || len(clients) == 0 {
+	throttle.ClearLoginAttempts(req.Email, c.ClientIP())
+
+	client, err := models.GetClientByGrantType("password")
+	if err != nil || client == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
 			"status": "500",
 			"title":  "Server Error",
@@ -50,8 +53,17 @@ func Login(c *gin.Context) {
 		}}})
 		return
 	}
-	client := clients[0]
-	token, err := models.CreateToken(int64(storedUser.ID), client.ID, "")
+	scopes, err := models.ResolveRequestedScopes(client, utils.ParseScopes(req.Scope))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
+			"status": "400",
+			"title":  "invalid_scope",
+			"detail": err.Error(),
+		}}})
+		return
+	}
+
+	token, err := models.CreateToken(int64(storedUser.ID), client.ID, strings.Join(scopes, ","))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
 			"status": "500",
@@ -63,5 +75,82 @@ func Login(c *gin.Context) {
 
 	resource := resources.NewAuthResource(uint(storedUser.ID), storedUser.Name, storedUser.Email, token.AccessToken)
 	response := resources.NewAuthLoginResponse(resource, token.RefreshToken, 86400, token.Scopes)
+	if helpers.RequestsOpenID(scopes) {
+		idToken, err := helpers.IssueIDToken(storedUser, client.ClientID, scopes, req.Nonce, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+				"status": "500",
+				"title":  "Server Error",
+				"detail": "Error generating id_token",
+			}}})
+			return
+		}
+		response.Data.Meta.IDToken = idToken
+	}
 	c.JSON(http.StatusOK, response)
 }
+
+// loginFailed registra el intento fallido y responde 401, o 429 si con él se bloqueó el acceso
+func loginFailed(c *gin.Context, email string, userID int64) {
+	if locked, retryAfter := throttle.FailedLogin(email, c.ClientIP(), c.Request.UserAgent(), userID); locked {
+		tooManyLoginAttempts(c, retryAfter)
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
+		"status": "401",
+		"title":  "Unauthorized",
+		"detail": "Invalid email or password",
+	}}})
+}
+
+// tooManyLoginAttempts responde 429 con Retry-After mientras dura el bloqueo
+func tooManyLoginAttempts(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(throttle.RetryAfterSeconds(retryAfter)))
+	c.JSON(http.StatusTooManyRequests, gin.H{"errors": []gin.H{{
+		"status": "429",
+		"title":  "Too Many Requests",
+		"detail": throttle.TooManyAttemptsMessage(retryAfter),
+	}}})
+}
+
+// verifyLoginSecondFactor exige el segundo factor a los usuarios con 2FA activo y a los que su rol se lo exige
+func verifyLoginSecondFactor(c *gin.Context, userID int, otp string, recoveryCode string) bool {
+	if helpers.MustEnrollTwoFactor(userID) {
+		c.JSON(http.StatusForbidden, gin.H{"errors": []gin.H{{
+			"status": "403",
+			"title":  "mfa_enrollment_required",
+			"detail": "Two-factor authentication must be enabled before signing in",
+		}}})
+		return false
+	}
+
+	if !helpers.TwoFactorEnabled(userID) {
+		return true
+	}
+
+	if otp == "" && recoveryCode == "" {
+		c.JSON(http.StatusForbidden, gin.H{"errors": []gin.H{{
+			"status": "403",
+			"title":  "mfa_required",
+			"detail": "Send the otp or recovery_code field along with your credentials",
+		}}})
+		return false
+	}
+
+	if allowed, retryAfter := throttle.Attempt("two-factor:"+strconv.Itoa(userID), 5, time.Minute); !allowed {
+		tooManyLoginAttempts(c, retryAfter)
+		return false
+	}
+
+	if err := helpers.VerifyTwoFactorChallenge(userID, otp, recoveryCode); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"errors": []gin.H{{
+			"status": "401",
+			"title":  "Unauthorized",
+			"detail": "Invalid authentication code",
+		}}})
+		return false
+	}
+
+	return true
+}
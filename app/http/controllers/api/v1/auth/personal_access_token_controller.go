@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	authprovider "semita/app/core/auth"
+	"semita/app/http/requests"
+	"semita/app/http/resources"
+	"semita/app/models"
+	"semita/app/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalAccessTokenIndex lista los tokens de acceso personal del usuario autenticado
+func PersonalAccessTokenIndex(c *gin.Context) {
+	userID := int64(authprovider.ID(c))
+
+	tokens, err := models.GetPersonalAccessTokens(userID)
+	if err != nil {
+		personalAccessTokenError(c, err)
+		return
+	}
+
+	data := make([]resources.PersonalAccessTokenResource, 0, len(tokens))
+	for _, token := range tokens {
+		data = append(data, resources.NewPersonalAccessTokenResource(token.ID, token.Name, token.GetScopesArray(),
+			token.LastUsedAt, token.ExpiresAt, token.CreatedAt))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// PersonalAccessTokenStore crea un token de acceso personal; su valor solo se devuelve en esta respuesta
+func PersonalAccessTokenStore(c *gin.Context) {
+	userID := int64(authprovider.ID(c))
+
+	var req requests.PersonalAccessTokenRequest
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
+			"status": "400",
+			"title":  "Validation Error",
+			"detail": err.Error(),
+		}}})
+		return
+	}
+
+	// Un token no puede emitir otro con más alcance que el suyo
+	tokenScopes := c.GetStringSlice("token_scopes")
+	if !utils.HasAllScopes(tokenScopes, req.Scopes) {
+		c.JSON(http.StatusForbidden, gin.H{"errors": []gin.H{{
+			"status": "403",
+			"title":  "Forbidden",
+			"detail": "The requested scopes exceed the scopes of the current access token",
+		}}})
+		return
+	}
+
+	expiresAt, _ := req.Expiration()
+	token, err := models.CreatePersonalAccessToken(userID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		personalAccessTokenError(c, err)
+		return
+	}
+
+	resource := resources.NewPersonalAccessTokenResource(token.ID, token.Name, token.GetScopesArray(),
+		token.LastUsedAt, token.ExpiresAt, token.CreatedAt)
+	c.JSON(http.StatusCreated, gin.H{"data": resource.WithToken(token.AccessToken)})
+}
+
+// PersonalAccessTokenDestroy revoca un token de acceso personal del usuario autenticado
+func PersonalAccessTokenDestroy(c *gin.Context) {
+	userID := int64(authprovider.ID(c))
+
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{
+			"status": "400",
+			"title":  "Validation Error",
+			"detail": "Invalid token ID",
+		}}})
+		return
+	}
+
+	if err := models.RevokePersonalAccessToken(userID, tokenID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"errors": []gin.H{{
+			"status": "404",
+			"title":  "Not Found",
+			"detail": err.Error(),
+		}}})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// personalAccessTokenError responde según el tipo de error del modelo
+func personalAccessTokenError(c *gin.Context, err error) {
+	if errors.Is(err, models.ErrNoPersonalAccessClient) {
+		c.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
+			"status": "500",
+			"title":  "Server Error",
+			"detail": "No personal access client available",
+		}}})
+		return
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": []gin.H{{
+		"status": "422",
+		"title":  "Unprocessable Entity",
+		"detail": err.Error(),
+	}}})
+}
@@ -69,8 +69,8 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	clients, err := models.GetAllClients()
-	if err != nil || len(clients) == 0 {
+	client, err := models.GetClientByGrantType("password")
+	if err != nil || client == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
 			"status": "500",
 			"title":  "Server Error",
@@ -78,8 +78,7 @@ func Register(c *gin.Context) {
 		}}})
 		return
 	}
-	client := clients[0]
-	token, err := models.CreateToken(int64(storedUser.ID), client.ID, "")
+	token, err := models.CreateToken(int64(storedUser.ID), client.ID, client.Scopes)
 	if err != nil {
 		utils.Logs("ERROR", "Error generating OAuth token: "+err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"errors": []gin.H{{
@@ -1,36 +1,47 @@
 package auth
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
 	"net/http"
+	authprovider "semita/app/core/auth"
+	"semita/app/core/throttle"
+	"semita/app/helpers"
 	"semita/app/models"
-	"semita/app/notifications"
+	"semita/app/utils"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ResendEmailVerify envía un nuevo enlace de verificación al usuario del token
 func ResendEmailVerify(context *gin.Context) {
-	// Simulación: obtener usuario autenticado (en real, usar JWT o sesión)
-	userId := 1 // TODO: obtener del contexto real
-	user, err := models.GetUserByID(strconv.Itoa(userId))
-	if err != nil {
+	user, authenticated := authprovider.User(context)
+	if !authenticated {
 		context.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
 		return
 	}
-	// Generar hash de verificación
-	hash := generateEmailVerificationHash(user.ID, user.Email)
-	verifyURL := "/auth/email/verify/" + strconv.Itoa(user.ID) + "/" + hash
-	err = notifications.SendEmailVerification(user.Email, verifyURL)
+
+	if user.HasVerifiedEmail() {
+		context.JSON(http.StatusOK, gin.H{"message": "El email ya está verificado"})
+		return
+	}
+
+	if !throttleVerification(context, "email-verification:resend:"+strconv.Itoa(user.ID)) {
+		return
+	}
+
+	err := helpers.SendEmailVerification(user)
 	if err != nil {
+		utils.Logs("ERROR", "Error sending verification email: "+err.Error())
 		context.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo enviar el correo de verificación"})
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{"message": "Correo de verificación enviado", "verify_url": verifyURL})
+	context.JSON(http.StatusOK, gin.H{"message": "Correo de verificación enviado"})
 }
 
+// VerifyEmail marca el email como verificado a partir del enlace enviado por correo.
+// La firma y la expiración del enlace las valida el middleware ValidSignature, y el límite de
+// intentos el limitador auth de la ruta.
 func VerifyEmail(context *gin.Context) {
 	id := context.Param("id")
 	hash := context.Param("hash")
@@ -38,17 +49,24 @@ func VerifyEmail(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"error": "ID y hash requeridos"})
 		return
 	}
+
 	// Buscar usuario por ID
 	user, err := models.GetUserByID(id)
 	if err != nil {
 		context.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
 		return
 	}
-	// Validar hash
-	if hash != generateEmailVerificationHash(user.ID, user.Email) {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "Hash inválido"})
+	// Validar que el enlace corresponde al email actual
+	if !helpers.ValidEmailVerificationHash(user, hash) {
+		context.JSON(http.StatusForbidden, gin.H{"error": "Hash inválido"})
+		return
+	}
+
+	if user.HasVerifiedEmail() {
+		context.JSON(http.StatusOK, gin.H{"message": "El email ya está verificado"})
 		return
 	}
+
 	// Marcar email como verificado
 	err = models.MarkEmailVerified(user.ID)
 	if err != nil {
@@ -58,8 +76,14 @@ func VerifyEmail(context *gin.Context) {
 	context.JSON(http.StatusOK, gin.H{"message": "Email verificado correctamente"})
 }
 
-func generateEmailVerificationHash(userID int, email string) string {
-	h := sha256.New()
-	h.Write([]byte(fmt.Sprintf("%d:%s", userID, email)))
-	return hex.EncodeToString(h.Sum(nil))
+// throttleVerification limita el reenvío a 6 intentos por minuto y responde 429 cuando se supera
+func throttleVerification(context *gin.Context, key string) bool {
+	allowed, retryAfter := throttle.Attempt(key, 6, time.Minute)
+	if allowed {
+		return true
+	}
+
+	context.Header("Retry-After", strconv.Itoa(throttle.RetryAfterSeconds(retryAfter)))
+	context.JSON(http.StatusTooManyRequests, gin.H{"error": "Demasiados intentos, intenta de nuevo más tarde"})
+	return false
 }
@@ -1,7 +1,9 @@
 package base
 
 import (
+	"errors"
 	"net/http"
+	"semita/app/core/auth"
 	"semita/app/models"
 	"semita/app/structs"
 	"semita/app/utils"
@@ -67,6 +69,17 @@ func (pc *PermissionController) Store(c *gin.Context) {
 		return
 	}
 
+	// guard_name debe ser uno de los guards de autenticación (web, api)
+	if permissionData.GuardName != "" {
+		if _, ok := auth.GetGuard(permissionData.GuardName); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Unknown guard: " + permissionData.GuardName,
+			})
+			return
+		}
+	}
+
 	permission, err := models.CreatePermission(permissionData)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -168,7 +181,14 @@ func (pc *PermissionController) AssignToUser(c *gin.Context) {
 		return
 	}
 
-	err := models.AssignPermissionToUser(request.UserID, request.PermissionID)
+	err := models.AssignPermissionToUserWithWindow(request.UserID, request.PermissionID, request.TeamID, request.GrantWindow)
+	if errors.Is(err, models.ErrInvalidGrantWindow) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Error assigning permission to user: " + err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -238,7 +258,7 @@ func (pc *PermissionController) RevokeFromUser(c *gin.Context) {
 		return
 	}
 
-	err := models.RevokePermissionFromUser(request.UserID, request.PermissionID)
+	err := models.RevokePermissionFromUserInTeam(request.UserID, request.PermissionID, request.TeamID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -1,7 +1,9 @@
 package base
 
 import (
+	"errors"
 	"net/http"
+	"semita/app/core/auth"
 	"semita/app/models"
 	"semita/app/structs"
 	"semita/app/utils"
@@ -81,6 +83,17 @@ func (rc *RoleController) Store(c *gin.Context) {
 		return
 	}
 
+	// guard_name debe ser uno de los guards de autenticación (web, api)
+	if roleData.GuardName != "" {
+		if _, ok := auth.GetGuard(roleData.GuardName); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Unknown guard: " + roleData.GuardName,
+			})
+			return
+		}
+	}
+
 	role, err := models.CreateRole(roleData)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -174,7 +187,14 @@ func (rc *RoleController) AssignToUser(c *gin.Context) {
 		return
 	}
 
-	err := models.AssignRoleToUser(request.UserID, request.RoleID)
+	err := models.AssignRoleToUserWithWindow(request.UserID, request.RoleID, request.TeamID, request.GrantWindow)
+	if errors.Is(err, models.ErrInvalidGrantWindow) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Error assigning role to user: " + err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -201,7 +221,7 @@ func (rc *RoleController) RevokeFromUser(c *gin.Context) {
 		return
 	}
 
-	err := models.RevokeRoleFromUser(request.UserID, request.RoleID)
+	err := models.RevokeRoleFromUserInTeam(request.UserID, request.RoleID, request.TeamID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -216,6 +236,93 @@ func (rc *RoleController) RevokeFromUser(c *gin.Context) {
 	})
 }
 
+// AssignParent hace que un rol herede los permisos de otro
+func (rc *RoleController) AssignParent(c *gin.Context) {
+	var request structs.RoleParentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	err := models.AddRoleParent(request.RoleID, request.ParentRoleID)
+	if errors.Is(err, models.ErrRoleParentCycle) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Error assigning parent role: " + err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error assigning parent role: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Parent role assigned successfully",
+	})
+}
+
+// RevokeParent quita la herencia entre dos roles
+func (rc *RoleController) RevokeParent(c *gin.Context) {
+	var request structs.RoleParentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid input data",
+			"errors":  err.Error(),
+		})
+		return
+	}
+
+	err := models.RemoveRoleParent(request.RoleID, request.ParentRoleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error revoking parent role: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Parent role revoked successfully",
+	})
+}
+
+// GetParents obtiene los roles de los que hereda directamente un rol
+func (rc *RoleController) GetParents(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid role ID",
+		})
+		return
+	}
+
+	parents, err := models.GetRoleParents(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Error retrieving parent roles: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   parents,
+	})
+}
+
 // GetUserRoles obtiene todos los roles de un usuario
 func (rc *RoleController) GetUserRoles(c *gin.Context) {
 	userIDParam := c.Param("user_id")
@@ -2,9 +2,9 @@ package base
 
 import (
 	"net/http"
+	"semita/app/core/auth"
 	"semita/app/models"
 	"semita/app/structs"
-	"semita/app/utils"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -80,30 +80,28 @@ func (upc *UserPermissionController) CheckUserPermissions(c *gin.Context) {
 
 // CheckCurrentUserPermissions verifica los permisos del usuario actualmente logueado
 func (upc *UserPermissionController) CheckCurrentUserPermissions(c *gin.Context) {
-	// Obtener el usuario autenticado de la sesión
-	user, authenticated := utils.GetAuthenticatedUser(c.Request)
-	if !authenticated {
+	// La identidad del token o la sesión ya trae los roles y todos los permisos del usuario
+	identity, err := auth.Resolve(c)
+	if identity == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
 			"message": "User not authenticated",
 		})
 		return
 	}
-
-	userID := user.ID
-
-	// Obtener roles del usuario
-	roles, err := models.GetUserRoles(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
-			"message": "Error retrieving user roles: " + err.Error(),
+			"message": "Error retrieving user roles and permissions: " + err.Error(),
 		})
 		return
 	}
 
+	user := identity.User
+	user.Password = ""
+
 	// Obtener permisos directos del usuario
-	directPermissions, err := models.GetUserDirectPermissions(userID)
+	directPermissions, err := models.GetUserDirectPermissions(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -112,21 +110,11 @@ func (upc *UserPermissionController) CheckCurrentUserPermissions(c *gin.Context)
 		return
 	}
 
-	// Obtener todos los permisos del usuario (directos + heredados)
-	allPermissions, err := models.GetUserAllPermissions(userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Error retrieving user permissions: " + err.Error(),
-		})
-		return
-	}
-
 	userWithPerms := structs.UserWithRolesAndPermissions{
 		UserStruct:        user,
-		Roles:             roles,
+		Roles:             identity.Roles,
 		DirectPermissions: directPermissions,
-		AllPermissions:    allPermissions,
+		AllPermissions:    identity.Permissions,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -229,9 +217,9 @@ func (upc *UserPermissionController) CheckPermission(c *gin.Context) {
 
 // CheckCurrentUserRole verifica si el usuario logueado tiene un rol específico
 func (upc *UserPermissionController) CheckCurrentUserRole(c *gin.Context) {
-	// Obtener el usuario autenticado de la sesión
-	user, authenticated := utils.GetAuthenticatedUser(c.Request)
-	if !authenticated {
+	// Obtener el usuario autenticado por el token o la sesión, con sus roles y permisos ya cargados
+	identity, err := auth.Resolve(c)
+	if identity == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
 			"message": "User not authenticated",
@@ -253,7 +241,6 @@ func (upc *UserPermissionController) CheckCurrentUserRole(c *gin.Context) {
 		return
 	}
 
-	hasRole, err := models.UserHasRoleByName(user.ID, roleName, guardName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -262,10 +249,12 @@ func (upc *UserPermissionController) CheckCurrentUserRole(c *gin.Context) {
 		return
 	}
 
+	hasRole := identity.HasRole(roleName, guardName)
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data": gin.H{
-			"user_id":  user.ID,
+			"user_id":  identity.User.ID,
 			"role":     roleName,
 			"guard":    guardName,
 			"has_role": hasRole,
@@ -275,9 +264,9 @@ func (upc *UserPermissionController) CheckCurrentUserRole(c *gin.Context) {
 
 // CheckCurrentUserPermission verifica si el usuario logueado tiene un permiso específico
 func (upc *UserPermissionController) CheckCurrentUserPermission(c *gin.Context) {
-	// Obtener el usuario autenticado de la sesión
-	user, authenticated := utils.GetAuthenticatedUser(c.Request)
-	if !authenticated {
+	// Obtener el usuario autenticado por el token o la sesión, con sus roles y permisos ya cargados
+	identity, err := auth.Resolve(c)
+	if identity == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
 			"message": "User not authenticated",
@@ -299,7 +288,6 @@ func (upc *UserPermissionController) CheckCurrentUserPermission(c *gin.Context)
 		return
 	}
 
-	hasPermission, err := models.UserHasPermission(user.ID, permissionName, guardName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -308,10 +296,12 @@ func (upc *UserPermissionController) CheckCurrentUserPermission(c *gin.Context)
 		return
 	}
 
+	hasPermission := identity.HasPermission(permissionName, guardName)
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data": gin.H{
-			"user_id":        user.ID,
+			"user_id":        identity.User.ID,
 			"permission":     permissionName,
 			"guard":          guardName,
 			"has_permission": hasPermission,
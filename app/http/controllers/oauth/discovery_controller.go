@@ -0,0 +1,50 @@
+package oauth
+
+import (
+	"net/http"
+	"semita/app/models"
+	"semita/app/utils"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenIDConfiguration publica el documento de descubrimiento de OpenID Connect
+func OpenIDConfiguration(c *gin.Context) {
+	issuer := utils.AppURL()
+
+	scopes := []string{"openid", "profile", "email"}
+	if registered, err := models.GetAllScopes(); err == nil {
+		for _, scope := range registered {
+			if !slices.Contains(scopes, scope.Name) {
+				scopes = append(scopes, scope.Name)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                issuer,
+		"token_endpoint":                        issuer + "/oauth/token",
+		"userinfo_endpoint":                     issuer + "/oauth/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"grant_types_supported":                 []string{"password", "refresh_token", "mfa_otp"},
+		"response_types_supported":              []string{"token"},
+		"scopes_supported":                      scopes,
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "name", "updated_at", "email", "email_verified"},
+	})
+}
+
+// JWKS publica las llaves públicas con las que se verifican los id_token
+func JWKS(c *gin.Context) {
+	jwks, err := utils.JWKS()
+	if err != nil {
+		utils.Logs("ERROR", "Error loading OIDC signing key: "+err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, jwks)
+}
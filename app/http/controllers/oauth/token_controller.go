@@ -0,0 +1,248 @@
+package oauth
+
+import (
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/core/throttle"
+	"semita/app/helpers"
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenResponse es la respuesta del token endpoint según RFC 6749 y OpenID Connect
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// IssueToken es el token endpoint estándar (POST /oauth/token) para los grants password y refresh_token
+func IssueToken(c *gin.Context) {
+	// Las respuestas con credenciales no deben almacenarse en caché
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	client, ok := authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	grantType := c.PostForm("grant_type")
+	if grantType == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "The grant_type parameter is required")
+		return
+	}
+	// El desafío de 2FA continúa un password grant, por lo que lo pueden usar los mismos clientes
+	supported := client.SupportsGrantType(grantType) || (grantType == mfaOTPGrantType && client.SupportsGrantType("password"))
+	if !supported {
+		oauthError(c, http.StatusBadRequest, "unauthorized_client", "The client is not authorized to use this grant type")
+		return
+	}
+
+	switch grantType {
+	case "password":
+		issuePasswordGrant(c, client)
+	case "refresh_token":
+		issueRefreshTokenGrant(c, client)
+	case mfaOTPGrantType:
+		issueMFAOTPGrant(c, client)
+	default:
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "The grant type is not supported")
+	}
+}
+
+// issuePasswordGrant emite un token a partir de las credenciales del usuario
+func issuePasswordGrant(c *gin.Context, client *models.OAuthClient) {
+	username := c.PostForm("username")
+	password := c.PostForm("password")
+	if username == "" || password == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "The username and password parameters are required")
+		return
+	}
+
+	// El password grant comparte el bloqueo por intentos fallidos con los formularios de login
+	if locked, retryAfter := throttle.LoginLockedOut(username, c.ClientIP()); locked {
+		c.Header("Retry-After", strconv.Itoa(throttle.RetryAfterSeconds(retryAfter)))
+		oauthError(c, http.StatusTooManyRequests, "invalid_grant", throttle.TooManyAttemptsMessage(retryAfter))
+		return
+	}
+
+	user, err := auth.Attempt(c.Request.Context(), auth.Credentials{Login: username, Password: password})
+	if err != nil {
+		if locked, retryAfter := throttle.FailedLogin(username, c.ClientIP(), c.Request.UserAgent(), int64(user.ID)); locked {
+			c.Header("Retry-After", strconv.Itoa(throttle.RetryAfterSeconds(retryAfter)))
+			oauthError(c, http.StatusTooManyRequests, "invalid_grant", throttle.TooManyAttemptsMessage(retryAfter))
+			return
+		}
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "The user credentials are incorrect")
+		return
+	}
+	throttle.ClearLoginAttempts(username, c.ClientIP())
+
+	if helpers.MustEnrollTwoFactor(user.ID) {
+		oauthError(c, http.StatusForbidden, "mfa_enrollment_required", "The user must enable two-factor authentication before requesting tokens")
+		return
+	}
+
+	// Con 2FA activo se responde con un desafío; el cliente lo completa con el grant mfa_otp
+	if helpers.TwoFactorEnabled(user.ID) {
+		mfaToken, err := helpers.IssueMFAToken(user.ID, client.ClientID, c.PostForm("scope"), c.PostForm("nonce"))
+		if err != nil {
+			utils.Logs("ERROR", "Error issuing mfa_token: "+err.Error())
+			oauthError(c, http.StatusInternalServerError, "server_error", "The multi-factor challenge could not be issued")
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":             "mfa_required",
+			"error_description": "Multi-factor authentication required",
+			"mfa_token":         mfaToken,
+		})
+		return
+	}
+
+	issueUserToken(c, client, user, c.PostForm("scope"), c.PostForm("nonce"))
+}
+
+// mfaOTPGrantType completa un password grant que respondió mfa_required
+const mfaOTPGrantType = "mfa_otp"
+
+// issueMFAOTPGrant emite el token de un password grant tras validar el segundo factor.
+// Recibe el mfa_token del desafío y un código TOTP (otp) o de recuperación (recovery_code).
+func issueMFAOTPGrant(c *gin.Context, client *models.OAuthClient) {
+	mfaToken := c.PostForm("mfa_token")
+	otp := c.PostForm("otp")
+	recoveryCode := c.PostForm("recovery_code")
+	if mfaToken == "" || (otp == "" && recoveryCode == "") {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "The mfa_token and otp or recovery_code parameters are required")
+		return
+	}
+
+	userID, clientID, scope, nonce, err := helpers.ParseMFAToken(mfaToken)
+	if err != nil || clientID != client.ClientID {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "The mfa_token is invalid or expired")
+		return
+	}
+
+	if allowed, retryAfter := throttle.Attempt("two-factor:"+strconv.Itoa(userID), 5, time.Minute); !allowed {
+		c.Header("Retry-After", strconv.Itoa(throttle.RetryAfterSeconds(retryAfter)))
+		oauthError(c, http.StatusTooManyRequests, "invalid_grant", throttle.TooManyAttemptsMessage(retryAfter))
+		return
+	}
+
+	if err := helpers.VerifyTwoFactorChallenge(userID, otp, recoveryCode); err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "The authentication code is invalid")
+		return
+	}
+
+	user, err := models.GetUserByID(strconv.Itoa(userID))
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "The mfa_token is invalid or expired")
+		return
+	}
+
+	issueUserToken(c, client, user, scope, nonce)
+}
+
+// issueUserToken emite el access token, y el id_token si se pidió openid, para un usuario ya autenticado
+func issueUserToken(c *gin.Context, client *models.OAuthClient, user structs.UserStruct, scope string, nonce string) {
+	scopes, err := models.ResolveRequestedScopes(client, utils.ParseScopes(scope))
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_scope", err.Error())
+		return
+	}
+
+	token, err := models.CreateToken(int64(user.ID), client.ID, strings.Join(scopes, ","))
+	if err != nil {
+		utils.Logs("ERROR", "Error generating OAuth token: "+err.Error())
+		oauthError(c, http.StatusInternalServerError, "server_error", "Error generating OAuth token")
+		return
+	}
+
+	response := newTokenResponse(token)
+	if helpers.RequestsOpenID(scopes) {
+		idToken, err := helpers.IssueIDToken(user, client.ClientID, scopes, nonce, time.Now())
+		if err != nil {
+			utils.Logs("ERROR", "Error generating id_token: "+err.Error())
+			oauthError(c, http.StatusInternalServerError, "server_error", "Error generating id_token")
+			return
+		}
+		response.IDToken = idToken
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// issueRefreshTokenGrant renueva un token emitido previamente al mismo cliente
+func issueRefreshTokenGrant(c *gin.Context, client *models.OAuthClient) {
+	refreshToken := c.PostForm("refresh_token")
+	if refreshToken == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "The refresh_token parameter is required")
+		return
+	}
+
+	existing, err := models.GetTokenByRefreshToken(refreshToken)
+	if err != nil || existing.ClientID != client.ID {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "The refresh token is invalid")
+		return
+	}
+
+	token, err := models.RefreshToken(refreshToken)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "The refresh token is invalid")
+		return
+	}
+
+	c.JSON(http.StatusOK, newTokenResponse(token))
+}
+
+// authenticateClient valida las credenciales del cliente por HTTP Basic o en el cuerpo del formulario
+func authenticateClient(c *gin.Context) (*models.OAuthClient, bool) {
+	clientID, clientSecret, hasBasic := c.Request.BasicAuth()
+	if !hasBasic {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+
+	client, err := models.ValidateClientCredentials(clientID, clientSecret)
+	if err != nil {
+		if hasBasic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return nil, false
+	}
+
+	return client, true
+}
+
+func newTokenResponse(token *models.OAuthToken) TokenResponse {
+	expiresIn := 0
+	if expiresAt, err := time.ParseInLocation("2006-01-02 15:04:05", token.ExpiresAt, time.Local); err == nil {
+		expiresIn = int(time.Until(expiresAt).Seconds())
+	}
+
+	return TokenResponse{
+		AccessToken:  token.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    expiresIn,
+		RefreshToken: token.RefreshToken,
+		Scope:        strings.Join(token.GetScopesArray(), " "),
+	}
+}
+
+// oauthError responde con el formato de error de RFC 6749
+func oauthError(c *gin.Context, status int, errorCode string, description string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":             errorCode,
+		"error_description": description,
+	})
+}
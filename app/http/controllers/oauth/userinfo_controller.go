@@ -0,0 +1,24 @@
+package oauth
+
+import (
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserInfo devuelve los claims del usuario dueño del token según los scopes concedidos
+func UserInfo(c *gin.Context) {
+	user, authenticated := auth.User(c)
+	if !authenticated {
+		c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+		oauthError(c, http.StatusUnauthorized, "invalid_token", "The token owner no longer exists")
+		return
+	}
+
+	scopes, _ := c.Get("token_scopes")
+	tokenScopes, _ := scopes.([]string)
+
+	c.JSON(http.StatusOK, helpers.OIDCUserClaims(user, tokenScopes))
+}
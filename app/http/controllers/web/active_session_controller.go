@@ -0,0 +1,93 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/helpers"
+	"semita/app/models"
+	"semita/app/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActiveSessionIndex muestra las sesiones web y los tokens activos del usuario
+func ActiveSessionIndex(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	sessions, err := helpers.ActiveSessions(int64(user.ID), utils.CurrentSessionID(context.Request))
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving sessions: %v", err))
+		http.Error(context.Writer, "Error al obtener las sesiones", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := models.GetActiveUserTokens(int64(user.ID))
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving tokens: %v", err))
+		http.Error(context.Writer, "Error al obtener los tokens", http.StatusInternalServerError)
+		return
+	}
+
+	helpers.View(context, "profile/sessions.html", "Sessions", gin.H{
+		"sessions": sessions,
+		"tokens":   tokens,
+		"listable": helpers.SessionsListable(),
+	})
+}
+
+// ActiveSessionDelete cierra otra sesión web del usuario
+func ActiveSessionDelete(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	if err := helpers.TerminateSession(int64(user.ID), context.Param("id")); err != nil {
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error closing session: "+err.Error())
+	} else {
+		utils.CreateFlashNotification(context.Writer, context.Request, "success", "Session closed successfully")
+	}
+
+	context.Redirect(http.StatusSeeOther, "/profile/sessions")
+	context.Abort()
+}
+
+// ActiveTokenDelete revoca un token OAuth del usuario
+func ActiveTokenDelete(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	tokenID, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err == nil {
+		err = models.RevokeUserToken(int64(user.ID), tokenID)
+	}
+
+	if err != nil {
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error revoking token: "+err.Error())
+	} else {
+		utils.CreateFlashNotification(context.Writer, context.Request, "success", "Token revoked successfully")
+	}
+
+	context.Redirect(http.StatusSeeOther, "/profile/sessions")
+	context.Abort()
+}
+
+// ActiveSessionLogoutAll cierra todas las sesiones del usuario, incluida la actual, y revoca sus tokens
+func ActiveSessionLogoutAll(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	if err := helpers.LogoutEverywhere(int64(user.ID)); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error logging out everywhere: %v", err))
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error closing sessions")
+		context.Redirect(http.StatusSeeOther, "/profile/sessions")
+		context.Abort()
+		return
+	}
+
+	utils.ForgetRememberCookie(context.Writer)
+	if err := utils.LogoutUserSession(context.Writer, context.Request); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error logging out: %v", err))
+	}
+
+	utils.CreateFlashNotification(context.Writer, context.Request, "success", "You have been logged out everywhere")
+	context.Redirect(http.StatusSeeOther, "/auth/login")
+	context.Abort()
+}
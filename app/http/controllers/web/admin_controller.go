@@ -2,6 +2,7 @@ package web
 
 import (
 	"net/http"
+	"semita/app/core/auth"
 	"semita/app/helpers"
 	"semita/app/models"
 	"semita/app/structs"
@@ -17,28 +18,28 @@ type AdminController struct{}
 // Dashboard muestra el panel de administración
 func (ac *AdminController) Dashboard(c *gin.Context) {
 	// Verificar si el usuario puede acceder al dashboard
-	if !helpers.CanAccessDashboard(c.Request) {
+	if !helpers.CanAccessDashboard(c) {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "No tienes permisos para acceder al dashboard.")
 		c.Redirect(http.StatusSeeOther, "/")
 		return
 	}
 
 	// Obtener información del usuario autenticado
-	user, _ := utils.GetAuthenticatedUser(c.Request)
+	user, _ := auth.User(c)
 
 	// Preparar datos para la vista
 	data := gin.H{
 		"user":             user,
-		"can_manage_users": helpers.CanManageUsers(c.Request),
-		"can_manage_roles": helpers.CanManageRoles(c.Request),
-		"can_manage_perms": helpers.CanManagePermissions(c.Request),
-		"is_admin":         helpers.IsUserAdmin(c.Request),
-		"is_super_admin":   helpers.IsUserSuperAdmin(c.Request),
+		"can_manage_users": helpers.CanManageUsers(c),
+		"can_manage_roles": helpers.CanManageRoles(c),
+		"can_manage_perms": helpers.CanManagePermissions(c),
+		"is_admin":         helpers.IsUserAdmin(c),
+		"is_super_admin":   helpers.IsUserSuperAdmin(c),
 	}
 
 	// Obtener roles y permisos del usuario para mostrar en la vista
-	userRoles, _ := helpers.GetUserRoles(c.Request)
-	userPermissions, _ := helpers.GetUserPermissions(c.Request)
+	userRoles, _ := helpers.GetUserRoles(c)
+	userPermissions, _ := helpers.GetUserPermissions(c)
 	data["user_roles"] = userRoles
 	data["user_permissions"] = userPermissions
 
@@ -48,7 +49,7 @@ func (ac *AdminController) Dashboard(c *gin.Context) {
 // UsersIndex muestra la lista de usuarios (solo para quienes tengan permiso)
 func (ac *AdminController) UsersIndex(c *gin.Context) {
 	// Verificar permiso para ver usuarios
-	if !helpers.HasPermission(c.Request, "view-users") {
+	if !helpers.HasPermission(c, "view-users") {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "No tienes permisos para ver usuarios.")
 		c.Redirect(http.StatusSeeOther, "/admin")
 		return
@@ -63,10 +64,10 @@ func (ac *AdminController) UsersIndex(c *gin.Context) {
 
 	data := gin.H{
 		"users":            users,
-		"can_create_users": helpers.HasPermission(c.Request, "create-users"),
-		"can_edit_users":   helpers.HasPermission(c.Request, "edit-users"),
-		"can_delete_users": helpers.HasPermission(c.Request, "delete-users"),
-		"can_assign_roles": helpers.HasPermission(c.Request, "assign-roles"),
+		"can_create_users": helpers.HasPermission(c, "create-users"),
+		"can_edit_users":   helpers.HasPermission(c, "edit-users"),
+		"can_delete_users": helpers.HasPermission(c, "delete-users"),
+		"can_assign_roles": helpers.HasPermission(c, "assign-roles"),
 	}
 
 	c.HTML(http.StatusOK, "admin/users/index.html", data)
@@ -75,7 +76,7 @@ func (ac *AdminController) UsersIndex(c *gin.Context) {
 // UserShow muestra un usuario específico con sus roles y permisos
 func (ac *AdminController) UserShow(c *gin.Context) {
 	// Verificar permiso para ver usuarios
-	if !helpers.HasPermission(c.Request, "view-users") {
+	if !helpers.HasPermission(c, "view-users") {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "No tienes permisos para ver usuarios.")
 		c.Redirect(http.StatusSeeOther, "/admin")
 		return
@@ -132,9 +133,9 @@ func (ac *AdminController) UserShow(c *gin.Context) {
 		"user_all_permissions":    userAllPermissions,
 		"available_roles":         availableRoles,
 		"available_permissions":   availablePermissions,
-		"can_edit_users":          helpers.HasPermission(c.Request, "edit-users"),
-		"can_assign_roles":        helpers.HasPermission(c.Request, "assign-roles"),
-		"can_assign_permissions":  helpers.HasPermission(c.Request, "assign-permissions"),
+		"can_edit_users":          helpers.HasPermission(c, "edit-users"),
+		"can_assign_roles":        helpers.HasPermission(c, "assign-roles"),
+		"can_assign_permissions":  helpers.HasPermission(c, "assign-permissions"),
 	}
 
 	c.HTML(http.StatusOK, "admin/users/show.html", data)
@@ -143,7 +144,7 @@ func (ac *AdminController) UserShow(c *gin.Context) {
 // RolesIndex muestra la lista de roles
 func (ac *AdminController) RolesIndex(c *gin.Context) {
 	// Verificar permiso para ver roles
-	if !helpers.HasPermission(c.Request, "view-roles") {
+	if !helpers.HasPermission(c, "view-roles") {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "No tienes permisos para ver roles.")
 		c.Redirect(http.StatusSeeOther, "/admin")
 		return
@@ -158,10 +159,10 @@ func (ac *AdminController) RolesIndex(c *gin.Context) {
 
 	data := gin.H{
 		"roles":            roles,
-		"can_create_roles": helpers.HasPermission(c.Request, "create-roles"),
-		"can_edit_roles":   helpers.HasPermission(c.Request, "edit-roles"),
-		"can_delete_roles": helpers.HasPermission(c.Request, "delete-roles"),
-		"can_assign_roles": helpers.HasPermission(c.Request, "assign-roles"),
+		"can_create_roles": helpers.HasPermission(c, "create-roles"),
+		"can_edit_roles":   helpers.HasPermission(c, "edit-roles"),
+		"can_delete_roles": helpers.HasPermission(c, "delete-roles"),
+		"can_assign_roles": helpers.HasPermission(c, "assign-roles"),
 	}
 
 	c.HTML(http.StatusOK, "admin/roles/index.html", data)
@@ -170,7 +171,7 @@ func (ac *AdminController) RolesIndex(c *gin.Context) {
 // PermissionsIndex muestra la lista de permisos
 func (ac *AdminController) PermissionsIndex(c *gin.Context) {
 	// Verificar permiso para ver permisos
-	if !helpers.HasPermission(c.Request, "view-permissions") {
+	if !helpers.HasPermission(c, "view-permissions") {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "No tienes permisos para ver permisos.")
 		c.Redirect(http.StatusSeeOther, "/admin")
 		return
@@ -185,10 +186,10 @@ func (ac *AdminController) PermissionsIndex(c *gin.Context) {
 
 	data := gin.H{
 		"permissions":            permissions,
-		"can_create_permissions": helpers.HasPermission(c.Request, "create-permissions"),
-		"can_edit_permissions":   helpers.HasPermission(c.Request, "edit-permissions"),
-		"can_delete_permissions": helpers.HasPermission(c.Request, "delete-permissions"),
-		"can_assign_permissions": helpers.HasPermission(c.Request, "assign-permissions"),
+		"can_create_permissions": helpers.HasPermission(c, "create-permissions"),
+		"can_edit_permissions":   helpers.HasPermission(c, "edit-permissions"),
+		"can_delete_permissions": helpers.HasPermission(c, "delete-permissions"),
+		"can_assign_permissions": helpers.HasPermission(c, "assign-permissions"),
 	}
 
 	c.HTML(http.StatusOK, "admin/permissions/index.html", data)
@@ -197,14 +198,14 @@ func (ac *AdminController) PermissionsIndex(c *gin.Context) {
 // Ejemplo de uso con múltiples verificaciones
 func (ac *AdminController) AdvancedPermissionExample(c *gin.Context) {
 	// Verificar múltiples condiciones
-	if !helpers.IsUserAdmin(c.Request) {
+	if !helpers.IsUserAdmin(c) {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "Solo administradores pueden acceder.")
 		c.Redirect(http.StatusSeeOther, "/")
 		return
 	}
 
 	// Verificar rol específico O permiso específico
-	if !helpers.HasRole(c.Request, "super-admin") && !helpers.HasPermission(c.Request, "manage-settings") {
+	if !helpers.HasRole(c, "super-admin") && !helpers.HasPermission(c, "manage-settings") {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "Necesitas ser super-admin o tener el permiso 'manage-settings'.")
 		c.Redirect(http.StatusSeeOther, "/admin")
 		return
@@ -212,7 +213,7 @@ func (ac *AdminController) AdvancedPermissionExample(c *gin.Context) {
 
 	// Verificar cualquiera de varios roles
 	requiredRoles := []string{"admin", "super-admin", "editor"}
-	if !helpers.HasAnyRole(c.Request, requiredRoles) {
+	if !helpers.HasAnyRole(c, requiredRoles) {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "Necesitas al menos uno de estos roles: admin, super-admin, editor.")
 		c.Redirect(http.StatusSeeOther, "/admin")
 		return
@@ -220,7 +221,7 @@ func (ac *AdminController) AdvancedPermissionExample(c *gin.Context) {
 
 	// Verificar todos los permisos requeridos
 	requiredPermissions := []string{"view-dashboard", "manage-settings"}
-	if !helpers.HasAllPermissions(c.Request, requiredPermissions) {
+	if !helpers.HasAllPermissions(c, requiredPermissions) {
 		utils.CreateFlashNotification(c.Writer, c.Request, "error", "Necesitas todos estos permisos: view-dashboard, manage-settings.")
 		c.Redirect(http.StatusSeeOther, "/admin")
 		return
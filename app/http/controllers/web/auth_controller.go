@@ -1,13 +1,17 @@
 package web
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"semita/app/core/auth"
+	"semita/app/core/throttle"
 	"semita/app/helpers"
 	"semita/app/models"
 	"semita/app/notifications"
 	"semita/app/structs"
 	"semita/app/utils"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +19,14 @@ import (
 )
 
 func AuthLogin(context *gin.Context) {
-	helpers.View(context, "auth/login.html", "Login", nil)
+	var providers []gin.H
+	for _, provider := range helpers.SocialProviders() {
+		providers = append(providers, gin.H{"Name": provider.Name(), "Label": provider.Label()})
+	}
+
+	helpers.View(context, "auth/login.html", "Login", gin.H{
+		"social_providers": providers,
+	})
 }
 
 func AuthLoginPost(context *gin.Context) {
@@ -35,20 +46,33 @@ func AuthLoginPost(context *gin.Context) {
 		Password: password,
 	}
 
-	storedUser, err := models.GetUserByEmail(user.Email)
-	if err != nil {
-		utils.Logs("ERROR", fmt.Sprintf("Error retrieving user: %v", err))
-		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid email or password")
+	// Bloqueo por intentos fallidos para este email desde esta IP
+	if locked, retryAfter := throttle.LoginLockedOut(user.Email, context.ClientIP()); locked {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", throttle.TooManyAttemptsMessage(retryAfter))
 		context.Redirect(http.StatusSeeOther, "/auth/login")
 		context.Abort()
 		return
 	}
 
-	errPassword := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(user.Password))
-	if errPassword != nil {
-		utils.Logs("ERROR", "Invalid password")
-		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid email or password")
-		context.Redirect(http.StatusSeeOther, "/auth/login")
+	storedUser, err := auth.Attempt(context.Request.Context(), auth.Credentials{Login: user.Email, Password: user.Password})
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Login failed for %s: %v", user.Email, err))
+		authLoginFailed(context, user.Email, int64(storedUser.ID))
+		return
+	}
+
+	throttle.ClearLoginAttempts(user.Email, context.ClientIP())
+
+	// Con 2FA activo la sesión se autentica recién después del segundo factor
+	if helpers.TwoFactorEnabled(storedUser.ID) {
+		if err := utils.StartTwoFactorChallenge(context.Writer, context.Request, storedUser.ID, context.PostForm("remember") != ""); err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error starting two-factor challenge: %v", err))
+			utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error creating user session")
+			context.Redirect(http.StatusSeeOther, "/auth/login")
+			context.Abort()
+			return
+		}
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor-challenge")
 		context.Abort()
 		return
 	}
@@ -62,12 +86,43 @@ func AuthLoginPost(context *gin.Context) {
 		return
 	}
 
+	// "Recordarme": cookie persistente con un token rotativo cuyo hash se guarda en users.remember_token
+	if context.PostForm("remember") != "" {
+		tokenHash, err := utils.QueueRememberCookie(context.Writer, storedUser.ID)
+		if err == nil {
+			err = models.UpdateRememberToken(storedUser.ID, tokenHash)
+		}
+		if err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error creating remember token: %v", err))
+		}
+	}
+
 	utils.CreateFlashNotification(context.Writer, context.Request, "success", "Login successful!")
 	context.Redirect(http.StatusSeeOther, "/")
 	context.Abort()
 }
 
+// authLoginFailed registra el intento fallido y avisa si con él se bloqueó el acceso
+func authLoginFailed(context *gin.Context, email string, userID int64) {
+	message := "Invalid email or password"
+	if locked, retryAfter := throttle.FailedLogin(email, context.ClientIP(), context.Request.UserAgent(), userID); locked {
+		message = throttle.TooManyAttemptsMessage(retryAfter)
+	}
+
+	utils.CreateFlashNotification(context.Writer, context.Request, "warning", message)
+	context.Redirect(http.StatusSeeOther, "/auth/login")
+	context.Abort()
+}
+
 func AuthLogout(c *gin.Context) {
+	// Invalidar el token de "recordarme" para que la cookie no restaure la sesión
+	if user, ok := auth.User(c); ok {
+		if err := models.UpdateRememberToken(user.ID, ""); err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error clearing remember token: %v", err))
+		}
+	}
+	utils.ForgetRememberCookie(c.Writer)
+
 	sessionLogoutError := utils.LogoutUserSession(c.Writer, c.Request)
 	if sessionLogoutError != nil {
 		c.String(http.StatusInternalServerError, "Error logging out")
@@ -132,17 +187,23 @@ func AuthForgotPasswordPost(context *gin.Context) {
 		return
 	}
 
-	token := utils.GenerateResetToken(email)
-	resetURL := "http://" + utils.GetEnv("APP_URL") + "/auth/reset-password?token=" + token
-	_ = models.CreatePasswordReset(email, token) // Guardar token en BD
-	errorSendEmail := notifications.SendPasswordReset(email, resetURL)
-
-	if errorSendEmail != nil {
-		utils.Logs("ERROR", errorSendEmail.Error())
-		fmt.Println("Error sending password reset email:", errorSendEmail)
-		return
+	// Solo se genera el enlace si el usuario existe, sin revelarlo en la respuesta: una solicitud
+	// limitada por el throttle por email responde igual que cualquier otra
+	if user, err := models.GetUserByEmail(email); err == nil {
+		token, err := models.CreatePasswordReset(user.Email)
+		if errors.Is(err, models.ErrPasswordResetThrottled) {
+			utils.Logs("INFO", fmt.Sprintf("Solicitud de recuperación limitada para el usuario %d", user.ID))
+		} else if err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error al generar token de recuperación: %v", err))
+		} else {
+			resetURL := utils.AppURL() + "/auth/reset-password?token=" + token
+			if errorSendEmail := notifications.SendPasswordReset(user.Email, resetURL); errorSendEmail != nil {
+				utils.Logs("ERROR", errorSendEmail.Error())
+			}
+		}
 	}
 
+	utils.CreateFlashNotification(context.Writer, context.Request, "success", "Si el email existe, se enviará un enlace de recuperación")
 	context.Redirect(http.StatusSeeOther, "/auth/login")
 	context.Abort()
 }
@@ -179,15 +240,9 @@ func AuthResetPasswordPost(context *gin.Context) {
 		return
 	}
 
-	// Usar hora local para ambos tiempos
-	now := time.Now()
-	tokenCreatedAt := passwordResetByToken.CreatedAt
-	timeSince := now.Sub(tokenCreatedAt)
-
-	// Verificar expiración de 2 horas
-	if timeSince > 2*time.Hour {
-		_ = models.DeletePasswordReset(token)
-		utils.Logs("INFO", fmt.Sprintf("Token expirado. Creado hace: %v", timeSince))
+	if passwordResetByToken.Expired() {
+		_ = models.DeletePasswordResets(passwordResetByToken.Email)
+		utils.Logs("INFO", fmt.Sprintf("Token expirado. Creado: %v", passwordResetByToken.CreatedAt))
 		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Token expirado. Por favor, solicita un nuevo enlace de restablecimiento.")
 		context.Redirect(http.StatusSeeOther, "/auth/forgot-password")
 		context.Abort()
@@ -212,8 +267,8 @@ func AuthResetPasswordPost(context *gin.Context) {
 		return
 	}
 
-	update := structs.UpdateUserStruct{ID: user.ID, Name: user.Name, Email: user.Email, Password: string(hashedPassword)}
-	err = models.UpdateUser(update)
+	// Guarda la contraseña, consume el token y revoca los tokens OAuth del usuario
+	err = models.ResetUserPassword(user.ID, user.Email, string(hashedPassword))
 
 	if err != nil {
 		utils.Logs("ERROR", fmt.Sprintf("No se pudo actualizar la contraseña: %v", err))
@@ -223,11 +278,51 @@ func AuthResetPasswordPost(context *gin.Context) {
 		return
 	}
 
-	// Eliminar el token después de usarlo exitosamente
-	_ = models.DeletePasswordReset(token)
 	utils.Logs("INFO", "Contraseña restablecida exitosamente")
 
 	utils.CreateFlashNotification(context.Writer, context.Request, "success", "Contraseña actualizada exitosamente!")
 	context.Redirect(http.StatusSeeOther, "/auth/login")
 	context.Abort()
 }
+
+// AuthVerifyEmailNotice muestra el aviso para verificar el email de la cuenta
+func AuthVerifyEmailNotice(context *gin.Context) {
+	helpers.View(context, "auth/verify_email.html", "Verificar Email", nil)
+}
+
+// AuthVerifyEmailResend envía un nuevo enlace de verificación al usuario de la sesión
+func AuthVerifyEmailResend(context *gin.Context) {
+	user, authenticated := auth.User(context)
+	if !authenticated {
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Usuario no encontrado")
+		context.Redirect(http.StatusSeeOther, "/auth/email/verify")
+		context.Abort()
+		return
+	}
+
+	if user.HasVerifiedEmail() {
+		utils.CreateFlashNotification(context.Writer, context.Request, "success", "Tu email ya está verificado")
+		context.Redirect(http.StatusSeeOther, "/")
+		context.Abort()
+		return
+	}
+
+	if allowed, _ := throttle.Attempt("email-verification:resend:"+strconv.Itoa(user.ID), 6, time.Minute); !allowed {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Demasiados intentos, intenta de nuevo más tarde")
+		context.Redirect(http.StatusSeeOther, "/auth/email/verify")
+		context.Abort()
+		return
+	}
+
+	if err := helpers.SendEmailVerification(user); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error al enviar verificación: %v", err))
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "No se pudo enviar el correo de verificación")
+		context.Redirect(http.StatusSeeOther, "/auth/email/verify")
+		context.Abort()
+		return
+	}
+
+	utils.CreateFlashNotification(context.Writer, context.Request, "success", "Correo de verificación enviado")
+	context.Redirect(http.StatusSeeOther, "/auth/email/verify")
+	context.Abort()
+}
@@ -28,7 +28,7 @@ func DummyApiIndex(context *gin.Context) {
 }
 
 func DummyApiCreate(c *gin.Context) {
-	var viewData = helpers.AuthSessionService(c.Writer, c.Request, "API create", nil)
+	var viewData = helpers.AuthSessionService(c, "API create", nil)
 	var templateCreate = template.Must(template.ParseFiles("resources/dummyjson/create.html", config.MainLayoutFilePath))
 	var errorExecuteTemplate = templateCreate.Execute(c.Writer, viewData)
 	if errorExecuteTemplate != nil {
@@ -109,7 +109,7 @@ func DummyApiShow(context *gin.Context) {
 		return
 	}
 
-	var viewData = helpers.AuthSessionService(context.Writer, context.Request, "API show", user)
+	var viewData = helpers.AuthSessionService(context, "API show", user)
 
 	var templateShow = template.Must(template.ParseFiles("resources/dummyjson/show.html", config.MainLayoutFilePath))
 	var errorExecuteTemplate = templateShow.Execute(context.Writer, viewData)
@@ -147,7 +147,7 @@ func DummyApiEdit(context *gin.Context) {
 		return
 	}
 
-	var viewData = helpers.AuthSessionService(context.Writer, context.Request, "API Edit", user)
+	var viewData = helpers.AuthSessionService(context, "API Edit", user)
 
 	var templateEdit = template.Must(template.ParseFiles("resources/dummyjson/edit.html", config.MainLayoutFilePath))
 	var errorExecuteTemplate = templateEdit.Execute(context.Writer, viewData)
@@ -0,0 +1,25 @@
+package web
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"semita/app/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileDownload entrega un archivo de storage/files. La ruta debe protegerse con una firma.
+func FileDownload(c *gin.Context) {
+	// filepath.Base evita salir del directorio con rutas como ../
+	name := filepath.Base(c.Param("name"))
+	path := filepath.Join(helpers.FilesPath, name)
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		c.String(http.StatusNotFound, "File not found")
+		return
+	}
+
+	c.FileAttachment(path, name)
+}
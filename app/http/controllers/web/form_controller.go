@@ -17,7 +17,7 @@ import (
 )
 
 func FormulariosGet(c *gin.Context) {
-	authData := helpers.AuthSessionService(c.Writer, c.Request, "Formulario", nil)
+	authData := helpers.AuthSessionService(c, "Formulario", nil)
 	tmpl := template.Must(template.ParseFiles("resources/formulario/form.html", config.MainLayoutFilePath))
 	err := tmpl.Execute(c.Writer, authData)
 	if err != nil {
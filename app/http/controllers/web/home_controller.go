@@ -21,7 +21,7 @@ type Data struct {
 }
 
 func HomeIndex(c *gin.Context) {
-	authHomeData := helpers.AuthSessionService(c.Writer, c.Request, "Inicio", nil)
+	authHomeData := helpers.AuthSessionService(c, "Inicio", nil)
 	templateHome := template.Must(template.ParseFiles("resources/home/home.html", config.MainLayoutFilePath))
 	if err := templateHome.Execute(c.Writer, authHomeData); err != nil {
 		fmt.Println("Error al ejecutar la plantilla:", err)
@@ -30,7 +30,7 @@ func HomeIndex(c *gin.Context) {
 }
 
 func Nosotros(c *gin.Context) {
-	authData := helpers.AuthSessionService(c.Writer, c.Request, "Nosotros", nil)
+	authData := helpers.AuthSessionService(c, "Nosotros", nil)
 	templateWe := template.Must(template.ParseFiles("resources/home/nosotros.html", config.MainLayoutFilePath))
 
 	err := templateWe.Execute(c.Writer, authData)
@@ -46,7 +46,7 @@ func Parametros(context *gin.Context) {
 		"slug": context.Param("slug"),
 	}
 	data["text"] = "Hello World"
-	authData := helpers.AuthSessionService(context.Writer, context.Request, "Parametros", data)
+	authData := helpers.AuthSessionService(context, "Parametros", data)
 	tmpl, err := template.ParseFiles("resources/home/parametros.html", config.MainLayoutFilePath)
 	if err != nil {
 		fmt.Println("Error al cargar la plantilla")
@@ -61,7 +61,7 @@ func QueryString(context *gin.Context) {
 		"id":   context.Query("id"),
 		"slug": context.Query("slug"),
 	}
-	authData := helpers.AuthSessionService(context.Writer, context.Request, "Query String", data)
+	authData := helpers.AuthSessionService(context, "Query String", data)
 	tmpl, err := template.ParseFiles("resources/home/querystring.html", config.MainLayoutFilePath)
 	if err != nil {
 		fmt.Println("Error al cargar la plantilla")
@@ -82,7 +82,7 @@ func Estructuras(c *gin.Context) {
 		Perfil:      "Administrador de Sistemas",
 		Habilidades: []Habilidades{habilidad01, habilidad02, habilidad03},
 	}
-	authData := helpers.AuthSessionService(c.Writer, c.Request, "Estructuras", data)
+	authData := helpers.AuthSessionService(c, "Estructuras", data)
 	templateFile, _ := template.ParseFiles("resources/home/estructuras.html", config.MainLayoutFilePath)
 	err := templateFile.Execute(c.Writer, authData)
 	if err != nil {
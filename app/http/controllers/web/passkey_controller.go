@@ -0,0 +1,137 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/core/throttle"
+	"semita/app/core/webauthn"
+	"semita/app/helpers"
+	"semita/app/models"
+	"semita/app/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// passkeyStoreRequest es el cuerpo JSON que envía el navegador al terminar el registro
+type passkeyStoreRequest struct {
+	Name       string                       `json:"name"`
+	Credential webauthn.AttestationResponse `json:"credential"`
+}
+
+// PasskeyIndex muestra las passkeys del usuario
+func PasskeyIndex(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	passkeys, err := models.GetUserWebAuthnCredentials(user.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving passkeys: %v", err))
+		http.Error(context.Writer, "Error al obtener las passkeys", http.StatusInternalServerError)
+		return
+	}
+
+	helpers.View(context, "profile/passkeys.html", "Passkeys", gin.H{
+		"passkeys": passkeys,
+	})
+}
+
+// PasskeyCreateOptions devuelve las opciones de navigator.credentials.create()
+func PasskeyCreateOptions(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	options, err := helpers.PasskeyCreationOptions(context, user)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error creating passkey options: %v", err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating passkey options"})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+// PasskeyStore valida el registro y guarda la passkey
+func PasskeyStore(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	var request passkeyStoreRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey data"})
+		return
+	}
+
+	if err := helpers.RegisterPasskey(context, user, request.Name, request.Credential); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error registering passkey: %v", err))
+		context.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The passkey could not be registered"})
+		return
+	}
+
+	utils.CreateFlashNotification(context.Writer, context.Request, "success", "Passkey added successfully")
+	context.JSON(http.StatusCreated, gin.H{"redirect": "/profile/passkeys"})
+}
+
+// PasskeyDelete elimina una passkey del usuario
+func PasskeyDelete(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err == nil {
+		err = helpers.DeletePasskey(context, user.ID, id)
+	}
+
+	if err != nil {
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error deleting passkey")
+	} else {
+		utils.CreateFlashNotification(context.Writer, context.Request, "success", "Passkey deleted successfully")
+	}
+
+	context.Redirect(http.StatusSeeOther, "/profile/passkeys")
+	context.Abort()
+}
+
+// PasskeyLoginOptions devuelve las opciones de navigator.credentials.get() para iniciar sesión
+func PasskeyLoginOptions(context *gin.Context) {
+	options, err := helpers.PasskeyRequestOptions(context)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error creating passkey options: %v", err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating passkey options"})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+// PasskeyLogin valida la aserción e inicia la sesión del dueño de la passkey
+func PasskeyLogin(context *gin.Context) {
+	if allowed, retryAfter := throttle.Attempt("passkey-login:"+context.ClientIP(), 10, time.Minute); !allowed {
+		context.Header("Retry-After", strconv.Itoa(throttle.RetryAfterSeconds(retryAfter)))
+		context.JSON(http.StatusTooManyRequests, gin.H{"error": throttle.TooManyAttemptsMessage(retryAfter)})
+		return
+	}
+
+	var assertion webauthn.AssertionResponse
+	if err := context.ShouldBindJSON(&assertion); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey data"})
+		return
+	}
+
+	user, err := helpers.AuthenticatePasskey(context, assertion)
+	if err != nil {
+		if !errors.Is(err, helpers.ErrPasskeyNotFound) {
+			utils.Logs("ERROR", fmt.Sprintf("Passkey login failed: %v", err))
+		}
+		context.JSON(http.StatusUnauthorized, gin.H{"error": "The passkey could not be verified"})
+		return
+	}
+
+	if err := utils.LoginUserSession(context.Writer, context.Request, user); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error creating user session: %v", err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user session"})
+		return
+	}
+
+	utils.CreateFlashNotification(context.Writer, context.Request, "success", "Login successful!")
+	context.JSON(http.StatusOK, gin.H{"redirect": "/"})
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/helpers"
+	"semita/app/http/requests"
+	"semita/app/models"
+	"semita/app/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalAccessTokenIndex muestra los tokens de acceso personal del usuario
+func PersonalAccessTokenIndex(context *gin.Context) {
+	renderPersonalAccessTokens(context, "")
+}
+
+// PersonalAccessTokenStore crea un token y lo muestra una única vez en la misma respuesta
+func PersonalAccessTokenStore(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	var req requests.PersonalAccessTokenRequest
+	if err := req.Validate(context); err != nil {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid token data: "+err.Error())
+		context.Redirect(http.StatusSeeOther, "/profile/tokens")
+		context.Abort()
+		return
+	}
+
+	expiresAt, _ := req.Expiration()
+	token, err := models.CreatePersonalAccessToken(int64(user.ID), req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error creating personal access token: %v", err))
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error creating token: "+err.Error())
+		context.Redirect(http.StatusSeeOther, "/profile/tokens")
+		context.Abort()
+		return
+	}
+
+	// No se redirige: el valor del token no se guarda en ningún sitio del que pueda volver a leerse
+	renderPersonalAccessTokens(context, token.AccessToken)
+}
+
+// PersonalAccessTokenDelete revoca un token de acceso personal del usuario
+func PersonalAccessTokenDelete(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	tokenID, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err == nil {
+		err = models.RevokePersonalAccessToken(int64(user.ID), tokenID)
+	}
+
+	if err != nil {
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error revoking token: "+err.Error())
+	} else {
+		utils.CreateFlashNotification(context.Writer, context.Request, "success", "Token revoked successfully")
+	}
+
+	context.Redirect(http.StatusSeeOther, "/profile/tokens")
+	context.Abort()
+}
+
+func renderPersonalAccessTokens(context *gin.Context, plainToken string) {
+	user, _ := auth.User(context)
+
+	tokens, err := models.GetPersonalAccessTokens(int64(user.ID))
+	clientMissing := errors.Is(err, models.ErrNoPersonalAccessClient)
+	if err != nil && !clientMissing {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving personal access tokens: %v", err))
+		http.Error(context.Writer, "Error al obtener los tokens de acceso personal", http.StatusInternalServerError)
+		return
+	}
+
+	scopes, err := models.GetAllScopes()
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving scopes: %v", err))
+	}
+
+	helpers.View(context, "profile/tokens.html", "Personal Access Tokens", gin.H{
+		"tokens":      tokens,
+		"scopes":      scopes,
+		"plain_token": plainToken,
+		"no_client":   clientMissing,
+	})
+}
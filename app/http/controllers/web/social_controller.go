@@ -0,0 +1,169 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/core/social"
+	"semita/app/helpers"
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SocialRedirect redirige al proveedor externo para iniciar sesión o, con sesión iniciada, para vincular la cuenta
+func SocialRedirect(context *gin.Context) {
+	provider, ok := helpers.SocialProvider(context.Param("provider"))
+	if !ok {
+		context.String(http.StatusNotFound, "Unknown provider")
+		return
+	}
+
+	redirectURL, err := helpers.StartSocialLogin(context, provider)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error starting %s login: %v", provider.Name(), err))
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "The provider is not available right now")
+		context.Redirect(http.StatusSeeOther, socialReturnPath(context))
+		context.Abort()
+		return
+	}
+
+	context.Redirect(http.StatusFound, redirectURL)
+	context.Abort()
+}
+
+// SocialCallback recibe la respuesta del proveedor, vincula la identidad e inicia la sesión
+func SocialCallback(context *gin.Context) {
+	provider, ok := helpers.SocialProvider(context.Param("provider"))
+	if !ok {
+		context.String(http.StatusNotFound, "Unknown provider")
+		return
+	}
+
+	var current *structs.UserStruct
+	if user, authenticated := auth.User(context); authenticated {
+		current = &user
+	}
+
+	external, token, err := helpers.FinishSocialLogin(context, provider)
+	if err != nil {
+		if !errors.Is(err, social.ErrProviderDenied) {
+			utils.Logs("ERROR", fmt.Sprintf("%s login failed: %v", provider.Name(), err))
+		}
+		socialFailed(context, "Could not sign in with "+provider.Label())
+		return
+	}
+
+	user, err := helpers.ResolveSocialUser(context, provider.Name(), external, token, current)
+	if err != nil {
+		switch {
+		case errors.Is(err, helpers.ErrSocialEmailTaken):
+			socialFailed(context, "An account with this email already exists. Sign in and link "+provider.Label()+" from your profile.")
+		case errors.Is(err, helpers.ErrSocialEmailMissing):
+			socialFailed(context, provider.Label()+" did not share your email address")
+		case errors.Is(err, helpers.ErrSocialIdentityTaken):
+			socialFailed(context, "This "+provider.Label()+" account is already linked to another user")
+		case errors.Is(err, helpers.ErrSocialProviderLinked):
+			socialFailed(context, "Another "+provider.Label()+" account is already linked")
+		default:
+			utils.Logs("ERROR", fmt.Sprintf("Error linking %s identity: %v", provider.Name(), err))
+			socialFailed(context, "Could not sign in with "+provider.Label())
+		}
+		return
+	}
+
+	if current != nil {
+		utils.CreateFlashNotification(context.Writer, context.Request, "success", provider.Label()+" account linked successfully")
+		context.Redirect(http.StatusSeeOther, "/profile/identities")
+		context.Abort()
+		return
+	}
+
+	// Con 2FA activo la sesión se autentica recién después del segundo factor
+	if helpers.TwoFactorEnabled(user.ID) {
+		if err := utils.StartTwoFactorChallenge(context.Writer, context.Request, user.ID, false); err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error starting two-factor challenge: %v", err))
+			socialFailed(context, "Error creating user session")
+			return
+		}
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor-challenge")
+		context.Abort()
+		return
+	}
+
+	if err := utils.LoginUserSession(context.Writer, context.Request, user); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error creating user session: %v", err))
+		socialFailed(context, "Error creating user session")
+		return
+	}
+
+	utils.CreateFlashNotification(context.Writer, context.Request, "success", "Login successful!")
+	context.Redirect(http.StatusSeeOther, "/")
+	context.Abort()
+}
+
+// SocialIdentities muestra las cuentas externas vinculadas y los proveedores disponibles
+func SocialIdentities(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	identities, err := models.GetUserIdentities(user.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving identities: %v", err))
+		http.Error(context.Writer, "Error al obtener las cuentas vinculadas", http.StatusInternalServerError)
+		return
+	}
+
+	linked := map[string]bool{}
+	for _, identity := range identities {
+		linked[identity.Provider] = true
+	}
+
+	var available []gin.H
+	for _, provider := range helpers.SocialProviders() {
+		if !linked[provider.Name()] {
+			available = append(available, gin.H{"Name": provider.Name(), "Label": provider.Label()})
+		}
+	}
+
+	helpers.View(context, "profile/identities.html", "Linked accounts", gin.H{
+		"identities": identities,
+		"providers":  available,
+	})
+}
+
+// SocialIdentityDelete desvincula una cuenta externa del usuario
+func SocialIdentityDelete(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err == nil {
+		err = helpers.DeleteSocialIdentity(context, user.ID, id)
+	}
+
+	if err != nil {
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error unlinking account")
+	} else {
+		utils.CreateFlashNotification(context.Writer, context.Request, "success", "Account unlinked successfully")
+	}
+
+	context.Redirect(http.StatusSeeOther, "/profile/identities")
+	context.Abort()
+}
+
+// socialFailed avisa del error y vuelve al login o, si se estaba vinculando, al perfil
+func socialFailed(context *gin.Context, message string) {
+	utils.CreateFlashNotification(context.Writer, context.Request, "warning", message)
+	context.Redirect(http.StatusSeeOther, socialReturnPath(context))
+	context.Abort()
+}
+
+func socialReturnPath(context *gin.Context) string {
+	if _, authenticated := auth.User(context); authenticated {
+		return "/profile/identities"
+	}
+	return "/auth/login"
+}
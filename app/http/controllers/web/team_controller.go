@@ -0,0 +1,268 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"semita/app/core/auth"
+	"semita/app/helpers"
+	"semita/app/models"
+	"semita/app/notifications"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TeamIndex muestra los equipos del usuario y permite cambiar el equipo actual o crear uno
+func TeamIndex(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	teams, err := models.GetUserTeams(user.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving teams: %v", err))
+		http.Error(context.Writer, "Error al obtener los equipos", http.StatusInternalServerError)
+		return
+	}
+
+	helpers.View(context, "teams/index.html", "Teams", gin.H{
+		"teams": teams,
+	})
+}
+
+// TeamStore crea un equipo con el usuario como propietario y lo deja como equipo actual
+func TeamStore(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	name := strings.TrimSpace(context.PostForm("name"))
+	if name == "" || len(name) > 255 {
+		teamRedirect(context, "/teams", "error", "The team name is required and must be at most 255 characters.")
+		return
+	}
+
+	team, err := models.CreateTeam(name, user.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error creating team: %v", err))
+		teamRedirect(context, "/teams", "error", "Error creating the team.")
+		return
+	}
+
+	if err := utils.SetCurrentTeam(context.Writer, context.Request, team.ID); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error switching team: %v", err))
+	}
+	teamRedirect(context, "/teams/show/"+strconv.Itoa(team.ID), "success", "Team created successfully.")
+}
+
+// TeamSwitch cambia el equipo actual de la sesión; con el ID 0 se vuelve al contexto sin equipo
+func TeamSwitch(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	teamID, err := strconv.Atoi(context.Param("id"))
+	if err != nil || teamID < 0 {
+		teamRedirect(context, "/teams", "error", "The requested team was not found.")
+		return
+	}
+	if teamID != 0 {
+		if _, err := models.GetUserTeam(teamID, user.ID); err != nil {
+			teamRedirect(context, "/teams", "error", "You are not a member of this team.")
+			return
+		}
+	}
+
+	if err := utils.SetCurrentTeam(context.Writer, context.Request, teamID); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error switching team: %v", err))
+		teamRedirect(context, "/teams", "error", "Error switching team.")
+		return
+	}
+	auth.Forget(context)
+
+	teamRedirect(context, "/teams", "success", "Current team updated.")
+}
+
+// TeamShow muestra los miembros y las invitaciones pendientes del equipo de la ruta
+func TeamShow(context *gin.Context) {
+	team, _ := auth.Team(context)
+
+	members, err := models.GetTeamMembers(team.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving team members: %v", err))
+		http.Error(context.Writer, "Error al obtener los miembros del equipo", http.StatusInternalServerError)
+		return
+	}
+
+	invitations, err := models.GetTeamInvitations(team.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving team invitations: %v", err))
+		http.Error(context.Writer, "Error al obtener las invitaciones del equipo", http.StatusInternalServerError)
+		return
+	}
+
+	helpers.View(context, "teams/show.html", "Team", gin.H{
+		"team":        team,
+		"members":     members,
+		"invitations": invitations,
+		"roles":       teamRoles(),
+	})
+}
+
+// TeamInvite invita un email al equipo de la ruta y le envía el enlace para aceptar
+func TeamInvite(context *gin.Context) {
+	user, _ := auth.User(context)
+	team, _ := auth.Team(context)
+	back := "/teams/show/" + strconv.Itoa(team.ID)
+
+	address, err := mail.ParseAddress(strings.TrimSpace(context.PostForm("email")))
+	if err != nil {
+		teamRedirect(context, back, "error", "Please enter a valid email address.")
+		return
+	}
+
+	roleID := 0
+	if value := context.PostForm("role_id"); value != "" {
+		roleID, _ = strconv.Atoi(value)
+		if !isTeamRole(roleID) {
+			teamRedirect(context, back, "error", "This role cannot be assigned within a team.")
+			return
+		}
+	}
+
+	if invitee, err := models.GetUserByEmail(address.Address); err == nil {
+		if _, err := models.GetUserTeam(team.ID, invitee.ID); err == nil {
+			teamRedirect(context, back, "error", "This user is already a member of the team.")
+			return
+		}
+	}
+
+	token, err := models.CreateTeamInvitation(team.ID, address.Address, roleID, user.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error creating team invitation: %v", err))
+		teamRedirect(context, back, "error", "Error creating the invitation.")
+		return
+	}
+
+	link := utils.AppURL() + "/teams/invitations/accept/" + token
+	if err := notifications.SendTeamInvitation(address.Address, team.Name, link); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error sending team invitation: %v", err))
+		teamRedirect(context, back, "warning", "The invitation was created, but the email could not be sent.")
+		return
+	}
+
+	teamRedirect(context, back, "success", "Invitation sent successfully.")
+}
+
+// TeamInvitationDelete cancela una invitación pendiente del equipo de la ruta
+func TeamInvitationDelete(context *gin.Context) {
+	team, _ := auth.Team(context)
+	back := "/teams/show/" + strconv.Itoa(team.ID)
+
+	invitationID, err := strconv.Atoi(context.Param("invitation"))
+	if err == nil {
+		err = models.DeleteTeamInvitation(team.ID, invitationID)
+	}
+	if err != nil {
+		teamRedirect(context, back, "error", "Error cancelling the invitation.")
+		return
+	}
+
+	teamRedirect(context, back, "success", "Invitation cancelled.")
+}
+
+// TeamInvitationAccept une al usuario autenticado al equipo de la invitación
+func TeamInvitationAccept(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	invitation, err := models.GetTeamInvitationByToken(context.Param("token"))
+	if err != nil {
+		teamRedirect(context, "/teams", "error", "This invitation is invalid or has already been used.")
+		return
+	}
+
+	err = models.AcceptTeamInvitation(*invitation, user)
+	switch {
+	case errors.Is(err, models.ErrTeamInvitationExpired):
+		teamRedirect(context, "/teams", "error", "This invitation has expired.")
+		return
+	case errors.Is(err, models.ErrTeamInvitationEmailMismatch):
+		teamRedirect(context, "/teams", "error", "This invitation was sent to a different email address.")
+		return
+	case err != nil:
+		utils.Logs("ERROR", fmt.Sprintf("Error accepting team invitation: %v", err))
+		teamRedirect(context, "/teams", "error", "Error accepting the invitation.")
+		return
+	}
+
+	if err := utils.SetCurrentTeam(context.Writer, context.Request, invitation.TeamID); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error switching team: %v", err))
+	}
+	teamRedirect(context, "/teams/show/"+strconv.Itoa(invitation.TeamID), "success", "You have joined the team.")
+}
+
+// TeamMemberDelete quita a un miembro del equipo de la ruta, con sus roles y permisos en él
+func TeamMemberDelete(context *gin.Context) {
+	team, _ := auth.Team(context)
+	back := "/teams/show/" + strconv.Itoa(team.ID)
+
+	userID, err := strconv.Atoi(context.Param("user"))
+	if err == nil {
+		err = models.RemoveTeamMember(team.ID, userID)
+	}
+	if errors.Is(err, models.ErrTeamOwner) {
+		teamRedirect(context, back, "error", "The team owner cannot be removed.")
+		return
+	}
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error removing team member: %v", err))
+		teamRedirect(context, back, "error", "Error removing the member.")
+		return
+	}
+
+	teamRedirect(context, back, "success", "Member removed from the team.")
+}
+
+// TeamDelete elimina el equipo de la ruta
+func TeamDelete(context *gin.Context) {
+	team, _ := auth.Team(context)
+
+	if err := models.DeleteTeam(team.ID); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error deleting team: %v", err))
+		teamRedirect(context, "/teams/show/"+strconv.Itoa(team.ID), "error", "Error deleting the team.")
+		return
+	}
+
+	if utils.CurrentTeam(context.Request) == team.ID {
+		if err := utils.SetCurrentTeam(context.Writer, context.Request, 0); err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error switching team: %v", err))
+		}
+	}
+	teamRedirect(context, "/teams", "success", "Team deleted successfully.")
+}
+
+// teamRoles devuelve los roles de TEAM_ROLES que existen, para el formulario de invitación
+func teamRoles() []structs.RoleStruct {
+	var roles []structs.RoleStruct
+	for _, name := range models.TeamRoles() {
+		if role, err := models.GetRoleByName(name, "web"); err == nil {
+			roles = append(roles, *role)
+		}
+	}
+	return roles
+}
+
+// isTeamRole indica si el rol se puede asignar dentro de un equipo
+func isTeamRole(roleID int) bool {
+	for _, role := range teamRoles() {
+		if role.ID == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+func teamRedirect(context *gin.Context, location string, alert string, message string) {
+	utils.CreateFlashNotification(context.Writer, context.Request, alert, message)
+	context.Redirect(http.StatusSeeOther, location)
+	context.Abort()
+}
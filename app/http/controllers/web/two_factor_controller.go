@@ -0,0 +1,202 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/core/throttle"
+	"semita/app/helpers"
+	"semita/app/models"
+	"semita/app/structs"
+	"semita/app/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorChallenge muestra el formulario del segundo factor tras validar la contraseña
+func TwoFactorChallenge(context *gin.Context) {
+	if _, _, ok := utils.PendingTwoFactorUser(context.Request); !ok {
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	helpers.View(context, "auth/two_factor_challenge.html", "Two-Factor Authentication", nil)
+}
+
+// TwoFactorChallengePost valida el código TOTP o de recuperación y completa el inicio de sesión
+func TwoFactorChallengePost(context *gin.Context) {
+	userID, remember, ok := utils.PendingTwoFactorUser(context.Request)
+	if !ok {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Your login attempt expired, please sign in again")
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	if allowed, retryAfter := throttle.Attempt("two-factor:"+strconv.Itoa(userID), 5, time.Minute); !allowed {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", throttle.TooManyAttemptsMessage(retryAfter))
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor-challenge")
+		context.Abort()
+		return
+	}
+
+	if err := helpers.VerifyTwoFactorChallenge(userID, context.PostForm("code"), context.PostForm("recovery_code")); err != nil {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid authentication code")
+		context.Redirect(http.StatusSeeOther, "/auth/two-factor-challenge")
+		context.Abort()
+		return
+	}
+
+	user, err := models.GetUserByID(strconv.Itoa(userID))
+	if err == nil {
+		err = utils.LoginUserSession(context.Writer, context.Request, user)
+	}
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error creating user session: %v", err))
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error creating user session")
+		context.Redirect(http.StatusSeeOther, "/auth/login")
+		context.Abort()
+		return
+	}
+
+	if remember {
+		tokenHash, err := utils.QueueRememberCookie(context.Writer, user.ID)
+		if err == nil {
+			err = models.UpdateRememberToken(user.ID, tokenHash)
+		}
+		if err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error creating remember token: %v", err))
+		}
+	}
+
+	utils.CreateFlashNotification(context.Writer, context.Request, "success", "Login successful!")
+	context.Redirect(http.StatusSeeOther, "/")
+	context.Abort()
+}
+
+// TwoFactorIndex muestra el estado de la autenticación en dos pasos del usuario
+func TwoFactorIndex(context *gin.Context) {
+	renderTwoFactor(context, nil)
+}
+
+// TwoFactorEnable inicia la activación generando un secreto nuevo
+func TwoFactorEnable(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	if helpers.TwoFactorEnabled(user.ID) {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Two-factor authentication is already enabled")
+	} else if err := helpers.EnableTwoFactor(user.ID); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error enabling two-factor authentication: %v", err))
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error enabling two-factor authentication")
+	}
+
+	context.Redirect(http.StatusSeeOther, "/profile/two-factor")
+	context.Abort()
+}
+
+// TwoFactorConfirm confirma la activación con un código de la app y muestra los códigos de recuperación
+func TwoFactorConfirm(context *gin.Context) {
+	user, _ := auth.User(context)
+
+	codes, err := helpers.ConfirmTwoFactor(user.ID, context.PostForm("code"))
+	if err != nil {
+		if !errors.Is(err, helpers.ErrInvalidTwoFactorCode) && !errors.Is(err, helpers.ErrTwoFactorNotPending) {
+			utils.Logs("ERROR", fmt.Sprintf("Error confirming two-factor authentication: %v", err))
+		}
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Invalid authentication code")
+		context.Redirect(http.StatusSeeOther, "/profile/two-factor")
+		context.Abort()
+		return
+	}
+
+	renderTwoFactor(context, codes)
+}
+
+// TwoFactorRecoveryCodes genera códigos de recuperación nuevos; exige la contraseña actual
+func TwoFactorRecoveryCodes(context *gin.Context) {
+	user, ok := confirmPassword(context)
+	if !ok {
+		return
+	}
+
+	codes, err := helpers.RegenerateRecoveryCodes(user.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error regenerating recovery codes: %v", err))
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error regenerating recovery codes")
+		context.Redirect(http.StatusSeeOther, "/profile/two-factor")
+		context.Abort()
+		return
+	}
+
+	renderTwoFactor(context, codes)
+}
+
+// TwoFactorDisable desactiva la autenticación en dos pasos; exige la contraseña actual
+func TwoFactorDisable(context *gin.Context) {
+	user, ok := confirmPassword(context)
+	if !ok {
+		return
+	}
+
+	if helpers.TwoFactorRequired(user.ID) {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Your role requires two-factor authentication")
+	} else if err := helpers.DisableTwoFactor(user.ID); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error disabling two-factor authentication: %v", err))
+		utils.CreateFlashNotification(context.Writer, context.Request, "error", "Error disabling two-factor authentication")
+	} else {
+		utils.CreateFlashNotification(context.Writer, context.Request, "success", "Two-factor authentication disabled")
+	}
+
+	context.Redirect(http.StatusSeeOther, "/profile/two-factor")
+	context.Abort()
+}
+
+// renderTwoFactor muestra la página de 2FA; los códigos de recuperación solo se muestran al generarlos
+func renderTwoFactor(context *gin.Context, recoveryCodes []string) {
+	user, _ := auth.User(context)
+
+	twoFactor, err := models.GetTwoFactor(user.ID)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("Error retrieving two-factor settings: %v", err))
+		http.Error(context.Writer, "Error al obtener la configuración de dos pasos", http.StatusInternalServerError)
+		return
+	}
+
+	data := gin.H{
+		"enabled":        twoFactor.Enabled(),
+		"pending":        twoFactor.Pending(),
+		"required":       helpers.TwoFactorRequired(user.ID),
+		"recovery_codes": recoveryCodes,
+	}
+
+	if twoFactor.Pending() {
+		setup, err := helpers.PendingTwoFactorSetup(user.ID, user.Email)
+		if err != nil {
+			utils.Logs("ERROR", fmt.Sprintf("Error generating two-factor QR: %v", err))
+		} else {
+			data["setup"] = setup
+		}
+	}
+
+	helpers.View(context, "profile/two_factor.html", "Two-Factor Authentication", data)
+}
+
+// confirmPassword valida la contraseña actual enviada en el formulario antes de una acción sensible
+func confirmPassword(context *gin.Context) (structs.UserStruct, bool) {
+	sessionUser, _ := auth.User(context)
+
+	// Se valida con los proveedores de autenticación para que funcione también con la contraseña de LDAP
+	user, err := auth.Attempt(context.Request.Context(), auth.Credentials{Login: sessionUser.Email, Password: context.PostForm("password")})
+	if err != nil || user.ID != sessionUser.ID {
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "The password is incorrect")
+		context.Redirect(http.StatusSeeOther, "/profile/two-factor")
+		context.Abort()
+		return structs.UserStruct{}, false
+	}
+
+	return user, true
+}
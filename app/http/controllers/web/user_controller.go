@@ -3,6 +3,7 @@ package web
 import (
 	"fmt"
 	"net/http"
+	"semita/app/core/gate"
 	"semita/app/helpers"
 	"semita/app/models"
 	"semita/app/structs"
@@ -24,7 +25,7 @@ func UserIndex(context *gin.Context) {
 		return
 	}
 
-	var viewData = helpers.AuthSessionService(context.Writer, context.Request, "User Index", users)
+	var viewData = helpers.AuthSessionService(context, "User Index", users)
 
 	var templateIndexPath = "resources/users/index.html"
 	var templateIndex = template.Must(template.ParseFiles(templateIndexPath, config.MainLayoutFilePath))
@@ -36,7 +37,7 @@ func UserIndex(context *gin.Context) {
 }
 
 func UserCreate(context *gin.Context) {
-	var viewData = helpers.AuthSessionService(context.Writer, context.Request, "User Create", nil)
+	var viewData = helpers.AuthSessionService(context, "User Create", nil)
 	var templateCreatePath = "resources/users/create.html"
 	var templateCreate = template.Must(template.ParseFiles(templateCreatePath, config.MainLayoutFilePath))
 	var errorExecuteTemplate = templateCreate.Execute(context.Writer, viewData)
@@ -72,7 +73,7 @@ func UserShow(context *gin.Context) {
 		return
 	}
 
-	var viewData = helpers.AuthSessionService(context.Writer, context.Request, "User Create", user)
+	var viewData = helpers.AuthSessionService(context, "User Create", user)
 
 	var templateShowPath = "resources/users/show.html"
 	var templateShow = template.Must(template.ParseFiles(templateShowPath, config.MainLayoutFilePath))
@@ -92,7 +93,7 @@ func UserEdit(context *gin.Context) {
 		return
 	}
 
-	var viewData = helpers.AuthSessionService(context.Writer, context.Request, "User Edit", user)
+	var viewData = helpers.AuthSessionService(context, "User Edit", user)
 
 	var templateEditPath = "resources/users/edit.html"
 	var templateEdit = template.Must(template.ParseFiles(templateEditPath, config.MainLayoutFilePath))
@@ -125,6 +126,13 @@ func UserUpdate(context *gin.Context) {
 		return
 	}
 
+	// Quien edita su propia cuenta sin poder listar usuarios vuelve a su perfil
+	if gate.Denies(context, "view-any", structs.UserStruct{}) {
+		context.Redirect(http.StatusSeeOther, "/users/show/"+id)
+		context.Abort()
+		return
+	}
+
 	context.Redirect(http.StatusSeeOther, "/users")
 	context.Abort()
 }
@@ -7,6 +7,7 @@ import (
 	"semita/app/helpers"
 	"semita/config"
 	"text/template"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/signintech/gopdf"
@@ -16,7 +17,8 @@ import (
 )
 
 func IndexPDF(c *gin.Context) {
-	authData := helpers.AuthSessionService(c.Writer, c.Request, "PDF", nil)
+	data := map[string]string{"download_url": helpers.FileDownloadURLIfExists("ejemplo.pdf", 30*time.Minute)}
+	authData := helpers.AuthSessionService(c, "PDF", data)
 	tmpl := template.Must(template.ParseFiles("resources/utils/pdf.html", config.MainLayoutFilePath))
 	err := tmpl.Execute(c.Writer, authData)
 	if err != nil {
@@ -67,7 +69,8 @@ func GenerateNewPDF(c *gin.Context) {
 }
 
 func IndexExcel(c *gin.Context) {
-	authData := helpers.AuthSessionService(c.Writer, c.Request, "Excel", nil)
+	data := map[string]string{"download_url": helpers.FileDownloadURLIfExists("ejemplo.xlsx", 30*time.Minute)}
+	authData := helpers.AuthSessionService(c, "Excel", data)
 	tmpl := template.Must(template.ParseFiles("resources/utils/excel.html", config.MainLayoutFilePath))
 	err := tmpl.Execute(c.Writer, authData)
 	if err != nil {
@@ -127,7 +130,8 @@ func GenerateNewExcel(c *gin.Context) {
 }
 
 func IndexQR(c *gin.Context) {
-	authData := helpers.AuthSessionService(c.Writer, c.Request, "QR", nil)
+	data := map[string]string{"download_url": helpers.FileDownloadURLIfExists("ejemplo.png", 30*time.Minute)}
+	authData := helpers.AuthSessionService(c, "QR", data)
 	tmpl := template.Must(template.ParseFiles("resources/utils/qr.html", config.MainLayoutFilePath))
 	err := tmpl.Execute(c.Writer, authData)
 	if err != nil {
@@ -162,7 +166,7 @@ func GenerateNewQR(c *gin.Context) {
 }
 
 func IndexSendEmail(c *gin.Context) {
-	authData := helpers.AuthSessionService(c.Writer, c.Request, "Email", nil)
+	authData := helpers.AuthSessionService(c, "Email", nil)
 	tmpl := template.Must(template.ParseFiles("resources/utils/email.html", config.MainLayoutFilePath))
 	err := tmpl.Execute(c.Writer, authData)
 	if err != nil {
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"semita/app/core/auth"
 	"semita/app/utils"
 
 	"github.com/gin-gonic/gin"
@@ -9,7 +10,7 @@ import (
 
 func RequireAuth(handler gin.HandlerFunc) gin.HandlerFunc {
 	return func(context *gin.Context) {
-		if !utils.IsUserAuthenticated(context.Request) {
+		if !auth.Check(context) {
 			utils.CreateFlashNotification(context.Writer, context.Request, "error", "You must be logged in to access this page.")
 			context.Redirect(http.StatusSeeOther, "/auth/login")
 			context.Abort()
@@ -21,7 +22,7 @@ func RequireAuth(handler gin.HandlerFunc) gin.HandlerFunc {
 
 func RedirectGuest(handler gin.HandlerFunc) gin.HandlerFunc {
 	return func(context *gin.Context) {
-		if utils.IsUserAuthenticated(context.Request) {
+		if auth.Check(context) {
 			context.Redirect(http.StatusSeeOther, "/")
 			context.Abort()
 			return
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"semita/app/helpers"
+	"semita/app/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csrfExemptPrefixes son las rutas que se autentican con tokens y no con la sesión web
+var csrfExemptPrefixes = []string{"/api/", "/oauth/"}
+
+// VerifyCsrfToken rechaza las peticiones que modifican datos sin un token CSRF válido.
+// El token se envía en el campo _token del formulario o en la cabecera X-CSRF-Token.
+func VerifyCsrfToken() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		switch context.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			context.Next()
+			return
+		}
+
+		for _, prefix := range csrfExemptPrefixes {
+			if strings.HasPrefix(context.Request.URL.Path, prefix) {
+				context.Next()
+				return
+			}
+		}
+
+		token := context.PostForm("_token")
+		if token == "" {
+			token = context.GetHeader("X-CSRF-Token")
+		}
+
+		sessionToken := utils.SessionCsrfToken(context.Request)
+		if sessionToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(sessionToken)) != 1 {
+			utils.Logs("INFO", "Token CSRF inválido en "+context.Request.Method+" "+context.Request.URL.Path)
+
+			if strings.Contains(context.GetHeader("Accept"), "application/json") {
+				context.AbortWithStatusJSON(419, gin.H{
+					"error": "CSRF token mismatch",
+				})
+				return
+			}
+
+			context.Status(419)
+			helpers.View(context, "error/419.html", "Page Expired", nil)
+			context.Abort()
+			return
+		}
+
+		context.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/core/gate"
+	"semita/app/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resourceKey es la clave del contexto donde Can deja el recurso cargado por el binder
+const resourceKey = "gate_resource"
+
+// ResourceBinder carga el recurso de la ruta sobre el que se verifica la habilidad
+type ResourceBinder func(c *gin.Context) (any, error)
+
+// Can middleware que verifica una habilidad del gate sobre el recurso de la ruta. Con binder nil la
+// habilidad se verifica sin recurso; si el binder falla se responde 404.
+func Can(ability string, binder ResourceBinder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guard := auth.ResolveGuard(c)
+
+		var resource []any
+		if binder != nil {
+			bound, err := binder(c)
+			if err != nil {
+				denyAccess(c, guard, http.StatusNotFound, "The requested resource was not found.")
+				return
+			}
+			c.Set(resourceKey, bound)
+			resource = append(resource, bound)
+		}
+
+		switch gate.Authorize(c, ability, resource...) {
+		case nil:
+			c.Next()
+		case gate.ErrUnauthenticated:
+			denyAccess(c, guard, http.StatusUnauthorized, "You must be logged in to access this page.")
+		default:
+			denyAccess(c, guard, http.StatusForbidden, "You don't have permission to perform this action.")
+		}
+	}
+}
+
+// Resource devuelve el recurso que cargó Can para la petición
+func Resource(c *gin.Context) (any, bool) {
+	return c.Get(resourceKey)
+}
+
+// BindModel usa siempre el mismo valor como recurso; sirve para habilidades sobre el tipo, como
+// create o view-any, que eligen la policy sin un registro concreto
+func BindModel(model any) ResourceBinder {
+	return func(c *gin.Context) (any, error) {
+		return model, nil
+	}
+}
+
+// BindUser carga el usuario cuyo ID viene en el parámetro de la ruta
+func BindUser(param string) ResourceBinder {
+	return func(c *gin.Context) (any, error) {
+		return models.GetUserByID(c.Param(param))
+	}
+}
+
+// BindTeam carga el equipo cuyo ID viene en el parámetro de la ruta
+func BindTeam(param string) ResourceBinder {
+	return func(c *gin.Context) (any, error) {
+		teamID, err := strconv.Atoi(c.Param(param))
+		if err != nil {
+			return nil, err
+		}
+		return models.GetTeamByID(teamID)
+	}
+}
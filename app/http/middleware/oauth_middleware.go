@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"semita/app/core/auth"
 	"semita/app/models"
 	"semita/app/utils"
 	"strings"
@@ -15,6 +17,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		authHeader := context.GetHeader("Authorization")
 
 		if authHeader == "" {
+			// Sin credenciales RFC 6750 pide el desafío sin código de error
+			context.Header("WWW-Authenticate", `Bearer realm="api"`)
 			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Token no proporcionado",
 			})
@@ -24,9 +28,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// El token debe tener el formato "Bearer {token}"
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Formato de token inválido",
-			})
+			bearerChallenge(context, http.StatusUnauthorized, "invalid_token", "Malformed Authorization header", nil)
 			return
 		}
 
@@ -35,9 +37,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Validar el token JWT
 		claims, err := utils.ValidateJWTToken(tokenString)
 		if err != nil {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Token inválido",
-			})
+			bearerChallenge(context, http.StatusUnauthorized, "invalid_token", "The access token is invalid or expired", nil)
 			return
 		}
 
@@ -45,32 +45,53 @@ func AuthMiddleware() gin.HandlerFunc {
 		token, err := models.GetTokenByAccessToken(tokenString)
 
 		if err != nil || token.Revoked {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Token revocado o inválido",
-			})
+			bearerChallenge(context, http.StatusUnauthorized, "invalid_token", "The access token has been revoked", nil)
 			return
 		}
 
+		// Registrar el último uso del token y desde dónde se usó, como mucho una vez por minuto
+		if token.ShouldTouch(context.ClientIP(), context.Request.UserAgent()) {
+			if err := models.TouchTokenLastUsed(token.ID, context.ClientIP(), context.Request.UserAgent()); err != nil {
+				utils.Logs("ERROR", "No se pudo registrar el uso del token: "+err.Error())
+			}
+		}
+
 		// Almacenar información del token para uso posterior en controladores
 		context.Set("user_id", claims.Subject)
 		context.Set("client_id", claims.Audience[0])
 		context.Set("token_id", claims.ID)
 		context.Set("token_scopes", claims.Scopes)
 		context.Set("token", token)
+		// Si algo resolvió antes la identidad con la sesión, se vuelve a resolver con el token
+		auth.Forget(context)
 
 		context.Next()
 	}
 }
 
-// ScopeMiddleware es el middleware para verificar los scopes requeridos
+// ScopeMiddleware es el middleware para verificar los scopes requeridos.
+// Basta con que el token tenga uno de ellos; equivale a RequireAnyScope.
 func ScopeMiddleware(requiredScopes ...string) gin.HandlerFunc {
+	return RequireAnyScope(requiredScopes...)
+}
+
+// RequireScopes exige que el token tenga todos los scopes indicados (semántica AND)
+func RequireScopes(requiredScopes ...string) gin.HandlerFunc {
+	return scopeMiddleware(requiredScopes, utils.HasAllScopes)
+}
+
+// RequireAnyScope exige que el token tenga al menos uno de los scopes indicados (semántica OR)
+func RequireAnyScope(requiredScopes ...string) gin.HandlerFunc {
+	return scopeMiddleware(requiredScopes, utils.HasAnyScope)
+}
+
+// scopeMiddleware compara los scopes del token con los requeridos usando la estrategia indicada
+func scopeMiddleware(requiredScopes []string, check func(tokenScopes []string, requiredScopes []string) bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Este middleware debe usarse después de AuthMiddleware
 		scopes, exists := c.Get("token_scopes")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "No se ha autenticado correctamente",
-			})
+			bearerChallenge(c, http.StatusUnauthorized, "invalid_token", "The request is not authenticated", nil)
 			return
 		}
 
@@ -82,19 +103,30 @@ func ScopeMiddleware(requiredScopes ...string) gin.HandlerFunc {
 			return
 		}
 
-		// Verificar si el token tiene al menos uno de los scopes requeridos
-		for _, requiredScope := range requiredScopes {
-			if utils.HasScope(tokenScopes, requiredScope) {
-				// Si tiene al menos uno de los scopes requeridos, permitir el acceso
-				c.Next()
-				return
-			}
+		if check(tokenScopes, requiredScopes) {
+			c.Next()
+			return
 		}
 
-		// Si no tiene ninguno de los scopes requeridos, denegar el acceso
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"error":           "Acceso denegado",
-			"required_scopes": requiredScopes,
-		})
+		bearerChallenge(c, http.StatusForbidden, "insufficient_scope", "The access token does not have the required scopes", requiredScopes)
+	}
+}
+
+// bearerChallenge responde con el encabezado WWW-Authenticate definido en RFC 6750.
+// La descripción va en el encabezado, por lo que debe ser ASCII.
+func bearerChallenge(c *gin.Context, status int, errorCode string, description string, scopes []string) {
+	challenge := fmt.Sprintf(`Bearer realm="api", error="%s", error_description="%s"`, errorCode, description)
+	if len(scopes) > 0 {
+		challenge += fmt.Sprintf(`, scope="%s"`, strings.Join(scopes, " "))
+	}
+	c.Header("WWW-Authenticate", challenge)
+
+	body := gin.H{
+		"error":             errorCode,
+		"error_description": description,
+	}
+	if len(scopes) > 0 {
+		body["required_scopes"] = scopes
 	}
+	c.AbortWithStatusJSON(status, body)
 }
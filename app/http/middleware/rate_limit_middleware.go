@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/core/throttle"
+	"semita/config"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limita las peticiones con el limitador definido en config.RateLimiters, leído al
+// registrar la ruta, cuando el .env ya está cargado.
+// Informa el estado en las cabeceras X-RateLimit-Limit, X-RateLimit-Remaining y X-RateLimit-Reset,
+// y responde 429 con Retry-After cuando se supera el límite.
+func RateLimit(name string) gin.HandlerFunc {
+	limiter, ok := config.RateLimiters()[name]
+	if !ok {
+		panic(fmt.Sprintf("limitador %q no definido en config.RateLimiters", name))
+	}
+
+	return func(context *gin.Context) {
+		key := "rate-limit:" + name + ":" + rateLimitKey(context, limiter.By)
+		result := throttle.Default().SlidingAttempt(key, limiter.MaxAttempts, limiter.Window)
+
+		context.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		context.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		context.Header("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+		if !result.Allowed {
+			context.Header("Retry-After", strconv.Itoa(throttle.RetryAfterSeconds(result.RetryAfter)))
+			context.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"errors": []gin.H{{
+				"status": "429",
+				"title":  "Too Many Requests",
+				"detail": throttle.TooManyAttemptsMessage(result.RetryAfter),
+			}}})
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// rateLimitKey identifica al solicitante según el criterio del limitador; sin token se usa la IP
+func rateLimitKey(context *gin.Context, by string) string {
+	switch by {
+	case "user":
+		if userID := auth.ID(context); userID != 0 {
+			return "user:" + strconv.Itoa(userID)
+		}
+	case "client":
+		if clientID := context.GetString("client_id"); clientID != "" {
+			return "client:" + clientID
+		}
+	}
+	return "ip:" + context.ClientIP()
+}
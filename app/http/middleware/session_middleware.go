@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/models"
+	"semita/app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthenticateSession cierra la sesión web si la contraseña del usuario cambió después de iniciarla,
+// por ejemplo tras un restablecimiento de contraseña, o si cerró sesión en todas partes
+func AuthenticateSession() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if !utils.IsUserAuthenticated(context.Request) {
+			context.Next()
+			return
+		}
+
+		// El guard de sesión carga al usuario de la base de datos; si ya no existe no hay usuario
+		user, ok := auth.User(context)
+		if !ok || utils.PasswordFingerprint(user.Password) != utils.SessionPasswordFingerprint(context.Request) ||
+			user.SessionEpoch != utils.SessionEpoch(context.Request) {
+			_ = utils.LogoutUserSession(context.Writer, context.Request)
+			auth.Forget(context)
+			utils.ForgetRememberCookie(context.Writer)
+			utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Your session has expired, please log in again.")
+			context.Redirect(http.StatusSeeOther, "/auth/login")
+			context.Abort()
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// AuthenticateViaRemember restaura la sesión expirada a partir de la cookie de "recordarme".
+// El token se rota en cada uso, de modo que una cookie copiada solo sirve una vez.
+func AuthenticateViaRemember() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if utils.IsUserAuthenticated(context.Request) {
+			context.Next()
+			return
+		}
+
+		userID, tokenHash, ok := utils.ReadRememberCookie(context.Request)
+		if !ok {
+			context.Next()
+			return
+		}
+
+		user, err := models.GetUserByRememberToken(userID, tokenHash)
+		if err != nil {
+			utils.ForgetRememberCookie(context.Writer)
+			context.Next()
+			return
+		}
+
+		newHash, err := utils.QueueRememberCookie(context.Writer, user.ID)
+		if err == nil {
+			err = models.UpdateRememberToken(user.ID, newHash)
+		}
+		if err == nil {
+			err = utils.LoginUserSession(context.Writer, context.Request, user)
+		}
+		if err != nil {
+			utils.Logs("ERROR", "No se pudo restaurar la sesión recordada: "+err.Error())
+		}
+		auth.Forget(context)
+
+		context.Next()
+	}
+}
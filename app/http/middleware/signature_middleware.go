@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"semita/app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidSignature rechaza las peticiones cuya URL absoluta firmada fue alterada o expiró
+func ValidSignature() gin.HandlerFunc {
+	return signatureMiddleware(utils.ValidateSignature)
+}
+
+// ValidRelativeSignature rechaza las peticiones cuya URL relativa firmada fue alterada o expiró
+func ValidRelativeSignature() gin.HandlerFunc {
+	return signatureMiddleware(utils.ValidateRelativeSignature)
+}
+
+func signatureMiddleware(validate func(*url.URL) error) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if err := validate(context.Request.URL); err != nil {
+			if errors.Is(err, utils.ErrMissingAppKey) {
+				utils.Logs("ERROR", "Signed URL rejected: "+err.Error())
+			}
+			message := "Invalid signature"
+			if errors.Is(err, utils.ErrExpiredSignature) {
+				message = "Signature expired"
+			}
+			context.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": message,
+			})
+			return
+		}
+
+		context.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"semita/app/core/auth"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResolveTeam middleware que fija el equipo de la petición desde un parámetro de la ruta, de modo que
+// los middleware de roles y permisos que vienen después se evalúan en ese equipo. Responde 403 si el
+// usuario no es miembro.
+func ResolveTeam(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guard := auth.ResolveGuard(c)
+		teamID, err := strconv.Atoi(c.Param(param))
+		if err != nil || teamID <= 0 {
+			denyAccess(c, guard, http.StatusNotFound, "The requested team was not found.")
+			return
+		}
+
+		auth.SetTeam(c, teamID)
+		requireTeam(c, guard, "You are not a member of this team.")
+	}
+}
+
+// RequireTeam middleware que exige un equipo actual (de la ruta, la cabecera X-Team-ID o la sesión)
+// del que el usuario sea miembro
+func RequireTeam() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requireTeam(c, auth.ResolveGuard(c), "Select a team you belong to before continuing.")
+	}
+}
+
+func requireTeam(c *gin.Context, guard auth.GuardDriver, message string) {
+	identity, _ := auth.Resolve(c)
+	if identity == nil {
+		denyAccess(c, guard, http.StatusUnauthorized, "You must be logged in to access this page.")
+		return
+	}
+	if identity.Team == nil {
+		denyAccess(c, guard, http.StatusForbidden, message)
+		return
+	}
+	c.Next()
+}
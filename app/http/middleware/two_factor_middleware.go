@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/helpers"
+	"semita/app/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// twoFactorEnrollmentExempt son las rutas accesibles mientras el usuario no active 2FA
+var twoFactorEnrollmentExempt = []string{"/api/", "/oauth/", "/public/", "/profile/two-factor", "/auth/logout", "/set-lang"}
+
+// EnsureTwoFactorEnrolled redirige a /profile/two-factor a los usuarios cuyo rol exige
+// autenticación en dos pasos y todavía no la activaron
+func EnsureTwoFactorEnrolled() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		for _, prefix := range twoFactorEnrollmentExempt {
+			if strings.HasPrefix(context.Request.URL.Path, prefix) {
+				context.Next()
+				return
+			}
+		}
+
+		user, authenticated := auth.User(context)
+		if !authenticated || !helpers.MustEnrollTwoFactor(user.ID) {
+			context.Next()
+			return
+		}
+
+		utils.CreateFlashNotification(context.Writer, context.Request, "warning", "Your role requires two-factor authentication, please enable it to continue")
+		context.Redirect(http.StatusSeeOther, "/profile/two-factor")
+		context.Abort()
+	}
+}
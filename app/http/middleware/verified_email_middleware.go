@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"semita/app/core/auth"
+	"semita/app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail exige que el usuario de la sesión haya verificado su email. Incluye la
+// verificación de RequireAuth: sin sesión redirige al login.
+func RequireVerifiedEmail(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		user, authenticated := auth.User(context)
+		if !authenticated {
+			utils.CreateFlashNotification(context.Writer, context.Request, "error", "You must be logged in to access this page.")
+			context.Redirect(http.StatusSeeOther, "/auth/login")
+			context.Abort()
+			return
+		}
+
+		if !user.HasVerifiedEmail() {
+			utils.CreateFlashNotification(context.Writer, context.Request, "warning", "You must verify your email address to access this page.")
+			context.Redirect(http.StatusSeeOther, "/auth/email/verify")
+			context.Abort()
+			return
+		}
+		handler(context)
+	}
+}
+
+// RequireVerifiedEmailApi rechaza los tokens de usuarios sin email verificado.
+// Debe usarse después de AuthMiddleware.
+func RequireVerifiedEmailApi() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		user, authenticated := auth.User(context)
+		if !authenticated || !user.HasVerifiedEmail() {
+			context.AbortWithStatusJSON(http.StatusForbidden, gin.H{"errors": []gin.H{{
+				"status": "403",
+				"title":  "Forbidden",
+				"detail": "You must verify your email address to access this resource",
+			}}})
+			return
+		}
+
+		context.Next()
+	}
+}
@@ -11,6 +11,11 @@ var validate = validator.New()
 type LoginRequest struct {
 	Email    string `form:"email" json:"email" binding:"required,email"`
 	Password string `form:"password" json:"password" binding:"required,min=6"`
+	Scope    string `form:"scope" json:"scope"` // Separados por espacio, como en RFC 6749
+	Nonce    string `form:"nonce" json:"nonce"` // Se copia en el id_token cuando se solicita openid
+	// Segundo factor, obligatorio si el usuario tiene 2FA activo: código TOTP o de recuperación
+	Otp          string `form:"otp" json:"otp"`
+	RecoveryCode string `form:"recovery_code" json:"recovery_code"`
 }
 
 func (r *LoginRequest) Validate(c *gin.Context) error {
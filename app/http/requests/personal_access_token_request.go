@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalAccessTokenRequest valida la creación de un token de acceso personal
+type PersonalAccessTokenRequest struct {
+	Name      string   `form:"name" json:"name" binding:"required,max=255"`
+	Scopes    []string `form:"scopes" json:"scopes"`
+	ExpiresAt string   `form:"expires_at" json:"expires_at"`
+}
+
+func (r *PersonalAccessTokenRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBind(r); err != nil {
+		return err
+	}
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	_, err := r.Expiration()
+	return err
+}
+
+// Expiration interpreta expires_at como fecha (2006-01-02) o RFC3339; nil si no se indicó
+func (r *PersonalAccessTokenRequest) Expiration() (*time.Time, error) {
+	if r.ExpiresAt == "" {
+		return nil, nil
+	}
+
+	if expiresAt, err := time.Parse(time.RFC3339, r.ExpiresAt); err == nil {
+		return &expiresAt, nil
+	}
+
+	expiresAt, err := time.ParseInLocation("2006-01-02", r.ExpiresAt, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	// Una fecha sin hora expira al final del día indicado
+	expiresAt = expiresAt.Add(24*time.Hour - time.Second)
+	return &expiresAt, nil
+}
@@ -0,0 +1,77 @@
+package resources
+
+import "semita/app/structs"
+
+// WebSessionResource representa una sesión web abierta en formato JSON:API
+type WebSessionResource struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id"`
+	Attributes WebSessionAttrs `json:"attributes"`
+}
+
+type WebSessionAttrs struct {
+	IPAddress    string `json:"ip_address"`
+	UserAgent    string `json:"user_agent"`
+	LastActivity string `json:"last_activity"`
+	Current      bool   `json:"current"`
+}
+
+// NewWebSessionResource construye la respuesta de una sesión web
+func NewWebSessionResource(session structs.ActiveSessionStruct) WebSessionResource {
+	return WebSessionResource{
+		Type: "web_sessions",
+		ID:   session.Key,
+		Attributes: WebSessionAttrs{
+			IPAddress:    session.IPAddress,
+			UserAgent:    session.UserAgent,
+			LastActivity: session.LastActivity,
+			Current:      session.Current,
+		},
+	}
+}
+
+// AccessTokenResource representa un token OAuth activo en formato JSON:API, sin exponer su valor
+type AccessTokenResource struct {
+	Type       string           `json:"type"`
+	ID         int64            `json:"id"`
+	Attributes AccessTokenAttrs `json:"attributes"`
+}
+
+type AccessTokenAttrs struct {
+	Name       string   `json:"name"`
+	ClientName string   `json:"client_name"`
+	Scopes     []string `json:"scopes"`
+	IPAddress  *string  `json:"ip_address"`
+	UserAgent  *string  `json:"user_agent"`
+	LastUsedAt *string  `json:"last_used_at"`
+	ExpiresAt  string   `json:"expires_at"`
+	CreatedAt  string   `json:"created_at"`
+	Current    bool     `json:"current"`
+}
+
+// NewAccessTokenResource construye la respuesta de un token activo
+func NewAccessTokenResource(id int64, name, clientName string, scopes []string, ipAddress, userAgent, lastUsedAt, expiresAt, createdAt string, current bool) AccessTokenResource {
+	return AccessTokenResource{
+		Type: "oauth_tokens",
+		ID:   id,
+		Attributes: AccessTokenAttrs{
+			Name:       name,
+			ClientName: clientName,
+			Scopes:     scopes,
+			IPAddress:  nullableString(ipAddress),
+			UserAgent:  nullableString(userAgent),
+			LastUsedAt: nullableString(lastUsedAt),
+			ExpiresAt:  expiresAt,
+			CreatedAt:  createdAt,
+			Current:    current,
+		},
+	}
+}
+
+// nullableString convierte la cadena vacía en null
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
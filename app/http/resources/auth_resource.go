@@ -40,6 +40,7 @@ type AuthLoginMeta struct {
 	RefreshToken string      `json:"refresh_token"`
 	ExpiresIn    int         `json:"expires_in"`
 	Scope        interface{} `json:"scope"`
+	IDToken      string      `json:"id_token,omitempty"`
 }
 
 func NewAuthLoginResponse(resource AuthResource, refreshToken string, expiresIn int, scope interface{}) AuthLoginResponse {
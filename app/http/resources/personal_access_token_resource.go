@@ -0,0 +1,48 @@
+package resources
+
+// PersonalAccessTokenResource representa un token de acceso personal en formato JSON:API
+type PersonalAccessTokenResource struct {
+	Type       string                   `json:"type"`
+	ID         int64                    `json:"id"`
+	Attributes PersonalAccessTokenAttrs `json:"attributes"`
+	Meta       *PersonalAccessTokenMeta `json:"meta,omitempty"`
+}
+
+type PersonalAccessTokenAttrs struct {
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	LastUsedAt *string  `json:"last_used_at"`
+	ExpiresAt  string   `json:"expires_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// PersonalAccessTokenMeta solo se incluye al crear el token, es la única vez que se muestra
+type PersonalAccessTokenMeta struct {
+	Token string `json:"token"`
+}
+
+// NewPersonalAccessTokenResource construye la respuesta de un token sin exponer su valor
+func NewPersonalAccessTokenResource(id int64, name string, scopes []string, lastUsedAt, expiresAt, createdAt string) PersonalAccessTokenResource {
+	var lastUsed *string
+	if lastUsedAt != "" {
+		lastUsed = &lastUsedAt
+	}
+
+	return PersonalAccessTokenResource{
+		Type: "personal_access_tokens",
+		ID:   id,
+		Attributes: PersonalAccessTokenAttrs{
+			Name:       name,
+			Scopes:     scopes,
+			LastUsedAt: lastUsed,
+			ExpiresAt:  expiresAt,
+			CreatedAt:  createdAt,
+		},
+	}
+}
+
+// WithToken adjunta el valor del token a la respuesta de creación
+func (r PersonalAccessTokenResource) WithToken(token string) PersonalAccessTokenResource {
+	r.Meta = &PersonalAccessTokenMeta{Token: token}
+	return r
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"semita/config"
+)
+
+// GetCacheVersion devuelve la versión compartida de una caché; 0 si nunca se invalidó
+func GetCacheVersion(key string) (int64, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var version int64
+	err := db.QueryRow("SELECT version FROM cache_versions WHERE cache_key = ?", key).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return version, err
+}
+
+// IncrementCacheVersion invalida una caché en todas las instancias aumentando su versión
+func IncrementCacheVersion(key string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	_, err := db.Exec(`INSERT INTO cache_versions (cache_key, version) VALUES (?, 1)
+		ON DUPLICATE KEY UPDATE version = version + 1`, key)
+	return err
+}
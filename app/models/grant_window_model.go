@@ -0,0 +1,224 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"semita/app/structs"
+	"semita/config"
+	"time"
+)
+
+// ErrInvalidGrantWindow indica una vigencia que ya venció o que vence antes de empezar
+var ErrInvalidGrantWindow = errors.New("the grant must expire in the future and after it starts")
+
+// grantTable describe una tabla de asignaciones a usuarios: user_roles o user_permissions
+type grantTable struct {
+	table      string
+	column     string // Columna del rol o permiso asignado
+	namesTable string // Tabla con el nombre del rol o permiso
+	kind       string // role o permission
+	label      string // Nombre del tipo en los detalles de los eventos
+}
+
+var roleGrants = grantTable{table: userRolesTable, column: "role_id", namesTable: rolesTable, kind: "role", label: "Rol"}
+var permissionGrants = grantTable{table: userPermissionsTable, column: "permission_id", namesTable: permissionsTable, kind: "permission", label: "Permiso"}
+
+// activeGrant devuelve la condición SQL de una asignación vigente de la tabla o alias. Recibe dos
+// veces la hora actual como parámetros (grantNow).
+func activeGrant(alias string) string {
+	return "(" + alias + ".starts_at IS NULL OR " + alias + ".starts_at <= ?) AND (" +
+		alias + ".expires_at IS NULL OR " + alias + ".expires_at > ?)"
+}
+
+// grantNow es la hora actual en el formato en que se guardan las vigencias
+func grantNow() string {
+	return time.Now().Format("2006-01-02 15:04:05")
+}
+
+// grantTimeValue convierte un extremo opcional de la vigencia al valor de la columna
+func grantTimeValue(value *time.Time) any {
+	if value == nil {
+		return nil
+	}
+	return value.In(time.Local).Format("2006-01-02 15:04:05")
+}
+
+// validateGrantWindow devuelve ErrInvalidGrantWindow si la asignación no llegaría a estar vigente
+func validateGrantWindow(window structs.GrantWindow) error {
+	if window.ExpiresAt == nil {
+		return nil
+	}
+	if !window.ExpiresAt.After(time.Now()) {
+		return ErrInvalidGrantWindow
+	}
+	if window.StartsAt != nil && !window.ExpiresAt.After(*window.StartsAt) {
+		return ErrInvalidGrantWindow
+	}
+	return nil
+}
+
+// nextGrantChange devuelve el próximo momento en que empieza o vence alguna asignación del usuario,
+// globales o del equipo, o el tiempo cero si no hay ninguna pendiente. La caché de permisos no guarda
+// los roles y permisos resueltos más allá de ese momento.
+func nextGrantChange(userID int, teamID int) (time.Time, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `
+		SELECT MIN(boundary) FROM (
+			SELECT starts_at AS boundary FROM ` + userRolesTable + `
+			WHERE user_id = ? AND (team_id = 0 OR team_id = ?) AND starts_at > ?
+			UNION ALL
+			SELECT expires_at FROM ` + userRolesTable + `
+			WHERE user_id = ? AND (team_id = 0 OR team_id = ?) AND expires_at > ?
+			UNION ALL
+			SELECT starts_at FROM ` + userPermissionsTable + `
+			WHERE user_id = ? AND (team_id = 0 OR team_id = ?) AND starts_at > ?
+			UNION ALL
+			SELECT expires_at FROM ` + userPermissionsTable + `
+			WHERE user_id = ? AND (team_id = 0 OR team_id = ?) AND expires_at > ?
+		) boundaries
+	`
+	now := grantNow()
+	var boundary sql.NullString
+	err := database.QueryRow(query,
+		userID, teamID, now, userID, teamID, now, userID, teamID, now, userID, teamID, now,
+	).Scan(&boundary)
+	if err != nil || !boundary.Valid {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation("2006-01-02 15:04:05", boundary.String, time.Local)
+}
+
+// replaceInactiveGrant prepara una nueva asignación: da por vencida la anterior del mismo rol o
+// permiso si ya venció y descarta la que todavía no empezó
+func replaceInactiveGrant(grants grantTable, userID int, targetID int, teamID int) error {
+	_, err := expireGrants(grants, "g.user_id = ? AND g."+grants.column+" = ? AND g.team_id = ?",
+		userID, targetID, teamID)
+	if err != nil {
+		return err
+	}
+
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `DELETE FROM ` + grants.table + ` WHERE user_id = ? AND ` + grants.column + ` = ? AND team_id = ?`
+	_, err = database.Exec(query, userID, targetID, teamID)
+	return err
+}
+
+// ExpireLapsedGrants elimina las asignaciones de roles y permisos vencidas y registra un evento de
+// seguridad (role.expired o permission.expired) por cada una. Devuelve cuántas se eliminaron.
+func ExpireLapsedGrants() (int, error) {
+	expired := 0
+	for _, grants := range []grantTable{roleGrants, permissionGrants} {
+		count, err := expireGrants(grants, "")
+		expired += count
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// expireGrants elimina las asignaciones vencidas de la tabla que cumplen la condición (alias g),
+// registrando antes su evento de seguridad. Si el registro falla la asignación se conserva para el
+// próximo intento; vencida, ya no cuenta en ninguna verificación.
+func expireGrants(grants grantTable, condition string, args ...any) (int, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	now := grantNow()
+	query := `
+		SELECT g.id, g.user_id, g.team_id, n.name, n.guard_name, g.expires_at
+		FROM ` + grants.table + ` g
+		INNER JOIN ` + grants.namesTable + ` n ON n.id = g.` + grants.column + `
+		WHERE g.expires_at <= ?`
+	if condition != "" {
+		query += " AND " + condition
+	}
+	rows, err := database.Query(query, append([]any{now}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+
+	type lapsedGrant struct {
+		id, userID, teamID         int
+		name, guardName, expiresAt string
+	}
+	var lapsed []lapsedGrant
+	for rows.Next() {
+		var grant lapsedGrant
+		if err = rows.Scan(&grant.id, &grant.userID, &grant.teamID, &grant.name, &grant.guardName, &grant.expiresAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lapsed = append(lapsed, grant)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, grant := range lapsed {
+		details := fmt.Sprintf("%s %s (guard %s) vencido el %s", grants.label, grant.name, grant.guardName, grant.expiresAt)
+		if grant.teamID != 0 {
+			details += fmt.Sprintf(" en el equipo %d", grant.teamID)
+		}
+		err = CreateSecurityEvent(SecurityEvent{UserID: int64(grant.userID), Event: grants.kind + ".expired", Details: details})
+		if err != nil {
+			return expired, err
+		}
+
+		query := `DELETE FROM ` + grants.table + ` WHERE id = ?`
+		if _, err = database.Exec(query, grant.id); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// GetExpiringGrants obtiene las asignaciones de roles y permisos que vencen dentro del plazo, de la
+// más próxima a la más lejana
+func GetExpiringGrants(within time.Duration) ([]structs.UserGrantStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	var selects []string
+	var args []any
+	now := time.Now()
+	for _, grants := range []grantTable{roleGrants, permissionGrants} {
+		selects = append(selects, `
+			SELECT '`+grants.kind+`' AS grant_type, g.user_id, u.email, n.name, n.guard_name, g.team_id,
+				COALESCE(g.starts_at, ''), g.expires_at
+			FROM `+grants.table+` g
+			INNER JOIN `+grants.namesTable+` n ON n.id = g.`+grants.column+`
+			INNER JOIN `+userTable+` u ON u.id = g.user_id
+			WHERE g.expires_at > ? AND g.expires_at <= ?`)
+		args = append(args, now.Format("2006-01-02 15:04:05"), now.Add(within).Format("2006-01-02 15:04:05"))
+	}
+	query := selects[0] + " UNION ALL " + selects[1] + " ORDER BY expires_at"
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []structs.UserGrantStruct
+	for rows.Next() {
+		var grant structs.UserGrantStruct
+		err = rows.Scan(&grant.Type, &grant.UserID, &grant.UserEmail, &grant.Name, &grant.GuardName, &grant.TeamID,
+			&grant.StartsAt, &grant.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
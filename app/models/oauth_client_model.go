@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"semita/app/utils"
 	"semita/config"
 	"strings"
 )
@@ -96,6 +97,22 @@ func GetAllClients() ([]OAuthClient, error) {
 	return clients, nil
 }
 
+// GetClientByGrantType obtiene el primer cliente que soporta el grant indicado, o nil si no hay ninguno
+func GetClientByGrantType(grantType string) (*OAuthClient, error) {
+	clients, err := GetAllClients()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, client := range clients {
+		if client.SupportsGrantType(grantType) {
+			return &client, nil
+		}
+	}
+
+	return nil, nil
+}
+
 // CreateClient crea un nuevo cliente OAuth
 func CreateClient(name, redirectURI, grantTypes, scopes string) (*OAuthClient, error) {
 	// Generar client_id y client_secret aleatorios
@@ -205,6 +222,11 @@ func (c *OAuthClient) GetScopesArray() []string {
 	return strings.Split(c.Scopes, ",")
 }
 
+// AllowsScope verifica si el cliente puede conceder un scope, considerando comodines
+func (c *OAuthClient) AllowsScope(scope string) bool {
+	return utils.HasScope(c.GetScopesArray(), scope)
+}
+
 // generateSecureToken genera un token aleatorio seguro
 func generateSecureToken(length int) (string, error) {
 	bytes := make([]byte, length)
@@ -1,7 +1,12 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"semita/app/utils"
 	"semita/config"
+	"strings"
 )
 
 type OAuthScope struct {
@@ -140,8 +145,18 @@ func ValidateScopes(scopes []string) (bool, error) {
 	defer db.Close()
 
 	for _, scope := range scopes {
+		// "*" siempre es válido; "recurso:*" es válido si existe algún scope de ese recurso
+		if scope == "*" {
+			continue
+		}
+
 		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM "+oauthScopeTable+" WHERE name = ?", scope).Scan(&count)
+		var err error
+		if prefix, ok := strings.CutSuffix(scope, ":*"); ok {
+			err = db.QueryRow("SELECT COUNT(*) FROM "+oauthScopeTable+" WHERE name LIKE ?", prefix+":%").Scan(&count)
+		} else {
+			err = db.QueryRow("SELECT COUNT(*) FROM "+oauthScopeTable+" WHERE name = ?", scope).Scan(&count)
+		}
 		if err != nil {
 			return false, err
 		}
@@ -152,3 +167,55 @@ func ValidateScopes(scopes []string) (bool, error) {
 
 	return true, nil
 }
+
+// ErrInvalidScope se devuelve cuando se solicita un scope inexistente o no permitido para el cliente
+var ErrInvalidScope = errors.New("invalid_scope")
+
+// ValidateRequestedScopes verifica que los scopes solicitados existan y que el cliente pueda concederlos
+func ValidateRequestedScopes(client *OAuthClient, scopes []string) error {
+	for _, scope := range scopes {
+		if !client.AllowsScope(scope) {
+			return fmt.Errorf("%w: el cliente no permite el scope %q", ErrInvalidScope, scope)
+		}
+	}
+
+	valid, err := ValidateScopes(scopes)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("%w: uno o más scopes no existen", ErrInvalidScope)
+	}
+
+	return nil
+}
+
+// DefaultScopes son los scopes de un token que no solicita ninguno (OAUTH_DEFAULT_SCOPES, separados
+// por espacio o coma). Por defecto solo users:read, para no dar acceso total a quien no pide nada.
+func DefaultScopes() []string {
+	value, ok := os.LookupEnv("OAUTH_DEFAULT_SCOPES")
+	if !ok {
+		value = "users:read"
+	}
+	return utils.ParseScopes(value)
+}
+
+// ResolveRequestedScopes valida los scopes solicitados al emitir un token.
+// Si no se solicita ninguno, el token recibe los scopes por defecto que el cliente permite.
+func ResolveRequestedScopes(client *OAuthClient, scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		defaults := []string{}
+		for _, scope := range DefaultScopes() {
+			if client.AllowsScope(scope) {
+				defaults = append(defaults, scope)
+			}
+		}
+		return defaults, nil
+	}
+
+	if err := ValidateRequestedScopes(client, scopes); err != nil {
+		return nil, err
+	}
+
+	return scopes, nil
+}
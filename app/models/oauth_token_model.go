@@ -13,11 +13,15 @@ type OAuthToken struct {
 	ID           int64  `db:"id"`
 	UserID       int64  `db:"user_id"`
 	ClientID     int64  `db:"client_id"`
+	Name         string `db:"name"`
 	AccessToken  string `db:"access_token"`
 	RefreshToken string `db:"refresh_token"`
 	Scopes       string `db:"scopes"` // Coma separada
 	Revoked      bool   `db:"revoked"`
 	ExpiresAt    string `db:"expires_at"`
+	LastUsedAt   string `db:"last_used_at"`
+	IPAddress    string `db:"ip_address"` // Del último uso
+	UserAgent    string `db:"user_agent"` // Del último uso
 	CreatedAt    string `db:"created_at"`
 	UpdatedAt    string `db:"updated_at"`
 }
@@ -25,21 +29,27 @@ type OAuthToken struct {
 // Tabla de tokens OAuth
 const oauthTokenTable = "oauth_tokens"
 
-// GetTokenByAccessToken obtiene un token por su access_token
-func GetTokenByAccessToken(accessToken string) (*OAuthToken, error) {
-	db := config.DatabaseConnect()
-	defer db.Close()
+// tokenTouchInterval es el tiempo mínimo entre dos registros de uso de un mismo token
+const tokenTouchInterval = time.Minute
 
-	query := `SELECT id, user_id, client_id, access_token, refresh_token, 
-              scopes, revoked, expires_at, created_at, updated_at 
-              FROM ` + oauthTokenTable + ` 
-              WHERE access_token = ? AND revoked = 0`
+// Columnas seleccionadas al recuperar tokens, las nulas se normalizan a cadena vacía
+const oauthTokenColumns = `id, user_id, client_id, COALESCE(name, ''), access_token, refresh_token, 
+              COALESCE(scopes, ''), revoked, expires_at, COALESCE(last_used_at, ''),
+              COALESCE(ip_address, ''), COALESCE(user_agent, ''), created_at, updated_at`
 
+// tokenScanner abstrae *sql.Row y *sql.Rows para reutilizar el escaneo
+type tokenScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanOAuthToken lee una fila con las columnas de oauthTokenColumns
+func scanOAuthToken(row tokenScanner) (*OAuthToken, error) {
 	var token OAuthToken
-	err := db.QueryRow(query, accessToken).Scan(
-		&token.ID, &token.UserID, &token.ClientID,
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.ClientID, &token.Name,
 		&token.AccessToken, &token.RefreshToken, &token.Scopes,
-		&token.Revoked, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
+		&token.Revoked, &token.ExpiresAt, &token.LastUsedAt, &token.IPAddress, &token.UserAgent,
+		&token.CreatedAt, &token.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -48,27 +58,28 @@ func GetTokenByAccessToken(accessToken string) (*OAuthToken, error) {
 	return &token, nil
 }
 
+// GetTokenByAccessToken obtiene un token por su access_token
+func GetTokenByAccessToken(accessToken string) (*OAuthToken, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := `SELECT ` + oauthTokenColumns + ` 
+              FROM ` + oauthTokenTable + ` 
+              WHERE access_token = ? AND revoked = 0`
+
+	return scanOAuthToken(db.QueryRow(query, accessToken))
+}
+
 // GetTokenByRefreshToken obtiene un token por su refresh_token
 func GetTokenByRefreshToken(refreshToken string) (*OAuthToken, error) {
 	db := config.DatabaseConnect()
 	defer db.Close()
 
-	query := `SELECT id, user_id, client_id, access_token, refresh_token, 
-              scopes, revoked, expires_at, created_at, updated_at 
+	query := `SELECT ` + oauthTokenColumns + ` 
               FROM ` + oauthTokenTable + ` 
               WHERE refresh_token = ? AND revoked = 0`
 
-	var token OAuthToken
-	err := db.QueryRow(query, refreshToken).Scan(
-		&token.ID, &token.UserID, &token.ClientID,
-		&token.AccessToken, &token.RefreshToken, &token.Scopes,
-		&token.Revoked, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &token, nil
+	return scanOAuthToken(db.QueryRow(query, refreshToken))
 }
 
 // CreateToken crea un nuevo token de acceso
@@ -211,30 +222,153 @@ func (t *OAuthToken) GetScopesArray() []string {
 
 // HasScope verifica si el token tiene un scope específico
 func (t *OAuthToken) HasScope(requiredScope string) bool {
-	scopes := t.GetScopesArray()
-	for _, scope := range scopes {
-		if scope == requiredScope {
-			return true
-		}
-	}
-	return false
+	return utils.HasScope(t.GetScopesArray(), requiredScope)
 }
 
 // Función auxiliar para obtener un token por ID
 func getTokenByID(db *sql.DB, id int64) (*OAuthToken, error) {
-	query := `SELECT id, user_id, client_id, access_token, refresh_token, 
-              scopes, revoked, expires_at, created_at, updated_at 
+	query := `SELECT ` + oauthTokenColumns + ` 
               FROM ` + oauthTokenTable + ` WHERE id = ?`
 
-	var token OAuthToken
-	err := db.QueryRow(query, id).Scan(
-		&token.ID, &token.UserID, &token.ClientID,
-		&token.AccessToken, &token.RefreshToken, &token.Scopes,
-		&token.Revoked, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
+	return scanOAuthToken(db.QueryRow(query, id))
+}
+
+// ShouldTouch indica si hay que registrar el uso del token: cuando pasó tokenTouchInterval desde el
+// último registro o cambió la IP o el user agent. Así no se escribe en cada petición.
+func (t *OAuthToken) ShouldTouch(ipAddress string, userAgent string) bool {
+	if t.IPAddress != ipAddress || t.UserAgent != userAgent {
+		return true
+	}
+	lastUsedAt, err := time.ParseInLocation("2006-01-02 15:04:05", t.LastUsedAt, time.Local)
+	if err != nil {
+		return true
+	}
+	return time.Since(lastUsedAt) >= tokenTouchInterval
+}
+
+// TouchTokenLastUsed registra el momento, la IP y el user agent del último uso de un token
+func TouchTokenLastUsed(id int64, ipAddress string, userAgent string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE "+oauthTokenTable+" SET last_used_at = ?, ip_address = ?, user_agent = ? WHERE id = ?",
+		time.Now().Format("2006-01-02 15:04:05"), ipAddress, userAgent, id)
+	return err
+}
+
+// OAuthTokenWithClient es un token junto con el nombre del cliente que lo emitió
+type OAuthTokenWithClient struct {
+	OAuthToken
+	ClientName string
+}
+
+// GetActiveUserTokens obtiene los tokens no revocados ni expirados de un usuario, de cualquier cliente
+func GetActiveUserTokens(userID int64) ([]OAuthTokenWithClient, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := `SELECT ` + oauthTokenColumns + `,
+              COALESCE((SELECT name FROM oauth_clients WHERE oauth_clients.id = ` + oauthTokenTable + `.client_id), '')
+              FROM ` + oauthTokenTable + `
+              WHERE user_id = ? AND revoked = 0 AND expires_at > ?
+              ORDER BY COALESCE(last_used_at, created_at) DESC`
 
+	rows, err := db.Query(query, userID, time.Now().Format("2006-01-02 15:04:05"))
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var tokens []OAuthTokenWithClient
+	for rows.Next() {
+		var token OAuthTokenWithClient
+		err := rows.Scan(
+			&token.ID, &token.UserID, &token.ClientID, &token.Name,
+			&token.AccessToken, &token.RefreshToken, &token.Scopes,
+			&token.Revoked, &token.ExpiresAt, &token.LastUsedAt, &token.IPAddress, &token.UserAgent,
+			&token.CreatedAt, &token.UpdatedAt, &token.ClientName)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
 
-	return &token, nil
+	return tokens, rows.Err()
+}
+
+// RevokeUserToken revoca un token de cualquier cliente que pertenezca al usuario
+func RevokeUserToken(userID int64, tokenID int64) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE "+oauthTokenTable+" SET revoked = 1 WHERE id = ? AND user_id = ? AND revoked = 0", tokenID, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("token no encontrado")
+	}
+
+	return nil
+}
+
+// PurgeTokens elimina por lotes los tokens revocados y/o expirados hace más de olderThan.
+// Un token se considera expirado cuando ya no sirve ni el access token ni su refresh token.
+func PurgeTokens(revoked bool, expired bool, olderThan time.Duration, batchSize int) (int64, error) {
+	refreshLifetime, err := utils.TokenLifetime(true)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var conditions []string
+	var args []any
+
+	if revoked {
+		conditions = append(conditions, "(revoked = 1 AND updated_at < ?)")
+		args = append(args, cutoff.Format("2006-01-02 15:04:05"))
+	}
+	if expired {
+		conditions = append(conditions, "(expires_at < ? AND created_at < ?)")
+		args = append(args, cutoff.Format("2006-01-02 15:04:05"), cutoff.Add(-refreshLifetime).Format("2006-01-02 15:04:05"))
+	}
+	if len(conditions) == 0 {
+		return 0, nil
+	}
+
+	query := "DELETE FROM " + oauthTokenTable + " WHERE " + strings.Join(conditions, " OR ") + " LIMIT ?"
+	return deleteInBatches(query, args, batchSize)
+}
+
+// deleteInBatches ejecuta un DELETE ... LIMIT repetidamente para no bloquear tablas grandes
+func deleteInBatches(query string, args []any, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var total int64
+	for {
+		result, err := db.Exec(query, append(args, batchSize)...)
+		if err != nil {
+			return total, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += affected
+
+		if affected < int64(batchSize) {
+			return total, nil
+		}
+	}
 }
@@ -1,23 +1,73 @@
 package models
 
 import (
+	"errors"
+	"os"
+	"semita/app/utils"
 	"semita/config"
+	"strconv"
 	"time"
 )
 
 type PasswordReset struct {
 	Email     string
-	Token     string
+	Token     string // Hash del token, el valor en claro solo viaja por correo
 	CreatedAt time.Time
 }
 
-func CreatePasswordReset(email, token string) error {
+// ErrPasswordResetThrottled se devuelve cuando se solicita otro token demasiado pronto
+var ErrPasswordResetThrottled = errors.New("espera antes de solicitar otro enlace de recuperación")
+
+// PasswordResetExpiration es la vigencia de un token de restablecimiento (AUTH_PASSWORD_RESET_EXPIRE, en minutos)
+func PasswordResetExpiration() time.Duration {
+	return envMinutes("AUTH_PASSWORD_RESET_EXPIRE", 120)
+}
+
+// PasswordResetThrottle es el tiempo mínimo entre solicitudes para un mismo email (AUTH_PASSWORD_RESET_THROTTLE, en segundos)
+func PasswordResetThrottle() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("AUTH_PASSWORD_RESET_THROTTLE"))
+	if err != nil || seconds < 0 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// CreatePasswordReset genera un token aleatorio para el email, reemplazando cualquier token anterior.
+// Devuelve el token en claro para enviarlo por correo; solo se guarda su hash.
+func CreatePasswordReset(email string) (string, error) {
 	db := config.DatabaseConnect()
 	defer db.Close()
-	_, err := db.Exec("INSERT INTO password_resets (email, token, created_at) VALUES (?, ?, ?)", email, token, time.Now().Format("2006-01-02 15:04:05"))
-	return err
+
+	// Limitar las solicitudes por email
+	var createdAtStr string
+	err := db.QueryRow("SELECT created_at FROM password_resets WHERE email = ? ORDER BY created_at DESC LIMIT 1", email).Scan(&createdAtStr)
+	if err == nil {
+		createdAt, parseErr := time.ParseInLocation("2006-01-02 15:04:05", createdAtStr, time.Local)
+		if parseErr == nil && time.Since(createdAt) < PasswordResetThrottle() {
+			return "", ErrPasswordResetThrottled
+		}
+	}
+
+	token, err := utils.GenerateResetToken()
+	if err != nil {
+		return "", err
+	}
+
+	// Un solo token activo por email
+	if _, err := db.Exec("DELETE FROM password_resets WHERE email = ?", email); err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec("INSERT INTO password_resets (email, token, created_at) VALUES (?, ?, ?)",
+		email, utils.HashToken(token), time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
 }
 
+// GetPasswordResetByToken busca un restablecimiento a partir del token en claro
 func GetPasswordResetByToken(token string) (PasswordReset, error) {
 	db := config.DatabaseConnect()
 	defer db.Close()
@@ -25,7 +75,7 @@ func GetPasswordResetByToken(token string) (PasswordReset, error) {
 	var pr PasswordReset
 	var createdAtStr string
 
-	err := db.QueryRow("SELECT email, token, created_at FROM password_resets WHERE token = ?", token).Scan(&pr.Email, &pr.Token, &createdAtStr)
+	err := db.QueryRow("SELECT email, token, created_at FROM password_resets WHERE token = ?", utils.HashToken(token)).Scan(&pr.Email, &pr.Token, &createdAtStr)
 	if err != nil {
 		return pr, err
 	}
@@ -39,9 +89,46 @@ func GetPasswordResetByToken(token string) (PasswordReset, error) {
 	return pr, nil
 }
 
-func DeletePasswordReset(token string) error {
+// Expired indica si el token superó la vigencia configurada
+func (pr PasswordReset) Expired() bool {
+	return time.Since(pr.CreatedAt) > PasswordResetExpiration()
+}
+
+// DeletePasswordResets elimina los tokens de restablecimiento de un email
+func DeletePasswordResets(email string) error {
 	db := config.DatabaseConnect()
 	defer db.Close()
-	_, err := db.Exec("DELETE FROM password_resets WHERE token = ?", token)
+	_, err := db.Exec("DELETE FROM password_resets WHERE email = ?", email)
 	return err
 }
+
+// ResetUserPassword guarda la nueva contraseña, consume los tokens de restablecimiento del email
+// y revoca los tokens OAuth del usuario. Las sesiones web dejan de ser válidas porque
+// la huella de la contraseña guardada en ellas ya no coincide.
+func ResetUserPassword(userID int, email string, passwordHash string) error {
+	if err := UpdateUserPassword(userID, passwordHash); err != nil {
+		return err
+	}
+
+	if err := DeletePasswordResets(email); err != nil {
+		return err
+	}
+
+	return RevokeAllUserTokens(int64(userID))
+}
+
+// PurgePasswordResets elimina por lotes los tokens de restablecimiento expirados hace más de olderThan
+func PurgePasswordResets(olderThan time.Duration, batchSize int) (int64, error) {
+	cutoff := time.Now().Add(-PasswordResetExpiration() - olderThan)
+	query := "DELETE FROM password_resets WHERE created_at < ? LIMIT ?"
+	return deleteInBatches(query, []any{cutoff.Format("2006-01-02 15:04:05")}, batchSize)
+}
+
+// envMinutes lee una cantidad de minutos de una variable de entorno, con valor por defecto
+func envMinutes(key string, fallback int) time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || minutes <= 0 {
+		minutes = fallback
+	}
+	return time.Duration(minutes) * time.Minute
+}
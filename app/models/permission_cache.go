@@ -0,0 +1,201 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"semita/app/structs"
+	"semita/app/utils"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// permissionCacheKey es la clave de cache_versions que comparten las instancias
+const permissionCacheKey = "permissions"
+
+// permissionCacheVersionCheck es cada cuánto se consulta la versión compartida con PERMISSION_CACHE_DRIVER=database
+const permissionCacheVersionCheck = 5 * time.Second
+
+// userAuthorization son los roles y los permisos (directos y heredados) resueltos de un usuario
+type userAuthorization struct {
+	roles       []structs.RoleStruct
+	permissions []structs.PermissionStruct
+	changesAt   time.Time // Próximo inicio o vencimiento de una asignación; cero si no hay
+	expiresAt   time.Time
+}
+
+// authorizationKey identifica los roles y permisos de un usuario dentro de un equipo; teamID 0 son
+// solo las asignaciones globales
+type authorizationKey struct {
+	userID int
+	teamID int
+}
+
+// permissionCache guarda en memoria los roles y permisos de cada usuario y equipo. generation cambia
+// con cada invalidación para no guardar un resultado que se consultó antes de ella.
+var permissionCache = struct {
+	mutex      sync.Mutex
+	users      map[authorizationKey]*userAuthorization
+	generation uint64
+	version    int64
+	checkedAt  time.Time
+}{users: map[authorizationKey]*userAuthorization{}}
+
+// PermissionCacheTTL es la vigencia de los roles y permisos en caché (PERMISSION_CACHE_TTL, en segundos).
+// Con 0 la caché queda desactivada.
+func PermissionCacheTTL() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("PERMISSION_CACHE_TTL"))
+	if err != nil || seconds < 0 {
+		seconds = 3600
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// PermissionCacheShared indica si las invalidaciones se comparten entre instancias con la tabla cache_versions
+// (PERMISSION_CACHE_DRIVER=database); con memory, el valor por defecto, solo afectan a la instancia actual
+func PermissionCacheShared() bool {
+	return os.Getenv("PERMISSION_CACHE_DRIVER") == "database"
+}
+
+// GetUserRolesAndPermissions obtiene los roles y todos los permisos de un usuario con una sola
+// lectura de la caché de permisos. Con teamID distinto de 0 incluye, además de las asignaciones
+// globales, las de ese equipo.
+func GetUserRolesAndPermissions(userID int, teamID int) ([]structs.RoleStruct, []structs.PermissionStruct, error) {
+	authorization, err := cachedUserAuthorization(userID, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return slices.Clone(authorization.roles), slices.Clone(authorization.permissions), nil
+}
+
+// cachedUserAuthorization devuelve los roles y permisos del usuario en el equipo, consultándolos solo
+// si no están en caché
+func cachedUserAuthorization(userID int, teamID int) (*userAuthorization, error) {
+	ttl := PermissionCacheTTL()
+	if ttl == 0 {
+		return queryUserAuthorization(userID, teamID)
+	}
+
+	syncPermissionCacheVersion()
+
+	key := authorizationKey{userID: userID, teamID: teamID}
+	permissionCache.mutex.Lock()
+	cached, ok := permissionCache.users[key]
+	generation := permissionCache.generation
+	permissionCache.mutex.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	authorization, err := queryUserAuthorization(userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	authorization.expiresAt = time.Now().Add(ttl)
+	if !authorization.changesAt.IsZero() && authorization.changesAt.Before(authorization.expiresAt) {
+		authorization.expiresAt = authorization.changesAt
+	}
+
+	permissionCache.mutex.Lock()
+	if permissionCache.generation == generation {
+		permissionCache.users[key] = authorization
+	}
+	permissionCache.mutex.Unlock()
+
+	return authorization, nil
+}
+
+func queryUserAuthorization(userID int, teamID int) (*userAuthorization, error) {
+	roles, err := queryUserRoles(userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := queryUserAllPermissions(userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	changesAt, err := nextGrantChange(userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return &userAuthorization{roles: roles, permissions: permissions, changesAt: changesAt}, nil
+}
+
+// syncPermissionCacheVersion vacía la caché local si otra instancia la invalidó desde la última consulta
+func syncPermissionCacheVersion() {
+	if !PermissionCacheShared() {
+		return
+	}
+
+	permissionCache.mutex.Lock()
+	if time.Since(permissionCache.checkedAt) < permissionCacheVersionCheck {
+		permissionCache.mutex.Unlock()
+		return
+	}
+	permissionCache.checkedAt = time.Now()
+	permissionCache.mutex.Unlock()
+
+	version, err := GetCacheVersion(permissionCacheKey)
+	if err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("No se pudo consultar la versión de la caché de permisos: %v", err))
+		return
+	}
+
+	permissionCache.mutex.Lock()
+	defer permissionCache.mutex.Unlock()
+	if version != permissionCache.version {
+		permissionCache.users = map[authorizationKey]*userAuthorization{}
+		permissionCache.generation++
+		permissionCache.version = version
+	}
+}
+
+// ForgetUserPermissions descarta los roles y permisos en caché de un usuario, en todos sus equipos
+func ForgetUserPermissions(userID int) {
+	permissionCache.mutex.Lock()
+	for key := range permissionCache.users {
+		if key.userID == userID {
+			delete(permissionCache.users, key)
+		}
+	}
+	permissionCache.generation++
+	permissionCache.mutex.Unlock()
+
+	publishPermissionCacheVersion()
+}
+
+// FlushPermissionCache descarta los roles y permisos en caché de todos los usuarios. Se llama al
+// modificar roles o permisos, que pueden afectar a cualquier usuario.
+func FlushPermissionCache() {
+	permissionCache.mutex.Lock()
+	permissionCache.users = map[authorizationKey]*userAuthorization{}
+	permissionCache.generation++
+	permissionCache.mutex.Unlock()
+
+	publishPermissionCacheVersion()
+}
+
+// publishPermissionCacheVersion avisa de la invalidación a las demás instancias
+func publishPermissionCacheVersion() {
+	if !PermissionCacheShared() {
+		return
+	}
+	if err := IncrementCacheVersion(permissionCacheKey); err != nil {
+		utils.Logs("ERROR", fmt.Sprintf("No se pudo invalidar la caché de permisos compartida: %v", err))
+	}
+}
+
+// hasRole indica si los roles resueltos incluyen el nombre en el guard
+func (a *userAuthorization) hasRole(roleName string, guardName string) bool {
+	return slices.ContainsFunc(a.roles, func(role structs.RoleStruct) bool {
+		return role.Name == roleName && role.GuardName == guardName
+	})
+}
+
+// hasPermission indica si algún permiso resuelto del guard cubre el nombre, incluidos los comodines
+func (a *userAuthorization) hasPermission(permissionName string, guardName string) bool {
+	return slices.ContainsFunc(a.permissions, func(permission structs.PermissionStruct) bool {
+		return permission.GuardName == guardName && PermissionMatches(permission.Name, permissionName)
+	})
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"semita/app/structs"
 	"semita/config"
-	"strings"
+	"slices"
 )
 
 var permissionsTable = "permissions"
@@ -110,6 +110,7 @@ func UpdatePermission(id int, permissionData structs.CreatePermissionStruct) (*s
 	if err != nil {
 		return nil, err
 	}
+	FlushPermissionCache()
 
 	return GetPermissionByID(id)
 }
@@ -120,8 +121,11 @@ func DeletePermission(id int) error {
 	defer database.Close()
 
 	query := `DELETE FROM ` + permissionsTable + ` WHERE id = ?`
-	_, err := database.Exec(query, id)
-	return err
+	if _, err := database.Exec(query, id); err != nil {
+		return err
+	}
+	FlushPermissionCache()
+	return nil
 }
 
 // GetRolePermissions obtiene todos los permisos de un rol
@@ -157,7 +161,8 @@ func GetRolePermissions(roleID int) ([]structs.PermissionStruct, error) {
 	return permissions, nil
 }
 
-// GetUserDirectPermissions obtiene los permisos directos de un usuario (no heredados de roles)
+// GetUserDirectPermissions obtiene los permisos directos globales y vigentes de un usuario (no heredados
+// de roles ni asignados en un equipo)
 func GetUserDirectPermissions(userID int) ([]structs.PermissionStruct, error) {
 	database := config.DatabaseConnect()
 	defer database.Close()
@@ -166,10 +171,11 @@ func GetUserDirectPermissions(userID int) ([]structs.PermissionStruct, error) {
 		SELECT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at 
 		FROM ` + permissionsTable + ` p
 		INNER JOIN ` + userPermissionsTable + ` up ON p.id = up.permission_id
-		WHERE up.user_id = ?
+		WHERE up.user_id = ? AND up.team_id = 0 AND ` + activeGrant("up") + `
 		ORDER BY p.name
 	`
-	rows, err := database.Query(query, userID)
+	now := grantNow()
+	rows, err := database.Query(query, userID, now, now)
 	if err != nil {
 		return nil, err
 	}
@@ -190,29 +196,46 @@ func GetUserDirectPermissions(userID int) ([]structs.PermissionStruct, error) {
 	return permissions, nil
 }
 
-// GetUserAllPermissions obtiene todos los permisos de un usuario (directos + heredados de roles)
+// GetUserAllPermissions obtiene todos los permisos de un usuario (directos + heredados de roles),
+// desde la caché de permisos si están
 func GetUserAllPermissions(userID int) ([]structs.PermissionStruct, error) {
+	authorization, err := cachedUserAuthorization(userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(authorization.permissions), nil
+}
+
+// queryUserAllPermissions consulta los permisos directos y heredados vigentes de un usuario en la base
+// de datos, globales y, con teamID distinto de 0, los asignados en el equipo
+func queryUserAllPermissions(userID int, teamID int) ([]structs.PermissionStruct, error) {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
+	// Los permisos de los roles incluyen los de sus roles padre (role_parents), a cualquier profundidad;
+	// UNION descarta los roles repetidos, así que un ciclo no hace infinita la consulta
 	query := `
-		(
-			SELECT DISTINCT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at 
-			FROM ` + permissionsTable + ` p
-			INNER JOIN ` + userPermissionsTable + ` up ON p.id = up.permission_id
-			WHERE up.user_id = ?
+		WITH RECURSIVE user_role_tree (role_id) AS (
+			SELECT ur.role_id FROM ` + userRolesTable + ` ur
+			WHERE ur.user_id = ? AND (ur.team_id = 0 OR ur.team_id = ?) AND ` + activeGrant("ur") + `
+			UNION
+			SELECT rp.parent_role_id
+			FROM ` + roleParentsTable + ` rp
+			INNER JOIN user_role_tree t ON rp.role_id = t.role_id
 		)
+		SELECT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at
+		FROM ` + permissionsTable + ` p
+		INNER JOIN ` + userPermissionsTable + ` up ON p.id = up.permission_id
+		WHERE up.user_id = ? AND (up.team_id = 0 OR up.team_id = ?) AND ` + activeGrant("up") + `
 		UNION
-		(
-			SELECT DISTINCT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at 
-			FROM ` + permissionsTable + ` p
-			INNER JOIN ` + rolePermissionsTable + ` rp ON p.id = rp.permission_id
-			INNER JOIN ` + userRolesTable + ` ur ON rp.role_id = ur.role_id
-			WHERE ur.user_id = ?
-		)
+		SELECT p.id, p.name, p.guard_name, p.description, p.created_at, p.updated_at
+		FROM ` + permissionsTable + ` p
+		INNER JOIN ` + rolePermissionsTable + ` rp ON p.id = rp.permission_id
+		INNER JOIN user_role_tree t ON rp.role_id = t.role_id
 		ORDER BY name
 	`
-	rows, err := database.Query(query, userID, userID)
+	now := grantNow()
+	rows, err := database.Query(query, userID, teamID, now, now, userID, teamID, now, now)
 	if err != nil {
 		return nil, err
 	}
@@ -248,8 +271,11 @@ func AssignPermissionToRole(roleID int, permissionID int) error {
 	}
 
 	query := `INSERT INTO ` + rolePermissionsTable + ` (role_id, permission_id) VALUES (?, ?)`
-	_, err = database.Exec(query, roleID, permissionID)
-	return err
+	if _, err = database.Exec(query, roleID, permissionID); err != nil {
+		return err
+	}
+	FlushPermissionCache()
+	return nil
 }
 
 // RevokePermissionFromRole revoca un permiso de un rol
@@ -258,37 +284,76 @@ func RevokePermissionFromRole(roleID int, permissionID int) error {
 	defer database.Close()
 
 	query := `DELETE FROM ` + rolePermissionsTable + ` WHERE role_id = ? AND permission_id = ?`
-	_, err := database.Exec(query, roleID, permissionID)
-	return err
+	if _, err := database.Exec(query, roleID, permissionID); err != nil {
+		return err
+	}
+	FlushPermissionCache()
+	return nil
 }
 
-// AssignPermissionToUser asigna un permiso directamente a un usuario
+// AssignPermissionToUser asigna un permiso global directamente a un usuario
 func AssignPermissionToUser(userID int, permissionID int) error {
-	database := config.DatabaseConnect()
-	defer database.Close()
+	return AssignPermissionToUserInTeam(userID, permissionID, 0)
+}
 
-	// Verificar si el usuario ya tiene el permiso directamente
-	exists, err := UserHasDirectPermission(userID, permissionID)
+// AssignPermissionToUserInTeam asigna un permiso directo a un usuario dentro de un equipo; con
+// teamID 0 la asignación es global
+func AssignPermissionToUserInTeam(userID int, permissionID int, teamID int) error {
+	return AssignPermissionToUserWithWindow(userID, permissionID, teamID, structs.GrantWindow{})
+}
+
+// AssignPermissionToUserWithWindow asigna un permiso directo a un usuario, en un equipo (o global, con
+// teamID 0) y con una vigencia opcional. Reemplaza una asignación del mismo permiso que todavía no
+// empezó o ya venció.
+func AssignPermissionToUserWithWindow(userID int, permissionID int, teamID int, window structs.GrantWindow) error {
+	if err := validateGrantWindow(window); err != nil {
+		return err
+	}
+	if err := requireTeamMember(teamID, userID); err != nil {
+		return err
+	}
+
+	// Verificar si el usuario ya tiene el permiso directamente y vigente
+	exists, err := UserHasDirectPermissionInTeam(userID, permissionID, teamID)
 	if err != nil {
 		return err
 	}
 	if exists {
 		return fmt.Errorf("user already has this direct permission")
 	}
+	if err = replaceInactiveGrant(permissionGrants, userID, permissionID, teamID); err != nil {
+		return err
+	}
+
+	database := config.DatabaseConnect()
+	defer database.Close()
 
-	query := `INSERT INTO ` + userPermissionsTable + ` (user_id, permission_id) VALUES (?, ?)`
-	_, err = database.Exec(query, userID, permissionID)
-	return err
+	query := `INSERT INTO ` + userPermissionsTable + ` (user_id, permission_id, team_id, starts_at, expires_at) VALUES (?, ?, ?, ?, ?)`
+	_, err = database.Exec(query, userID, permissionID, teamID, grantTimeValue(window.StartsAt), grantTimeValue(window.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	ForgetUserPermissions(userID)
+	return nil
 }
 
-// RevokePermissionFromUser revoca un permiso directo de un usuario
+// RevokePermissionFromUser revoca un permiso directo global de un usuario
 func RevokePermissionFromUser(userID int, permissionID int) error {
+	return RevokePermissionFromUserInTeam(userID, permissionID, 0)
+}
+
+// RevokePermissionFromUserInTeam revoca un permiso directo de un usuario dentro de un equipo; con
+// teamID 0, el permiso global
+func RevokePermissionFromUserInTeam(userID int, permissionID int, teamID int) error {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
-	query := `DELETE FROM ` + userPermissionsTable + ` WHERE user_id = ? AND permission_id = ?`
-	_, err := database.Exec(query, userID, permissionID)
-	return err
+	query := `DELETE FROM ` + userPermissionsTable + ` WHERE user_id = ? AND permission_id = ? AND team_id = ?`
+	if _, err := database.Exec(query, userID, permissionID, teamID); err != nil {
+		return err
+	}
+	ForgetUserPermissions(userID)
+	return nil
 }
 
 // RoleHasPermission verifica si un rol tiene un permiso específico
@@ -306,14 +371,21 @@ func RoleHasPermission(roleID int, permissionID int) (bool, error) {
 	return count > 0, nil
 }
 
-// UserHasDirectPermission verifica si un usuario tiene un permiso directo
+// UserHasDirectPermission verifica si un usuario tiene un permiso directo global
 func UserHasDirectPermission(userID int, permissionID int) (bool, error) {
+	return UserHasDirectPermissionInTeam(userID, permissionID, 0)
+}
+
+// UserHasDirectPermissionInTeam verifica si un usuario tiene el permiso directo y vigente en el equipo
+// (o global, con teamID 0)
+func UserHasDirectPermissionInTeam(userID int, permissionID int, teamID int) (bool, error) {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
-	query := `SELECT COUNT(*) FROM ` + userPermissionsTable + ` WHERE user_id = ? AND permission_id = ?`
+	query := `SELECT COUNT(*) FROM ` + userPermissionsTable + ` up WHERE up.user_id = ? AND up.permission_id = ? AND up.team_id = ? AND ` + activeGrant("up")
+	now := grantNow()
 	var count int
-	err := database.QueryRow(query, userID, permissionID).Scan(&count)
+	err := database.QueryRow(query, userID, permissionID, teamID, now, now).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -323,38 +395,15 @@ func UserHasDirectPermission(userID int, permissionID int) (bool, error) {
 
 // UserHasPermission verifica si un usuario tiene un permiso (directo o heredado)
 func UserHasPermission(userID int, permissionName string, guardName string) (bool, error) {
-	database := config.DatabaseConnect()
-	defer database.Close()
-
 	if guardName == "" {
 		guardName = "web"
 	}
 
-	query := `
-		SELECT COUNT(*) FROM (
-			(
-				SELECT 1 
-				FROM ` + userPermissionsTable + ` up
-				INNER JOIN ` + permissionsTable + ` p ON up.permission_id = p.id
-				WHERE up.user_id = ? AND p.name = ? AND p.guard_name = ?
-			)
-			UNION
-			(
-				SELECT 1 
-				FROM ` + userRolesTable + ` ur
-				INNER JOIN ` + rolePermissionsTable + ` rp ON ur.role_id = rp.role_id
-				INNER JOIN ` + permissionsTable + ` p ON rp.permission_id = p.id
-				WHERE ur.user_id = ? AND p.name = ? AND p.guard_name = ?
-			)
-		) AS combined_permissions
-	`
-	var count int
-	err := database.QueryRow(query, userID, permissionName, guardName, userID, permissionName, guardName).Scan(&count)
+	authorization, err := cachedUserAuthorization(userID, 0)
 	if err != nil {
 		return false, err
 	}
-
-	return count > 0, nil
+	return authorization.hasPermission(permissionName, guardName), nil
 }
 
 // UserHasAnyPermission verifica si un usuario tiene al menos uno de los permisos especificados
@@ -363,53 +412,20 @@ func UserHasAnyPermission(userID int, permissionNames []string, guardName string
 		return false, nil
 	}
 
-	database := config.DatabaseConnect()
-	defer database.Close()
-
 	if guardName == "" {
 		guardName = "web"
 	}
 
-	placeholders := strings.Repeat("?,", len(permissionNames))
-	placeholders = placeholders[:len(placeholders)-1] // Remover la última coma
-
-	query := fmt.Sprintf(`
-		SELECT COUNT(*) FROM (
-			(
-				SELECT 1 
-				FROM %s up
-				INNER JOIN %s p ON up.permission_id = p.id
-				WHERE up.user_id = ? AND p.name IN (%s) AND p.guard_name = ?
-			)
-			UNION
-			(
-				SELECT 1 
-				FROM %s ur
-				INNER JOIN %s rp ON ur.role_id = rp.role_id
-				INNER JOIN %s p ON rp.permission_id = p.id
-				WHERE ur.user_id = ? AND p.name IN (%s) AND p.guard_name = ?
-			)
-		) AS combined_permissions
-	`, userPermissionsTable, permissionsTable, placeholders, userRolesTable, rolePermissionsTable, permissionsTable, placeholders)
-
-	args := make([]interface{}, 0, len(permissionNames)*2+4)
-	args = append(args, userID)
-	for _, name := range permissionNames {
-		args = append(args, name)
-	}
-	args = append(args, guardName, userID)
-	for _, name := range permissionNames {
-		args = append(args, name)
-	}
-	args = append(args, guardName)
-
-	var count int
-	err := database.QueryRow(query, args...).Scan(&count)
+	authorization, err := cachedUserAuthorization(userID, 0)
 	if err != nil {
 		return false, err
 	}
-
-	return count > 0, nil
+	for _, permissionName := range permissionNames {
+		if authorization.hasPermission(permissionName, guardName) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // UserHasAllPermissions verifica si un usuario tiene todos los permisos especificados
@@ -0,0 +1,37 @@
+package models
+
+import "strings"
+
+// PermissionWildcard es el segmento que cubre cualquier valor en un nombre de permiso
+const PermissionWildcard = "*"
+
+// PermissionMatches indica si un permiso concedido cubre al pedido. Los nombres se comparan por
+// segmentos separados por punto: "*" cubre un segmento y, al final, todos los que sigan. Así
+// "users.*" cubre "users.create", "*.view" cubre "posts.view" y "*" cubre cualquier permiso.
+// Los nombres sin punto, como "create-users", solo coinciden consigo mismos o con "*".
+func PermissionMatches(granted string, requested string) bool {
+	if granted == requested || granted == PermissionWildcard {
+		return true
+	}
+	if !strings.Contains(granted, PermissionWildcard) {
+		return false
+	}
+
+	grantedSegments := strings.Split(granted, ".")
+	requestedSegments := strings.Split(requested, ".")
+	for i, segment := range grantedSegments {
+		if i >= len(requestedSegments) {
+			return false
+		}
+		if segment == PermissionWildcard {
+			if i == len(grantedSegments)-1 {
+				return true
+			}
+			continue
+		}
+		if segment != requestedSegments[i] {
+			return false
+		}
+	}
+	return len(grantedSegments) == len(requestedSegments)
+}
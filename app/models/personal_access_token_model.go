@@ -0,0 +1,159 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"semita/app/utils"
+	"semita/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PersonalAccessGrant es el grant que identifica al cliente de tokens de acceso personal
+const PersonalAccessGrant = "personal_access"
+
+// ErrNoPersonalAccessClient se devuelve cuando aún no se ha creado el cliente de acceso personal
+var ErrNoPersonalAccessClient = errors.New("no existe un cliente de acceso personal, ejecuta oauth:client --personal")
+
+// GetPersonalAccessClient obtiene el cliente OAuth dedicado a los tokens de acceso personal
+func GetPersonalAccessClient() (*OAuthClient, error) {
+	client, err := GetClientByGrantType(PersonalAccessGrant)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, ErrNoPersonalAccessClient
+	}
+
+	return client, nil
+}
+
+// CreatePersonalAccessClient crea el cliente OAuth que emite los tokens de acceso personal
+func CreatePersonalAccessClient(name string) (*OAuthClient, error) {
+	return CreateClient(name, "", PersonalAccessGrant, "*")
+}
+
+// personalAccessTokenLifetime devuelve la vigencia por defecto de un token personal
+func personalAccessTokenLifetime() time.Duration {
+	seconds, err := strconv.ParseInt(os.Getenv("OAUTH_PERSONAL_ACCESS_TOKEN_LIFETIME"), 10, 64)
+	if err != nil || seconds <= 0 {
+		seconds = 31536000 // 1 año por defecto
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// CreatePersonalAccessToken crea un token de acceso personal con nombre, scopes y expiración opcional
+func CreatePersonalAccessToken(userID int64, name string, scopes []string, expiresAt *time.Time) (*OAuthToken, error) {
+	client, err := GetPersonalAccessClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateRequestedScopes(client, scopes); err != nil {
+		return nil, err
+	}
+
+	expiration := time.Now().Add(personalAccessTokenLifetime())
+	if expiresAt != nil {
+		if !expiresAt.After(time.Now()) {
+			return nil, errors.New("la fecha de expiración debe ser futura")
+		}
+		expiration = *expiresAt
+	}
+
+	tokenID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateJWTTokenWithExpiration(userID, client.ClientID, tokenID, scopes, expiration)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los tokens personales no se renuevan, el refresh_token es un valor opaco que nunca se entrega
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := `INSERT INTO ` + oauthTokenTable + `
+              (user_id, client_id, name, access_token, refresh_token, scopes, revoked, expires_at)
+              VALUES (?, ?, ?, ?, ?, ?, 0, ?)`
+
+	result, err := db.Exec(query, userID, client.ID, name, accessToken, refreshToken, strings.Join(scopes, ","), expiration.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return getTokenByID(db, id)
+}
+
+// GetPersonalAccessTokens obtiene los tokens de acceso personal activos de un usuario
+func GetPersonalAccessTokens(userID int64) ([]OAuthToken, error) {
+	client, err := GetPersonalAccessClient()
+	if err != nil {
+		return nil, err
+	}
+
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := `SELECT ` + oauthTokenColumns + `
+              FROM ` + oauthTokenTable + `
+              WHERE user_id = ? AND client_id = ? AND revoked = 0
+              ORDER BY created_at DESC`
+
+	rows, err := db.Query(query, userID, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []OAuthToken
+	for rows.Next() {
+		token, err := scanOAuthToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RevokePersonalAccessToken revoca un token de acceso personal que pertenezca al usuario
+func RevokePersonalAccessToken(userID int64, tokenID int64) error {
+	client, err := GetPersonalAccessClient()
+	if err != nil {
+		return err
+	}
+
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE "+oauthTokenTable+" SET revoked = 1 WHERE id = ? AND user_id = ? AND client_id = ? AND revoked = 0",
+		tokenID, userID, client.ID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("token no encontrado")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"semita/config"
+	"time"
+)
+
+// HitRateLimit suma un intento a la clave y devuelve el total y el fin de la ventana.
+// Si la ventana anterior ya venció, el contador empieza de nuevo con la duración decay.
+func HitRateLimit(key string, decay time.Duration) (int, time.Time, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	now := time.Now().Unix()
+	resetAt := time.Now().Add(decay).Unix()
+
+	// MySQL evalúa las asignaciones en orden, por eso reset_at se compara antes de actualizarse
+	query := `INSERT INTO rate_limits (throttle_key, attempts, reset_at) VALUES (?, 1, ?)
+		ON DUPLICATE KEY UPDATE
+			attempts = IF(reset_at <= ?, 1, attempts + 1),
+			reset_at = IF(reset_at <= ?, VALUES(reset_at), reset_at)`
+	if _, err := db.Exec(query, key, resetAt, now, now); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var attempts int
+	var storedResetAt int64
+	err := db.QueryRow("SELECT attempts, reset_at FROM rate_limits WHERE throttle_key = ?", key).Scan(&attempts, &storedResetAt)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return attempts, time.Unix(storedResetAt, 0), nil
+}
+
+// GetRateLimit devuelve los intentos vigentes de la clave; 0 si no hay una ventana activa
+func GetRateLimit(key string) (int, time.Time, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var attempts int
+	var resetAt int64
+	err := db.QueryRow("SELECT attempts, reset_at FROM rate_limits WHERE throttle_key = ? AND reset_at > ?", key, time.Now().Unix()).Scan(&attempts, &resetAt)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return attempts, time.Unix(resetAt, 0), nil
+}
+
+// DeleteRateLimit reinicia el contador de la clave
+func DeleteRateLimit(key string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+	_, err := db.Exec("DELETE FROM rate_limits WHERE throttle_key = ?", key)
+	return err
+}
+
+// PurgeRateLimits elimina por lotes los contadores cuya ventana ya venció
+func PurgeRateLimits(batchSize int) (int64, error) {
+	return deleteInBatches("DELETE FROM rate_limits WHERE reset_at <= ? LIMIT ?", []any{time.Now().Unix()}, batchSize)
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"semita/app/structs"
 	"semita/config"
-	"strings"
+	"slices"
 )
 
 var rolesTable = "roles"
@@ -16,7 +16,7 @@ func GetAllRoles() ([]structs.RoleStruct, error) {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
-	query := `SELECT id, name, guard_name, description, created_at, updated_at FROM ` + rolesTable + ` ORDER BY name`
+	query := `SELECT id, name, guard_name, description, requires_two_factor, created_at, updated_at FROM ` + rolesTable + ` ORDER BY name`
 	rows, err := database.Query(query)
 	if err != nil {
 		return nil, err
@@ -27,7 +27,7 @@ func GetAllRoles() ([]structs.RoleStruct, error) {
 	for rows.Next() {
 		var role structs.RoleStruct
 		var description sql.NullString
-		err = rows.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.CreatedAt, &role.UpdatedAt)
+		err = rows.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.RequiresTwoFactor, &role.CreatedAt, &role.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -43,12 +43,12 @@ func GetRoleByID(id int) (*structs.RoleStruct, error) {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
-	query := `SELECT id, name, guard_name, description, created_at, updated_at FROM ` + rolesTable + ` WHERE id = ?`
+	query := `SELECT id, name, guard_name, description, requires_two_factor, created_at, updated_at FROM ` + rolesTable + ` WHERE id = ?`
 	row := database.QueryRow(query, id)
 
 	var role structs.RoleStruct
 	var description sql.NullString
-	err := row.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.CreatedAt, &role.UpdatedAt)
+	err := row.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.RequiresTwoFactor, &role.CreatedAt, &role.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -62,12 +62,12 @@ func GetRoleByName(name string, guardName string) (*structs.RoleStruct, error) {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
-	query := `SELECT id, name, guard_name, description, created_at, updated_at FROM ` + rolesTable + ` WHERE name = ? AND guard_name = ?`
+	query := `SELECT id, name, guard_name, description, requires_two_factor, created_at, updated_at FROM ` + rolesTable + ` WHERE name = ? AND guard_name = ?`
 	row := database.QueryRow(query, name, guardName)
 
 	var role structs.RoleStruct
 	var description sql.NullString
-	err := row.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.CreatedAt, &role.UpdatedAt)
+	err := row.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.RequiresTwoFactor, &role.CreatedAt, &role.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +85,8 @@ func CreateRole(roleData structs.CreateRoleStruct) (*structs.RoleStruct, error)
 		roleData.GuardName = "web"
 	}
 
-	query := `INSERT INTO ` + rolesTable + ` (name, guard_name, description) VALUES (?, ?, ?)`
-	result, err := database.Exec(query, roleData.Name, roleData.GuardName, roleData.Description)
+	query := `INSERT INTO ` + rolesTable + ` (name, guard_name, description, requires_two_factor) VALUES (?, ?, ?, ?)`
+	result, err := database.Exec(query, roleData.Name, roleData.GuardName, roleData.Description, roleData.RequiresTwoFactor)
 	if err != nil {
 		return nil, err
 	}
@@ -104,11 +104,12 @@ func UpdateRole(id int, roleData structs.CreateRoleStruct) (*structs.RoleStruct,
 	database := config.DatabaseConnect()
 	defer database.Close()
 
-	query := `UPDATE ` + rolesTable + ` SET name = ?, description = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
-	_, err := database.Exec(query, roleData.Name, roleData.Description, id)
+	query := `UPDATE ` + rolesTable + ` SET name = ?, description = ?, requires_two_factor = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := database.Exec(query, roleData.Name, roleData.Description, roleData.RequiresTwoFactor, id)
 	if err != nil {
 		return nil, err
 	}
+	FlushPermissionCache()
 
 	return GetRoleByID(id)
 }
@@ -119,33 +120,74 @@ func DeleteRole(id int) error {
 	defer database.Close()
 
 	query := `DELETE FROM ` + rolesTable + ` WHERE id = ?`
-	_, err := database.Exec(query, id)
-	return err
+	if _, err := database.Exec(query, id); err != nil {
+		return err
+	}
+	FlushPermissionCache()
+	return nil
 }
 
-// GetUserRoles obtiene todos los roles de un usuario
+// GetUserRoles obtiene todos los roles de un usuario, desde la caché de permisos si están
 func GetUserRoles(userID int) ([]structs.RoleStruct, error) {
+	authorization, err := cachedUserAuthorization(userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(authorization.roles), nil
+}
+
+// GetUserTeamRoles obtiene los roles vigentes asignados a un usuario solo dentro de un equipo, sin los globales
+func GetUserTeamRoles(userID int, teamID int) ([]structs.RoleStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `
+		SELECT r.id, r.name, r.guard_name, r.description, r.requires_two_factor, r.created_at, r.updated_at
+		FROM ` + rolesTable + ` r
+		INNER JOIN ` + userRolesTable + ` ur ON r.id = ur.role_id
+		WHERE ur.user_id = ? AND ur.team_id = ? AND ` + activeGrant("ur") + `
+		ORDER BY r.name
+	`
+	now := grantNow()
+	rows, err := database.Query(query, userID, teamID, now, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// queryUserRoles consulta los roles vigentes de un usuario: los globales y, con teamID distinto de 0,
+// los del equipo
+func queryUserRoles(userID int, teamID int) ([]structs.RoleStruct, error) {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
 	query := `
-		SELECT r.id, r.name, r.guard_name, r.description, r.created_at, r.updated_at 
+		SELECT r.id, r.name, r.guard_name, r.description, r.requires_two_factor, r.created_at, r.updated_at
 		FROM ` + rolesTable + ` r
 		INNER JOIN ` + userRolesTable + ` ur ON r.id = ur.role_id
-		WHERE ur.user_id = ?
+		WHERE ur.user_id = ? AND (ur.team_id = 0 OR ur.team_id = ?) AND ` + activeGrant("ur") + `
 		ORDER BY r.name
 	`
-	rows, err := database.Query(query, userID)
+	now := grantNow()
+	rows, err := database.Query(query, userID, teamID, now, now)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanRoles(rows)
+}
+
+// scanRoles lee las filas de una consulta de roles
+func scanRoles(rows *sql.Rows) ([]structs.RoleStruct, error) {
 	var roles []structs.RoleStruct
 	for rows.Next() {
 		var role structs.RoleStruct
 		var description sql.NullString
-		err = rows.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.CreatedAt, &role.UpdatedAt)
+		err := rows.Scan(&role.ID, &role.Name, &role.GuardName, &description, &role.RequiresTwoFactor, &role.CreatedAt, &role.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -156,43 +198,83 @@ func GetUserRoles(userID int) ([]structs.RoleStruct, error) {
 	return roles, nil
 }
 
-// AssignRoleToUser asigna un rol a un usuario
+// AssignRoleToUser asigna un rol global a un usuario
 func AssignRoleToUser(userID int, roleID int) error {
-	database := config.DatabaseConnect()
-	defer database.Close()
+	return AssignRoleToUserInTeam(userID, roleID, 0)
+}
+
+// AssignRoleToUserInTeam asigna un rol a un usuario dentro de un equipo; con teamID 0 la asignación es global
+func AssignRoleToUserInTeam(userID int, roleID int, teamID int) error {
+	return AssignRoleToUserWithWindow(userID, roleID, teamID, structs.GrantWindow{})
+}
+
+// AssignRoleToUserWithWindow asigna un rol a un usuario, en un equipo (o global, con teamID 0) y con
+// una vigencia opcional. Reemplaza una asignación del mismo rol que todavía no empezó o ya venció.
+func AssignRoleToUserWithWindow(userID int, roleID int, teamID int, window structs.GrantWindow) error {
+	if err := validateGrantWindow(window); err != nil {
+		return err
+	}
+	if err := requireTeamMember(teamID, userID); err != nil {
+		return err
+	}
 
-	// Verificar si el usuario ya tiene el rol
-	exists, err := UserHasRole(userID, roleID)
+	// Verificar si el usuario ya tiene el rol vigente
+	exists, err := UserHasRoleInTeam(userID, roleID, teamID)
 	if err != nil {
 		return err
 	}
 	if exists {
 		return fmt.Errorf("user already has this role")
 	}
+	if err = replaceInactiveGrant(roleGrants, userID, roleID, teamID); err != nil {
+		return err
+	}
 
-	query := `INSERT INTO ` + userRolesTable + ` (user_id, role_id) VALUES (?, ?)`
-	_, err = database.Exec(query, userID, roleID)
-	return err
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `INSERT INTO ` + userRolesTable + ` (user_id, role_id, team_id, starts_at, expires_at) VALUES (?, ?, ?, ?, ?)`
+	_, err = database.Exec(query, userID, roleID, teamID, grantTimeValue(window.StartsAt), grantTimeValue(window.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	ForgetUserPermissions(userID)
+	return nil
 }
 
-// RevokeRoleFromUser revoca un rol de un usuario
+// RevokeRoleFromUser revoca un rol global de un usuario
 func RevokeRoleFromUser(userID int, roleID int) error {
+	return RevokeRoleFromUserInTeam(userID, roleID, 0)
+}
+
+// RevokeRoleFromUserInTeam revoca un rol de un usuario dentro de un equipo; con teamID 0, el rol global.
+// También descarta la asignación que todavía no empezó.
+func RevokeRoleFromUserInTeam(userID int, roleID int, teamID int) error {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
-	query := `DELETE FROM ` + userRolesTable + ` WHERE user_id = ? AND role_id = ?`
-	_, err := database.Exec(query, userID, roleID)
-	return err
+	query := `DELETE FROM ` + userRolesTable + ` WHERE user_id = ? AND role_id = ? AND team_id = ?`
+	if _, err := database.Exec(query, userID, roleID, teamID); err != nil {
+		return err
+	}
+	ForgetUserPermissions(userID)
+	return nil
 }
 
-// UserHasRole verifica si un usuario tiene un rol específico
+// UserHasRole verifica si un usuario tiene un rol global específico
 func UserHasRole(userID int, roleID int) (bool, error) {
+	return UserHasRoleInTeam(userID, roleID, 0)
+}
+
+// UserHasRoleInTeam verifica si un usuario tiene el rol asignado y vigente en el equipo (o global, con teamID 0)
+func UserHasRoleInTeam(userID int, roleID int, teamID int) (bool, error) {
 	database := config.DatabaseConnect()
 	defer database.Close()
 
-	query := `SELECT COUNT(*) FROM ` + userRolesTable + ` WHERE user_id = ? AND role_id = ?`
+	query := `SELECT COUNT(*) FROM ` + userRolesTable + ` ur WHERE ur.user_id = ? AND ur.role_id = ? AND ur.team_id = ? AND ` + activeGrant("ur")
+	now := grantNow()
 	var count int
-	err := database.QueryRow(query, userID, roleID).Scan(&count)
+	err := database.QueryRow(query, userID, roleID, teamID, now, now).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -202,26 +284,15 @@ func UserHasRole(userID int, roleID int) (bool, error) {
 
 // UserHasRoleByName verifica si un usuario tiene un rol por nombre
 func UserHasRoleByName(userID int, roleName string, guardName string) (bool, error) {
-	database := config.DatabaseConnect()
-	defer database.Close()
-
 	if guardName == "" {
 		guardName = "web"
 	}
 
-	query := `
-		SELECT COUNT(*) 
-		FROM ` + userRolesTable + ` ur
-		INNER JOIN ` + rolesTable + ` r ON ur.role_id = r.id
-		WHERE ur.user_id = ? AND r.name = ? AND r.guard_name = ?
-	`
-	var count int
-	err := database.QueryRow(query, userID, roleName, guardName).Scan(&count)
+	authorization, err := cachedUserAuthorization(userID, 0)
 	if err != nil {
 		return false, err
 	}
-
-	return count > 0, nil
+	return authorization.hasRole(roleName, guardName), nil
 }
 
 // UserHasAnyRole verifica si un usuario tiene al menos uno de los roles especificados
@@ -230,37 +301,20 @@ func UserHasAnyRole(userID int, roleNames []string, guardName string) (bool, err
 		return false, nil
 	}
 
-	database := config.DatabaseConnect()
-	defer database.Close()
-
 	if guardName == "" {
 		guardName = "web"
 	}
 
-	placeholders := strings.Repeat("?,", len(roleNames))
-	placeholders = placeholders[:len(placeholders)-1] // Remover la última coma
-
-	query := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s ur
-		INNER JOIN %s r ON ur.role_id = r.id
-		WHERE ur.user_id = ? AND r.name IN (%s) AND r.guard_name = ?
-	`, userRolesTable, rolesTable, placeholders)
-
-	args := make([]interface{}, 0, len(roleNames)+2)
-	args = append(args, userID)
-	for _, name := range roleNames {
-		args = append(args, name)
-	}
-	args = append(args, guardName)
-
-	var count int
-	err := database.QueryRow(query, args...).Scan(&count)
+	authorization, err := cachedUserAuthorization(userID, 0)
 	if err != nil {
 		return false, err
 	}
-
-	return count > 0, nil
+	for _, roleName := range roleNames {
+		if authorization.hasRole(roleName, guardName) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // UserHasAllRoles verifica si un usuario tiene todos los roles especificados
@@ -269,35 +323,29 @@ func UserHasAllRoles(userID int, roleNames []string, guardName string) (bool, er
 		return true, nil
 	}
 
-	database := config.DatabaseConnect()
-	defer database.Close()
-
 	if guardName == "" {
 		guardName = "web"
 	}
 
-	placeholders := strings.Repeat("?,", len(roleNames))
-	placeholders = placeholders[:len(placeholders)-1] // Remover la última coma
-
-	query := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s ur
-		INNER JOIN %s r ON ur.role_id = r.id
-		WHERE ur.user_id = ? AND r.name IN (%s) AND r.guard_name = ?
-	`, userRolesTable, rolesTable, placeholders)
-
-	args := make([]interface{}, 0, len(roleNames)+2)
-	args = append(args, userID)
-	for _, name := range roleNames {
-		args = append(args, name)
+	authorization, err := cachedUserAuthorization(userID, 0)
+	if err != nil {
+		return false, err
 	}
-	args = append(args, guardName)
+	for _, roleName := range roleNames {
+		if !authorization.hasRole(roleName, guardName) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
 
-	var count int
-	err := database.QueryRow(query, args...).Scan(&count)
+// UserRequiresTwoFactor indica si alguno de los roles del usuario exige autenticación en dos pasos
+func UserRequiresTwoFactor(userID int) (bool, error) {
+	authorization, err := cachedUserAuthorization(userID, 0)
 	if err != nil {
 		return false, err
 	}
-
-	return count == len(roleNames), nil
+	return slices.ContainsFunc(authorization.roles, func(role structs.RoleStruct) bool {
+		return role.RequiresTwoFactor
+	}), nil
 }
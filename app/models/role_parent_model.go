@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"semita/app/structs"
+	"semita/config"
+	"slices"
+)
+
+var roleParentsTable = "role_parents"
+
+// ErrRoleParentCycle indica que la herencia pedida haría que un rol herede de sí mismo
+var ErrRoleParentCycle = errors.New("role inheritance would create a cycle")
+
+// GetRoleParents obtiene los roles de los que hereda directamente un rol
+func GetRoleParents(roleID int) ([]structs.RoleStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `
+		SELECT r.id, r.name, r.guard_name, r.description, r.requires_two_factor, r.created_at, r.updated_at
+		FROM ` + rolesTable + ` r
+		INNER JOIN ` + roleParentsTable + ` rp ON r.id = rp.parent_role_id
+		WHERE rp.role_id = ?
+		ORDER BY r.name
+	`
+	rows, err := database.Query(query, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// GetRoleAncestorIDs obtiene los IDs de todos los roles de los que hereda un rol, directa o indirectamente
+func GetRoleAncestorIDs(roleID int) ([]int, error) {
+	parents, err := queryRoleParentEdges()
+	if err != nil {
+		return nil, err
+	}
+	return roleAncestors(parents, roleID), nil
+}
+
+// AddRoleParent hace que un rol herede los permisos de otro del mismo guard. Devuelve
+// ErrRoleParentCycle si el padre ya hereda, directa o indirectamente, del rol.
+func AddRoleParent(roleID int, parentRoleID int) error {
+	if roleID == parentRoleID {
+		return ErrRoleParentCycle
+	}
+
+	role, err := GetRoleByID(roleID)
+	if err != nil {
+		return err
+	}
+	parent, err := GetRoleByID(parentRoleID)
+	if err != nil {
+		return err
+	}
+	if role.GuardName != parent.GuardName {
+		return fmt.Errorf("parent role must belong to the %s guard", role.GuardName)
+	}
+
+	parents, err := queryRoleParentEdges()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(parents[roleID], parentRoleID) {
+		return fmt.Errorf("role already inherits from this role")
+	}
+	if slices.Contains(roleAncestors(parents, parentRoleID), roleID) {
+		return ErrRoleParentCycle
+	}
+
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `INSERT INTO ` + roleParentsTable + ` (role_id, parent_role_id) VALUES (?, ?)`
+	if _, err = database.Exec(query, roleID, parentRoleID); err != nil {
+		return err
+	}
+	FlushPermissionCache()
+	return nil
+}
+
+// RemoveRoleParent quita la herencia entre dos roles
+func RemoveRoleParent(roleID int, parentRoleID int) error {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `DELETE FROM ` + roleParentsTable + ` WHERE role_id = ? AND parent_role_id = ?`
+	if _, err := database.Exec(query, roleID, parentRoleID); err != nil {
+		return err
+	}
+	FlushPermissionCache()
+	return nil
+}
+
+// queryRoleParentEdges consulta toda la tabla de herencia: los padres directos de cada rol
+func queryRoleParentEdges() (map[int][]int, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	rows, err := database.Query(`SELECT role_id, parent_role_id FROM ` + roleParentsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parents := map[int][]int{}
+	for rows.Next() {
+		var roleID, parentRoleID int
+		if err = rows.Scan(&roleID, &parentRoleID); err != nil {
+			return nil, err
+		}
+		parents[roleID] = append(parents[roleID], parentRoleID)
+	}
+
+	return parents, rows.Err()
+}
+
+// roleAncestors recorre la herencia desde el rol; tolera ciclos creados fuera de AddRoleParent
+func roleAncestors(parents map[int][]int, roleID int) []int {
+	var ancestors []int
+	visited := map[int]bool{roleID: true}
+	pending := slices.Clone(parents[roleID])
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		ancestors = append(ancestors, current)
+		pending = append(pending, parents[current]...)
+	}
+	return ancestors
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"semita/config"
+	"time"
+)
+
+// SecurityEvent es un evento relevante para la seguridad de una cuenta (bloqueos, accesos sospechosos, etc.)
+type SecurityEvent struct {
+	ID        int64
+	UserID    int64 // 0 si el evento no está asociado a un usuario
+	Event     string
+	Email     string
+	IPAddress string
+	UserAgent string
+	Details   string
+	CreatedAt time.Time
+}
+
+// CreateSecurityEvent registra un evento de seguridad
+func CreateSecurityEvent(event SecurityEvent) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var userID any
+	if event.UserID > 0 {
+		userID = event.UserID
+	}
+
+	query := `INSERT INTO security_events (user_id, event, email, ip_address, user_agent, details, created_at)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)`
+	_, err := db.Exec(query, userID, event.Event, event.Email, event.IPAddress, event.UserAgent, event.Details,
+		time.Now().Format("2006-01-02 15:04:05"))
+	return err
+}
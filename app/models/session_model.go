@@ -0,0 +1,115 @@
+package models
+
+import (
+	"database/sql"
+	"semita/config"
+	"time"
+)
+
+// Session es una sesión web guardada por el driver de base de datos
+type Session struct {
+	ID           string
+	UserID       int64 // 0 si la sesión no pertenece a un usuario autenticado
+	IPAddress    string
+	UserAgent    string
+	Payload      string
+	LastActivity time.Time
+}
+
+// GetSession obtiene una sesión por su ID
+func GetSession(id string) (Session, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var session Session
+	var userID sql.NullInt64
+	var lastActivity int64
+
+	query := "SELECT id, user_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), payload, last_activity FROM sessions WHERE id = ?"
+	err := db.QueryRow(query, id).Scan(&session.ID, &userID, &session.IPAddress, &session.UserAgent, &session.Payload, &lastActivity)
+	if err != nil {
+		return session, err
+	}
+
+	session.UserID = userID.Int64
+	session.LastActivity = time.Unix(lastActivity, 0)
+	return session, nil
+}
+
+// SaveSession crea o actualiza una sesión
+func SaveSession(session Session) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var userID any
+	if session.UserID > 0 {
+		userID = session.UserID
+	}
+
+	query := `INSERT INTO sessions (id, user_id, ip_address, user_agent, payload, last_activity)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE user_id = VALUES(user_id), ip_address = VALUES(ip_address),
+			user_agent = VALUES(user_agent), payload = VALUES(payload), last_activity = VALUES(last_activity)`
+	_, err := db.Exec(query, session.ID, userID, session.IPAddress, session.UserAgent, session.Payload, session.LastActivity.Unix())
+	return err
+}
+
+// DeleteSession elimina una sesión por su ID
+func DeleteSession(id string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+	_, err := db.Exec("DELETE FROM sessions WHERE id = ?", id)
+	return err
+}
+
+// PurgeSessions elimina por lotes las sesiones sin actividad desde hace más de maxLifetime
+func PurgeSessions(maxLifetime time.Duration, batchSize int) (int64, error) {
+	cutoff := time.Now().Add(-maxLifetime).Unix()
+	return deleteInBatches("DELETE FROM sessions WHERE last_activity < ? LIMIT ?", []any{cutoff}, batchSize)
+}
+
+// GetUserSessions obtiene las sesiones de un usuario con actividad posterior a activeSince
+func GetUserSessions(userID int64, activeSince time.Time) ([]Session, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := `SELECT id, user_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), payload, last_activity
+		FROM sessions WHERE user_id = ? AND last_activity >= ? ORDER BY last_activity DESC`
+
+	rows, err := db.Query(query, userID, activeSince.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		var sessionUserID sql.NullInt64
+		var lastActivity int64
+		if err := rows.Scan(&session.ID, &sessionUserID, &session.IPAddress, &session.UserAgent, &session.Payload, &lastActivity); err != nil {
+			return nil, err
+		}
+		session.UserID = sessionUserID.Int64
+		session.LastActivity = time.Unix(lastActivity, 0)
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// TouchSession actualiza la última actividad de una sesión
+func TouchSession(id string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+	_, err := db.Exec("UPDATE sessions SET last_activity = ? WHERE id = ?", time.Now().Unix(), id)
+	return err
+}
+
+// DeleteUserSessions elimina todas las sesiones de un usuario excepto exceptID (vacío para no conservar ninguna)
+func DeleteUserSessions(userID int64, exceptID string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+	_, err := db.Exec("DELETE FROM sessions WHERE user_id = ? AND id <> ?", userID, exceptID)
+	return err
+}
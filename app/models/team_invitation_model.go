@@ -0,0 +1,149 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"semita/app/structs"
+	"semita/app/utils"
+	"semita/config"
+	"strings"
+	"time"
+)
+
+var teamInvitationsTable = "team_invitations"
+
+var (
+	ErrTeamInvitationExpired       = errors.New("the team invitation has expired")
+	ErrTeamInvitationEmailMismatch = errors.New("the team invitation was sent to a different email")
+)
+
+// TeamInvitationExpiration es la vigencia de una invitación a un equipo (TEAM_INVITATION_EXPIRE, en minutos)
+func TeamInvitationExpiration() time.Duration {
+	return envMinutes("TEAM_INVITATION_EXPIRE", 7*24*60)
+}
+
+// CreateTeamInvitation invita un email al equipo, reemplazando una invitación anterior al mismo email.
+// Con roleID distinto de 0 el rol se asigna en el equipo al aceptar. Devuelve el token en claro para
+// enviarlo por correo; solo se guarda su hash.
+func CreateTeamInvitation(teamID int, email string, roleID int, invitedBy int) (string, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	var role any
+	if roleID != 0 {
+		role = roleID
+	}
+	expiresAt := time.Now().Add(TeamInvitationExpiration()).Format("2006-01-02 15:04:05")
+
+	query := `
+		INSERT INTO ` + teamInvitationsTable + ` (team_id, email, role_id, token, invited_by, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE role_id = VALUES(role_id), token = VALUES(token),
+			invited_by = VALUES(invited_by), expires_at = VALUES(expires_at), created_at = CURRENT_TIMESTAMP
+	`
+	_, err = database.Exec(query, teamID, strings.ToLower(email), role, utils.HashToken(token), invitedBy, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// GetTeamInvitationByToken busca una invitación a partir del token en claro
+func GetTeamInvitationByToken(token string) (*structs.TeamInvitationStruct, error) {
+	rows, err := queryTeamInvitations(`ti.token = ?`, utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &rows[0], nil
+}
+
+// GetTeamInvitations obtiene las invitaciones pendientes de un equipo
+func GetTeamInvitations(teamID int) ([]structs.TeamInvitationStruct, error) {
+	return queryTeamInvitations(`ti.team_id = ?`, teamID)
+}
+
+// DeleteTeamInvitation cancela una invitación del equipo
+func DeleteTeamInvitation(teamID int, invitationID int) error {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	_, err := database.Exec(`DELETE FROM `+teamInvitationsTable+` WHERE id = ? AND team_id = ?`, invitationID, teamID)
+	return err
+}
+
+// AcceptTeamInvitation agrega al usuario al equipo con el rol de la invitación y la consume. El email
+// del usuario debe coincidir con el invitado.
+func AcceptTeamInvitation(invitation structs.TeamInvitationStruct, user structs.UserStruct) error {
+	expiresAt, err := time.ParseInLocation("2006-01-02 15:04:05", invitation.ExpiresAt, time.Local)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(expiresAt) {
+		return ErrTeamInvitationExpired
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return ErrTeamInvitationEmailMismatch
+	}
+
+	if err = AddTeamMember(invitation.TeamID, user.ID); err != nil {
+		return err
+	}
+	if invitation.RoleID != 0 {
+		hasRole, err := UserHasRoleInTeam(user.ID, invitation.RoleID, invitation.TeamID)
+		if err != nil {
+			return err
+		}
+		if !hasRole {
+			if err = AssignRoleToUserInTeam(user.ID, invitation.RoleID, invitation.TeamID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return DeleteTeamInvitation(invitation.TeamID, invitation.ID)
+}
+
+func queryTeamInvitations(condition string, args ...any) ([]structs.TeamInvitationStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `
+		SELECT ti.id, ti.team_id, ti.email, ti.role_id, r.name, ti.invited_by, ti.expires_at, ti.created_at
+		FROM ` + teamInvitationsTable + ` ti
+		LEFT JOIN ` + rolesTable + ` r ON r.id = ti.role_id
+		WHERE ` + condition + `
+		ORDER BY ti.created_at DESC
+	`
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []structs.TeamInvitationStruct
+	for rows.Next() {
+		var invitation structs.TeamInvitationStruct
+		var roleID, invitedBy sql.NullInt64
+		var roleName sql.NullString
+		err = rows.Scan(&invitation.ID, &invitation.TeamID, &invitation.Email, &roleID, &roleName,
+			&invitedBy, &invitation.ExpiresAt, &invitation.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		invitation.RoleID = int(roleID.Int64)
+		invitation.RoleName = roleName.String
+		invitation.InvitedBy = int(invitedBy.Int64)
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
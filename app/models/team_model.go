@@ -0,0 +1,263 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"semita/app/structs"
+	"semita/config"
+	"strings"
+)
+
+var teamsTable = "teams"
+var teamMembersTable = "team_members"
+
+// ErrNotTeamMember se devuelve al asignar un rol o permiso de un equipo a quien no es miembro
+var ErrNotTeamMember = errors.New("user is not a member of this team")
+
+// ErrTeamOwner se devuelve al intentar quitar del equipo a su propietario
+var ErrTeamOwner = errors.New("the team owner cannot be removed from the team")
+
+// TeamRoles son los roles que se pueden asignar al invitar a un equipo (TEAM_ROLES, separados por coma).
+// Los roles de un equipo cuentan en todas las verificaciones mientras ese equipo es el actual, así que
+// solo deben tener permisos propios del equipo.
+func TeamRoles() []string {
+	value := os.Getenv("TEAM_ROLES")
+	if value == "" {
+		value = "team-admin,team-member"
+	}
+
+	var roles []string
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// requireTeamMember devuelve ErrNotTeamMember si teamID no es 0 y el usuario no pertenece al equipo
+func requireTeamMember(teamID int, userID int) error {
+	if teamID == 0 {
+		return nil
+	}
+	_, err := GetUserTeam(teamID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotTeamMember
+	}
+	return err
+}
+
+// CreateTeam crea un equipo y agrega a su propietario como miembro
+func CreateTeam(name string, ownerID int) (*structs.TeamStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	tx, err := database.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO `+teamsTable+` (name, owner_id) VALUES (?, ?)`, name, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	teamID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.Exec(`INSERT INTO `+teamMembersTable+` (team_id, user_id) VALUES (?, ?)`, teamID, ownerID); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetTeamByID(int(teamID))
+}
+
+// GetTeamByID obtiene un equipo por su ID
+func GetTeamByID(id int) (*structs.TeamStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `SELECT id, name, owner_id, created_at, updated_at FROM ` + teamsTable + ` WHERE id = ?`
+
+	var team structs.TeamStruct
+	err := database.QueryRow(query, id).Scan(&team.ID, &team.Name, &team.OwnerID, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// GetUserTeam obtiene el equipo solo si el usuario es miembro; si no, devuelve sql.ErrNoRows
+func GetUserTeam(teamID int, userID int) (*structs.TeamStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `
+		SELECT t.id, t.name, t.owner_id, t.created_at, t.updated_at
+		FROM ` + teamsTable + ` t
+		INNER JOIN ` + teamMembersTable + ` tm ON t.id = tm.team_id
+		WHERE t.id = ? AND tm.user_id = ?
+	`
+
+	var team structs.TeamStruct
+	err := database.QueryRow(query, teamID, userID).Scan(&team.ID, &team.Name, &team.OwnerID, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// GetUserTeams obtiene los equipos a los que pertenece un usuario
+func GetUserTeams(userID int) ([]structs.TeamStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `
+		SELECT t.id, t.name, t.owner_id, t.created_at, t.updated_at
+		FROM ` + teamsTable + ` t
+		INNER JOIN ` + teamMembersTable + ` tm ON t.id = tm.team_id
+		WHERE tm.user_id = ?
+		ORDER BY t.name
+	`
+	rows, err := database.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []structs.TeamStruct
+	for rows.Next() {
+		var team structs.TeamStruct
+		if err = rows.Scan(&team.ID, &team.Name, &team.OwnerID, &team.CreatedAt, &team.UpdatedAt); err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+// GetTeamMembers obtiene los miembros de un equipo con los roles que tienen en él
+func GetTeamMembers(teamID int) ([]structs.TeamMemberStruct, error) {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `
+		SELECT u.id, u.name, u.email, tm.created_at
+		FROM ` + userTable + ` u
+		INNER JOIN ` + teamMembersTable + ` tm ON u.id = tm.user_id
+		WHERE tm.team_id = ?
+		ORDER BY u.name
+	`
+	rows, err := database.Query(query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []structs.TeamMemberStruct
+	for rows.Next() {
+		var member structs.TeamMemberStruct
+		if err = rows.Scan(&member.UserID, &member.Name, &member.Email, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range members {
+		if members[i].Roles, err = GetUserTeamRoles(members[i].UserID, teamID); err != nil {
+			return nil, err
+		}
+	}
+
+	return members, nil
+}
+
+// AddTeamMember agrega un usuario al equipo; no hace nada si ya es miembro
+func AddTeamMember(teamID int, userID int) error {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	query := `INSERT IGNORE INTO ` + teamMembersTable + ` (team_id, user_id) VALUES (?, ?)`
+	_, err := database.Exec(query, teamID, userID)
+	return err
+}
+
+// RemoveTeamMember quita a un usuario del equipo junto con los roles y permisos que tenía en él
+func RemoveTeamMember(teamID int, userID int) error {
+	team, err := GetTeamByID(teamID)
+	if err != nil {
+		return err
+	}
+	if team.OwnerID == userID {
+		return ErrTeamOwner
+	}
+
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	tx, err := database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`DELETE FROM ` + userRolesTable + ` WHERE team_id = ? AND user_id = ?`,
+		`DELETE FROM ` + userPermissionsTable + ` WHERE team_id = ? AND user_id = ?`,
+		`DELETE FROM ` + teamMembersTable + ` WHERE team_id = ? AND user_id = ?`,
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(statement, teamID, userID); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	ForgetUserPermissions(userID)
+	return nil
+}
+
+// DeleteTeam elimina un equipo y sus asignaciones de roles y permisos; sus miembros e invitaciones
+// se borran en cascada. team_id de las asignaciones no es una clave foránea (0 es la asignación
+// global), así que se borran en la misma transacción.
+func DeleteTeam(id int) error {
+	database := config.DatabaseConnect()
+	defer database.Close()
+
+	tx, err := database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`DELETE FROM ` + userRolesTable + ` WHERE team_id = ?`,
+		`DELETE FROM ` + userPermissionsTable + ` WHERE team_id = ?`,
+		`DELETE FROM ` + teamsTable + ` WHERE id = ?`,
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(statement, id); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	FlushPermissionCache()
+	return nil
+}
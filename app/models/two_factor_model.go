@@ -0,0 +1,116 @@
+package models
+
+import (
+	"database/sql"
+	"semita/config"
+	"time"
+)
+
+// TwoFactor es la configuración de autenticación en dos pasos de un usuario
+type TwoFactor struct {
+	Secret        string // Secreto TOTP cifrado con APP_KEY; vacío si no se inició la activación
+	RecoveryCodes string // JSON con los hashes de los códigos de recuperación sin usar
+	ConfirmedAt   string // Vacío mientras la activación no se confirme con un código
+	LastStep      int64  // Último paso TOTP aceptado, para impedir reutilizar un código
+}
+
+// Enabled indica si el usuario confirmó la autenticación en dos pasos
+func (t TwoFactor) Enabled() bool {
+	return t.Secret != "" && t.ConfirmedAt != ""
+}
+
+// Pending indica si la activación se inició pero todavía no se confirmó
+func (t TwoFactor) Pending() bool {
+	return t.Secret != "" && t.ConfirmedAt == ""
+}
+
+// GetTwoFactor obtiene la configuración de dos pasos de un usuario
+func GetTwoFactor(userID int) (TwoFactor, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var twoFactor TwoFactor
+	var lastStep sql.NullInt64
+	query := `SELECT COALESCE(two_factor_secret, ''), COALESCE(two_factor_recovery_codes, ''),
+			COALESCE(two_factor_confirmed_at, ''), two_factor_last_step
+		FROM users WHERE id = ?`
+	err := db.QueryRow(query, userID).Scan(&twoFactor.Secret, &twoFactor.RecoveryCodes, &twoFactor.ConfirmedAt, &lastStep)
+	if err != nil {
+		return TwoFactor{}, err
+	}
+
+	twoFactor.LastStep = lastStep.Int64
+	return twoFactor, nil
+}
+
+// SaveTwoFactorSecret guarda un secreto nuevo sin confirmar y descarta la configuración anterior
+func SaveTwoFactorSecret(userID int, encryptedSecret string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := `UPDATE users SET two_factor_secret = ?, two_factor_recovery_codes = NULL,
+		two_factor_confirmed_at = NULL, two_factor_last_step = NULL WHERE id = ?`
+	_, err := db.Exec(query, encryptedSecret, userID)
+	return err
+}
+
+// ConfirmTwoFactor marca la activación como confirmada y guarda los códigos de recuperación
+func ConfirmTwoFactor(userID int, recoveryCodes string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := "UPDATE users SET two_factor_confirmed_at = ?, two_factor_recovery_codes = ? WHERE id = ? AND two_factor_secret IS NOT NULL"
+	_, err := db.Exec(query, time.Now().Format("2006-01-02 15:04:05"), recoveryCodes, userID)
+	return err
+}
+
+// UpdateTwoFactorRecoveryCodes reemplaza los códigos de recuperación del usuario
+func UpdateTwoFactorRecoveryCodes(userID int, recoveryCodes string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+	_, err := db.Exec("UPDATE users SET two_factor_recovery_codes = ? WHERE id = ?", recoveryCodes, userID)
+	return err
+}
+
+// ConsumeTwoFactorRecoveryCodes reemplaza los códigos de recuperación solo si siguen siendo los
+// leídos. Devuelve false si otra petición los cambió antes, de modo que cada código sirve una sola vez.
+func ConsumeTwoFactorRecoveryCodes(userID int, previous string, remaining string) (bool, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := "UPDATE users SET two_factor_recovery_codes = ? WHERE id = ? AND two_factor_recovery_codes = ?"
+	result, err := db.Exec(query, remaining, userID, previous)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ClaimTwoFactorStep registra el paso TOTP usado. Devuelve false si ese paso, o uno posterior,
+// ya se había usado, de modo que cada código sirve una sola vez.
+func ClaimTwoFactorStep(userID int, step int64) (bool, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := "UPDATE users SET two_factor_last_step = ? WHERE id = ? AND (two_factor_last_step IS NULL OR two_factor_last_step < ?)"
+	result, err := db.Exec(query, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// DisableTwoFactor elimina la configuración de dos pasos del usuario
+func DisableTwoFactor(userID int) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := `UPDATE users SET two_factor_secret = NULL, two_factor_recovery_codes = NULL,
+		two_factor_confirmed_at = NULL, two_factor_last_step = NULL WHERE id = ?`
+	_, err := db.Exec(query, userID)
+	return err
+}
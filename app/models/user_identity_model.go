@@ -0,0 +1,111 @@
+package models
+
+import (
+	"semita/config"
+	"time"
+)
+
+// UserIdentity vincula un usuario con su cuenta en un proveedor externo (Google, GitHub, ...).
+// Los tokens se guardan cifrados con utils.Encrypt.
+type UserIdentity struct {
+	ID             int64
+	UserID         int
+	Provider       string
+	ProviderUserID string
+	Email          string
+	Name           string
+	AvatarURL      string
+	AccessToken    string
+	RefreshToken   string
+	ExpiresAt      string
+	LastLoginAt    string
+	CreatedAt      string
+}
+
+const userIdentityColumns = `id, user_id, provider, provider_user_id, COALESCE(email, ''), COALESCE(name, ''),
+	COALESCE(avatar_url, ''), COALESCE(access_token, ''), COALESCE(refresh_token, ''), COALESCE(expires_at, ''),
+	COALESCE(last_login_at, ''), created_at`
+
+func scanUserIdentity(row tokenScanner) (UserIdentity, error) {
+	var identity UserIdentity
+	err := row.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.ProviderUserID, &identity.Email,
+		&identity.Name, &identity.AvatarURL, &identity.AccessToken, &identity.RefreshToken, &identity.ExpiresAt,
+		&identity.LastLoginAt, &identity.CreatedAt)
+	return identity, err
+}
+
+// CreateUserIdentity vincula una identidad externa a un usuario
+func CreateUserIdentity(identity UserIdentity) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	query := `INSERT INTO user_identities
+		(user_id, provider, provider_user_id, email, name, avatar_url, access_token, refresh_token, expires_at,
+		last_login_at, created_at, updated_at)
+		VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?)`
+	_, err := db.Exec(query, identity.UserID, identity.Provider, identity.ProviderUserID, identity.Email, identity.Name,
+		identity.AvatarURL, identity.AccessToken, identity.RefreshToken, identity.ExpiresAt, now, now, now)
+	return err
+}
+
+// GetUserIdentity busca la identidad de un usuario externo en un proveedor
+func GetUserIdentity(provider string, providerUserID string) (UserIdentity, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	row := db.QueryRow("SELECT "+userIdentityColumns+" FROM user_identities WHERE provider = ? AND provider_user_id = ?",
+		provider, providerUserID)
+	return scanUserIdentity(row)
+}
+
+// GetUserIdentities obtiene las identidades externas vinculadas a un usuario
+func GetUserIdentities(userID int) ([]UserIdentity, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	rows, err := db.Query("SELECT "+userIdentityColumns+" FROM user_identities WHERE user_id = ? ORDER BY provider", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []UserIdentity
+	for rows.Next() {
+		identity, err := scanUserIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+// UpdateUserIdentityLogin actualiza el perfil y los tokens de la identidad en cada inicio de sesión
+func UpdateUserIdentityLogin(identity UserIdentity) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	query := `UPDATE user_identities SET email = NULLIF(?, ''), name = NULLIF(?, ''), avatar_url = NULLIF(?, ''),
+		access_token = NULLIF(?, ''), refresh_token = COALESCE(NULLIF(?, ''), refresh_token), expires_at = NULLIF(?, ''),
+		last_login_at = ?, updated_at = ? WHERE id = ?`
+	_, err := db.Exec(query, identity.Email, identity.Name, identity.AvatarURL, identity.AccessToken,
+		identity.RefreshToken, identity.ExpiresAt, now, now, identity.ID)
+	return err
+}
+
+// DeleteUserIdentity desvincula una identidad del usuario; devuelve false si no le pertenece
+func DeleteUserIdentity(userID int, id int64) (bool, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM user_identities WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
@@ -70,10 +70,10 @@ func GetUserByID(id string) (user structs.UserStruct, err error) {
 	defer database.Close()
 
 	// Preparamos la consulta para obtener un usuario por su ID
-	var query = "SELECT id, name, email, password, created_at, updated_at FROM " + userTable + " WHERE id = ?"
+	var query = "SELECT id, name, email, password, COALESCE(email_verified_at, ''), session_epoch, created_at, updated_at FROM " + userTable + " WHERE id = ?"
 
 	// Ejecutamos la consulta y obtenemos los resultados
-	err = database.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	err = database.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.EmailVerifiedAt, &user.SessionEpoch, &user.CreatedAt, &user.UpdatedAt)
 
 	// Si hubo un error al ejecutar la consulta o no se encontró el usuario, retornamos el error
 	if err != nil {
@@ -91,10 +91,10 @@ func GetUserByEmail(email string) (user structs.UserStruct, err error) {
 	defer database.Close()
 
 	// Preparamos la consulta para obtener un usuario por su email
-	var query = "SELECT id, name, email, password, created_at, updated_at FROM " + userTable + " WHERE email = ?"
+	var query = "SELECT id, name, email, password, COALESCE(email_verified_at, ''), session_epoch, created_at, updated_at FROM " + userTable + " WHERE email = ?"
 
 	// Ejecutamos la consulta y obtenemos los resultados
-	err = database.QueryRow(query, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	err = database.QueryRow(query, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.EmailVerifiedAt, &user.SessionEpoch, &user.CreatedAt, &user.UpdatedAt)
 
 	// Si hubo un error al ejecutar la consulta o no se encontró el usuario, retornamos el error
 	if err != nil {
@@ -112,10 +112,11 @@ func UpdateUser(user structs.UpdateUserStruct) (err error) {
 	defer database.Close()
 
 	// Preparamos la consulta para actualizar un usuario por su ID
-	var query = "UPDATE " + userTable + " SET name = ?, email = ?, password = ? WHERE id = ?"
+	// Si la contraseña cambia se invalida el token de "recordarme"
+	var query = "UPDATE " + userTable + " SET name = ?, email = ?, remember_token = CASE WHEN password = ? THEN remember_token ELSE NULL END, password = ? WHERE id = ?"
 
 	// Ejecutamos la consulta con los datos del usuario
-	_, err = database.Exec(query, user.Name, user.Email, user.Password, user.ID)
+	_, err = database.Exec(query, user.Name, user.Email, user.Password, user.Password, user.ID)
 
 	// Si hubo un error al ejecutar la consulta, retornamos el error
 	if err != nil {
@@ -153,3 +154,48 @@ func MarkEmailVerified(userID int) error {
 	_, err := db.Exec("UPDATE "+userTable+" SET email_verified_at = ? WHERE id = ?", time.Now().Format("2006-01-02 15:04:05"), userID)
 	return err
 }
+
+// UpdateUserPassword actualiza únicamente la contraseña (ya hasheada) del usuario e invalida su token de "recordarme"
+func UpdateUserPassword(userID int, passwordHash string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+	_, err := db.Exec("UPDATE "+userTable+" SET password = ?, remember_token = NULL WHERE id = ?", passwordHash, userID)
+	return err
+}
+
+// UpdateRememberToken guarda el hash del token de "recordarme"; un hash vacío lo elimina
+func UpdateRememberToken(userID int, tokenHash string) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var value any
+	if tokenHash != "" {
+		value = tokenHash
+	}
+	_, err := db.Exec("UPDATE "+userTable+" SET remember_token = ? WHERE id = ?", value, userID)
+	return err
+}
+
+// IncrementSessionEpoch cambia la versión de las sesiones del usuario, con lo que todas sus sesiones
+// web dejan de ser válidas en la próxima petición
+func IncrementSessionEpoch(userID int) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+	_, err := db.Exec("UPDATE "+userTable+" SET session_epoch = session_epoch + 1 WHERE id = ?", userID)
+	return err
+}
+
+// GetUserByRememberToken busca al usuario cuyo token de "recordarme" coincide con el hash indicado
+func GetUserByRememberToken(userID int, tokenHash string) (structs.UserStruct, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	var user structs.UserStruct
+	query := "SELECT id, name, email, password, COALESCE(email_verified_at, ''), session_epoch, created_at, updated_at FROM " + userTable + " WHERE id = ? AND remember_token = ?"
+	err := db.QueryRow(query, userID, tokenHash).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.EmailVerifiedAt, &user.SessionEpoch, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return structs.UserStruct{}, err
+	}
+
+	return user, nil
+}
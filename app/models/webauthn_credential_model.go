@@ -0,0 +1,102 @@
+package models
+
+import (
+	"semita/config"
+	"time"
+)
+
+// WebAuthnCredential es una passkey registrada por un usuario
+type WebAuthnCredential struct {
+	ID                int64
+	UserID            int
+	Name              string
+	CredentialID      string // ID de la credencial en base64url
+	PublicKey         []byte // Llave pública COSE
+	SignCount         uint32
+	AAGUID            string
+	AttestationFormat string
+	BackupEligible    bool
+	LastUsedAt        string
+	CreatedAt         string
+}
+
+const webAuthnCredentialColumns = `id, user_id, name, credential_id, public_key, sign_count, COALESCE(aaguid, ''),
+	attestation_format, backup_eligible, COALESCE(last_used_at, ''), created_at`
+
+func scanWebAuthnCredential(row tokenScanner) (WebAuthnCredential, error) {
+	var credential WebAuthnCredential
+	err := row.Scan(&credential.ID, &credential.UserID, &credential.Name, &credential.CredentialID, &credential.PublicKey,
+		&credential.SignCount, &credential.AAGUID, &credential.AttestationFormat, &credential.BackupEligible,
+		&credential.LastUsedAt, &credential.CreatedAt)
+	return credential, err
+}
+
+// CreateWebAuthnCredential guarda una passkey nueva
+func CreateWebAuthnCredential(credential WebAuthnCredential) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	query := `INSERT INTO webauthn_credentials
+		(user_id, name, credential_id, public_key, sign_count, aaguid, attestation_format, backup_eligible, created_at)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?)`
+	_, err := db.Exec(query, credential.UserID, credential.Name, credential.CredentialID, credential.PublicKey,
+		credential.SignCount, credential.AAGUID, credential.AttestationFormat, credential.BackupEligible,
+		time.Now().Format("2006-01-02 15:04:05"))
+	return err
+}
+
+// GetUserWebAuthnCredentials obtiene las passkeys de un usuario
+func GetUserWebAuthnCredentials(userID int) ([]WebAuthnCredential, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	rows, err := db.Query("SELECT "+webAuthnCredentialColumns+" FROM webauthn_credentials WHERE user_id = ? ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+// GetWebAuthnCredentialByCredentialID busca una passkey por el ID que envía el autenticador
+func GetWebAuthnCredentialByCredentialID(credentialID string) (WebAuthnCredential, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	row := db.QueryRow("SELECT "+webAuthnCredentialColumns+" FROM webauthn_credentials WHERE credential_id = ?", credentialID)
+	return scanWebAuthnCredential(row)
+}
+
+// TouchWebAuthnCredential actualiza el contador de firmas y la fecha del último uso
+func TouchWebAuthnCredential(id int64, signCount uint32) error {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE webauthn_credentials SET sign_count = ?, last_used_at = ? WHERE id = ?",
+		signCount, time.Now().Format("2006-01-02 15:04:05"), id)
+	return err
+}
+
+// DeleteUserWebAuthnCredential elimina una passkey del usuario; devuelve false si no le pertenece
+func DeleteUserWebAuthnCredential(userID int, id int64) (bool, error) {
+	db := config.DatabaseConnect()
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM webauthn_credentials WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
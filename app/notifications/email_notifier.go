@@ -2,6 +2,7 @@ package notifications
 
 import (
 	"fmt"
+	"html"
 	"semita/app/utils"
 )
 
@@ -18,3 +19,9 @@ func SendPasswordReset(to, url string) error {
 	body := fmt.Sprintf("<p>Haz clic en el siguiente enlace para restablecer tu contraseña:</p><p><a href=\"%s\">Restablecer contraseña</a></p>", url)
 	return DefaultNotifier.Send(to, subject, body)
 }
+
+func SendTeamInvitation(to, teamName, url string) error {
+	subject := "Invitación al equipo " + teamName
+	body := fmt.Sprintf("<p>Te invitaron a unirte al equipo <strong>%s</strong>.</p><p><a href=\"%s\">Aceptar invitación</a></p>", html.EscapeString(teamName), url)
+	return DefaultNotifier.Send(to, subject, body)
+}
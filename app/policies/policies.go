@@ -0,0 +1,35 @@
+// Package policies registra en el gate las reglas de autorización de la aplicación.
+// Se importa por efecto secundario al arrancar el servidor.
+package policies
+
+import (
+	"semita/app/core/auth"
+	"semita/app/core/gate"
+	"semita/app/models"
+	"semita/app/structs"
+)
+
+// SuperAdminRole es el rol que pasa todas las verificaciones del gate
+const SuperAdminRole = "super-admin"
+
+func init() {
+	gate.Before(func(actor *auth.Identity, ability string, resource any) (bool, bool) {
+		// Solo cuenta el rol global: un super-admin asignado dentro de un equipo no salta las reglas
+		if !actor.HasRole(SuperAdminRole) {
+			return false, false
+		}
+		for _, guardName := range actor.Guard.PermissionGuards() {
+			global, err := models.UserHasRoleByName(actor.User.ID, SuperAdminRole, guardName)
+			if err != nil {
+				return false, false
+			}
+			if global {
+				return true, true
+			}
+		}
+		return false, false
+	})
+
+	gate.Policy(structs.UserStruct{}, UserPolicy{})
+	gate.Policy(structs.TeamStruct{}, TeamPolicy{})
+}
@@ -0,0 +1,25 @@
+package policies
+
+import (
+	"semita/app/core/auth"
+	"semita/app/structs"
+)
+
+// TeamPolicy autoriza las acciones sobre equipos. Las verificaciones de permisos usan el equipo actual
+// de la petición, por eso las rutas de un equipo lo fijan con middleware.ResolveTeam.
+type TeamPolicy struct{}
+
+// View permite ver el equipo a sus miembros
+func (TeamPolicy) View(actor *auth.Identity, team structs.TeamStruct) bool {
+	return actor.User.ID == team.OwnerID || actor.TeamID() == team.ID
+}
+
+// Manage permite invitar y quitar miembros al propietario y a quien tenga manage-team en el equipo
+func (TeamPolicy) Manage(actor *auth.Identity, team structs.TeamStruct) bool {
+	return actor.User.ID == team.OwnerID || (actor.TeamID() == team.ID && actor.HasPermission("manage-team"))
+}
+
+// Delete permite eliminar el equipo solo a su propietario
+func (TeamPolicy) Delete(actor *auth.Identity, team structs.TeamStruct) bool {
+	return actor.User.ID == team.OwnerID
+}
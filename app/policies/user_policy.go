@@ -0,0 +1,35 @@
+package policies
+
+import (
+	"semita/app/core/auth"
+	"semita/app/structs"
+)
+
+// UserPolicy autoriza las acciones sobre usuarios. Cada usuario puede ver y editar su propia cuenta
+// sin los permisos de administración de usuarios.
+type UserPolicy struct{}
+
+// ViewAny permite listar usuarios
+func (UserPolicy) ViewAny(actor *auth.Identity, _ structs.UserStruct) bool {
+	return actor.HasPermission("view-users")
+}
+
+// View permite ver un usuario
+func (UserPolicy) View(actor *auth.Identity, target structs.UserStruct) bool {
+	return actor.User.ID == target.ID || actor.HasPermission("view-users")
+}
+
+// Create permite crear usuarios
+func (UserPolicy) Create(actor *auth.Identity, _ structs.UserStruct) bool {
+	return actor.HasPermission("create-users")
+}
+
+// Update permite editar un usuario
+func (UserPolicy) Update(actor *auth.Identity, target structs.UserStruct) bool {
+	return actor.User.ID == target.ID || actor.HasPermission("edit-users")
+}
+
+// Delete permite eliminar un usuario; nadie puede eliminar su propia cuenta desde aquí
+func (UserPolicy) Delete(actor *auth.Identity, target structs.UserStruct) bool {
+	return actor.User.ID != target.ID && actor.HasPermission("delete-users")
+}
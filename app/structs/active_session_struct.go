@@ -0,0 +1,10 @@
+package structs
+
+// ActiveSessionStruct representa una sesión web abierta de un usuario
+type ActiveSessionStruct struct {
+	Key          string `json:"id"` // Identificador público derivado del ID de sesión, que nunca se expone
+	IPAddress    string `json:"ip_address"`
+	UserAgent    string `json:"user_agent"`
+	LastActivity string `json:"last_activity"`
+	Current      bool   `json:"current"`
+}
@@ -3,10 +3,14 @@ package structs
 type AuthSessionStruct struct {
 	User            UserStruct
 	IsAuthenticated bool
+	Team            *TeamStruct // Equipo actual del usuario; nil si no hay
 	AlertId         string
 	AlertMessage    string
 	Title           string
 	Data            any
-	Lang            string              // Idioma actual
-	Translate       func(string) string // Función de traducción automática
+	Lang            string                    // Idioma actual
+	Translate       func(string) string       // Función de traducción automática
+	CsrfToken       string                    // Token CSRF de la sesión, para cabeceras X-CSRF-Token
+	CsrfField       string                    // Campo oculto _token listo para incluir en formularios
+	Can             func(string, ...any) bool // Verifica una habilidad del gate: {{if call .Can "update" .Data}}
 }
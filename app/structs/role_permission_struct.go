@@ -1,13 +1,16 @@
 package structs
 
+import "time"
+
 // Role struct representa un rol en el sistema
 type RoleStruct struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	GuardName   string `json:"guard_name"`
-	Description string `json:"description"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	GuardName         string `json:"guard_name"`
+	Description       string `json:"description"`
+	RequiresTwoFactor bool   `json:"requires_two_factor"` // Exige 2FA a los usuarios con este rol
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
 }
 
 // Permission struct representa un permiso en el sistema
@@ -36,9 +39,10 @@ type UserWithRolesAndPermissions struct {
 
 // CreateRoleStruct para crear nuevos roles
 type CreateRoleStruct struct {
-	Name        string `json:"name" binding:"required"`
-	GuardName   string `json:"guard_name"`
-	Description string `json:"description"`
+	Name              string `json:"name" binding:"required"`
+	GuardName         string `json:"guard_name"`
+	Description       string `json:"description"`
+	RequiresTwoFactor bool   `json:"requires_two_factor"`
 }
 
 // CreatePermissionStruct para crear nuevos permisos
@@ -52,6 +56,14 @@ type CreatePermissionStruct struct {
 type AssignRoleRequest struct {
 	UserID int `json:"user_id" binding:"required"`
 	RoleID int `json:"role_id" binding:"required"`
+	TeamID int `json:"team_id,omitempty"` // Equipo de la asignación; vacío para un rol global
+	GrantWindow
+}
+
+// RoleParentRequest para que un rol herede los permisos de otro
+type RoleParentRequest struct {
+	RoleID       int `json:"role_id" binding:"required"`
+	ParentRoleID int `json:"parent_role_id" binding:"required"`
 }
 
 // AssignPermissionRequest para asignar permisos a usuarios o roles
@@ -59,6 +71,28 @@ type AssignPermissionRequest struct {
 	PermissionID int `json:"permission_id" binding:"required"`
 	UserID       int `json:"user_id,omitempty"`
 	RoleID       int `json:"role_id,omitempty"`
+	TeamID       int `json:"team_id,omitempty"` // Equipo de la asignación a un usuario; vacío para un permiso global
+	GrantWindow
+}
+
+// GrantWindow es la vigencia opcional de una asignación de rol o permiso a un usuario. Sin StartsAt
+// rige desde que se asigna y sin ExpiresAt no vence.
+type GrantWindow struct {
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// UserGrantStruct es una asignación de rol o permiso a un usuario con vigencia, para los listados de
+// asignaciones temporales
+type UserGrantStruct struct {
+	Type      string `json:"type"` // role o permission
+	UserID    int    `json:"user_id"`
+	UserEmail string `json:"user_email"`
+	Name      string `json:"name"`
+	GuardName string `json:"guard_name"`
+	TeamID    int    `json:"team_id,omitempty"`
+	StartsAt  string `json:"starts_at,omitempty"`
+	ExpiresAt string `json:"expires_at"`
 }
 
 // RolePermissionCheck para verificaciones de permisos
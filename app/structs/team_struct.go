@@ -0,0 +1,31 @@
+package structs
+
+// TeamStruct representa un equipo (organización) al que pertenecen varios usuarios
+type TeamStruct struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	OwnerID   int    `json:"owner_id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// TeamMemberStruct representa a un miembro del equipo con los roles que tiene en él
+type TeamMemberStruct struct {
+	UserID   int          `json:"user_id"`
+	Name     string       `json:"name"`
+	Email    string       `json:"email"`
+	JoinedAt string       `json:"joined_at"`
+	Roles    []RoleStruct `json:"roles"`
+}
+
+// TeamInvitationStruct representa una invitación pendiente a un equipo
+type TeamInvitationStruct struct {
+	ID        int    `json:"id"`
+	TeamID    int    `json:"team_id"`
+	Email     string `json:"email"`
+	RoleID    int    `json:"role_id"` // 0 si la invitación no asigna rol
+	RoleName  string `json:"role_name"`
+	InvitedBy int    `json:"invited_by"`
+	ExpiresAt string `json:"expires_at"`
+	CreatedAt string `json:"created_at"`
+}
@@ -6,12 +6,19 @@ type UserBase struct {
 }
 
 type UserStruct struct {
-	ID        int    `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Password        string `json:"password"`
+	EmailVerifiedAt string `json:"email_verified_at"`
+	SessionEpoch    int    `json:"-"` // Versión de las sesiones web; cambia al cerrar sesión en todas partes
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+// HasVerifiedEmail indica si el usuario ya verificó su correo
+func (u UserStruct) HasVerifiedEmail() bool {
+	return u.EmailVerifiedAt != ""
 }
 
 type Users []UserStruct
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// AppURL devuelve la URL base de la aplicación con esquema y sin barra final.
+// APP_URL puede venir sin esquema (p. ej. "localhost:8080"), en cuyo caso se asume http.
+func AppURL() string {
+	appURL := strings.TrimRight(os.Getenv("APP_URL"), "/")
+	if !strings.Contains(appURL, "://") {
+		appURL = "http://" + appURL
+	}
+	return appURL
+}
+
+// AppAddress devuelve el host y puerto de APP_URL, sin esquema, para levantar el servidor
+func AppAddress() string {
+	appURL := AppURL()
+	_, address, _ := strings.Cut(appURL, "://")
+	address, _, _ = strings.Cut(address, "/")
+	return address
+}
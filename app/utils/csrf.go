@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+)
+
+// csrfSessionKey es la clave de la sesión web donde se guarda el token CSRF
+const csrfSessionKey = "_token"
+
+// CsrfToken devuelve el token CSRF de la sesión, creándolo si todavía no existe
+func CsrfToken(response http.ResponseWriter, request *http.Request) string {
+	session, err := GetSessionStore().Get(request, "user-session")
+	if err != nil {
+		return ""
+	}
+
+	if token, ok := session.Values[csrfSessionKey].(string); ok && token != "" {
+		return token
+	}
+
+	token, err := GenerateRandomToken(20)
+	if err != nil {
+		Logs("ERROR", "No se pudo generar el token CSRF: "+err.Error())
+		return ""
+	}
+
+	session.Values[csrfSessionKey] = token
+	if err := session.Save(request, response); err != nil {
+		Logs("ERROR", "No se pudo guardar el token CSRF: "+err.Error())
+	}
+
+	return token
+}
+
+// SessionCsrfToken devuelve el token CSRF guardado en la sesión sin crear uno nuevo
+func SessionCsrfToken(request *http.Request) string {
+	session, err := GetSessionStore().Get(request, "user-session")
+	if err != nil {
+		return ""
+	}
+
+	token, _ := session.Values[csrfSessionKey].(string)
+	return token
+}
+
+// CsrfField genera el campo oculto _token para incluir en los formularios
+func CsrfField(token string) string {
+	return fmt.Sprintf(`<input type="hidden" name="_token" value="%s">`, html.EscapeString(token))
+}
@@ -53,9 +53,14 @@ func (emailNotifier EmailNotifier) Send(to, subject, body string) error {
 	return nil
 }
 
-// generateResetToken genera un hash simple para recuperación
-func GenerateResetToken(email string) string {
-	h := sha256.New()
-	h.Write([]byte(email + ":reset"))
-	return hex.EncodeToString(h.Sum(nil))
+// GenerateResetToken genera un token aleatorio para recuperación de contraseña.
+// El token solo se envía por correo; en la base de datos se guarda HashToken(token).
+func GenerateResetToken() (string, error) {
+	return GenerateRandomToken(32)
+}
+
+// HashToken calcula el hash SHA-256 con el que se almacenan los tokens de un solo uso
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrDecrypt se devuelve cuando el valor cifrado fue alterado o se cifró con otra APP_KEY
+var ErrDecrypt = errors.New("no se pudo descifrar el valor")
+
+// encryptionKey deriva de APP_KEY la llave AES-256 para cifrar datos en reposo
+func encryptionKey() []byte {
+	key := sha256.Sum256([]byte("encryption:" + GetEnv("APP_KEY")))
+	return key[:]
+}
+
+// Encrypt cifra un valor con AES-GCM y lo devuelve en base64 junto con su nonce
+func Encrypt(plaintext string) (string, error) {
+	block, err := aes.NewCipher(encryptionKey())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt descifra un valor generado por Encrypt
+func Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+
+	block, err := aes.NewCipher(encryptionKey())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+
+	return string(plaintext), nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -27,6 +28,18 @@ func GetEnv(key string) string {
 	return val
 }
 
+// ErrMissingAppKey se devuelve al firmar o validar con una APP_KEY vacía o sin generar ("null")
+var ErrMissingAppKey = errors.New("APP_KEY is not set, run key:generate")
+
+// AppKey devuelve la APP_KEY, o ErrMissingAppKey si está vacía o sigue con el valor "null" de .env.example
+func AppKey() (string, error) {
+	key := strings.TrimSpace(os.Getenv("APP_KEY"))
+	if key == "" || strings.EqualFold(key, "null") {
+		return "", ErrMissingAppKey
+	}
+	return key, nil
+}
+
 func UpdateEnvFile(key, value string) {
 	file := ".env"
 	input, err := os.ReadFile(file)
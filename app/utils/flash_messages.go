@@ -2,24 +2,10 @@ package utils
 
 import (
 	"net/http"
-	"sync"
-
-	"github.com/gorilla/sessions"
 )
 
-var storeOnce sync.Once
-var store *sessions.CookieStore
-
-func getStore() *sessions.CookieStore {
-	storeOnce.Do(func() {
-		appKey := GetEnv("APP_KEY")
-		store = sessions.NewCookieStore([]byte(appKey))
-	})
-	return store
-}
-
 func GetFlashNotifications(response http.ResponseWriter, request *http.Request) (string, string) {
-	var session, _ = getStore().Get(request, "flash-session")
+	var session, _ = GetSessionStore().Get(request, "flash-session")
 
 	var alertId = ""
 	var alertMensaje = ""
@@ -34,13 +20,16 @@ func GetFlashNotifications(response http.ResponseWriter, request *http.Request)
 		delete(session.Values, "alert_mensaje")
 	}
 
-	_ = session.Save(request, response)
+	// Solo se guarda si había una notificación que consumir, para no crear sesiones vacías
+	if alertId != "" || alertMensaje != "" {
+		_ = session.Save(request, response)
+	}
 
 	return alertId, alertMensaje
 }
 
 func CreateFlashNotification(response http.ResponseWriter, request *http.Request, alertID string, alertMessage string) {
-	var session, err = getStore().Get(request, "flash-session")
+	var session, err = GetSessionStore().Get(request, "flash-session")
 
 	if err != nil {
 		Logs("ERROR", err.Error())
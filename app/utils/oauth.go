@@ -7,6 +7,7 @@ import (
 	"os"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,8 +19,8 @@ type OAuthTokenClaims struct {
 	Scopes []string `json:"scopes,omitempty"`
 }
 
-// GenerateJWTToken genera un token JWT con los datos proporcionados
-func GenerateJWTToken(userID int64, clientID string, tokenID string, scopes []string, isRefresh bool) (string, time.Time, error) {
+// TokenLifetime devuelve la vigencia configurada para tokens de acceso o de refresco
+func TokenLifetime(isRefresh bool) (time.Duration, error) {
 	var expirationSeconds int64
 	var expirationEnvVar string
 
@@ -39,12 +40,32 @@ func GenerateJWTToken(userID int64, clientID string, tokenID string, scopes []st
 		var err error
 		expirationSeconds, err = strconv.ParseInt(expirationEnvVar, 10, 64)
 		if err != nil {
-			return "", time.Time{}, err
+			return 0, err
 		}
 	}
 
-	expirationTime := time.Now().Add(time.Second * time.Duration(expirationSeconds))
+	return time.Second * time.Duration(expirationSeconds), nil
+}
+
+// GenerateJWTToken genera un token JWT con los datos proporcionados
+func GenerateJWTToken(userID int64, clientID string, tokenID string, scopes []string, isRefresh bool) (string, time.Time, error) {
+	lifetime, err := TokenLifetime(isRefresh)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expirationTime := time.Now().Add(lifetime)
 
+	tokenString, err := GenerateJWTTokenWithExpiration(userID, clientID, tokenID, scopes, expirationTime)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expirationTime, nil
+}
+
+// GenerateJWTTokenWithExpiration genera un token JWT que expira en la fecha indicada
+func GenerateJWTTokenWithExpiration(userID int64, clientID string, tokenID string, scopes []string, expirationTime time.Time) (string, error) {
 	claims := OAuthTokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "semita_api",
@@ -60,16 +81,11 @@ func GenerateJWTToken(userID int64, clientID string, tokenID string, scopes []st
 
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
-		return "", time.Time{}, fmt.Errorf("JWT_SECRET no está configurado")
+		return "", fmt.Errorf("JWT_SECRET no está configurado")
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", time.Time{}, err
-	}
-
-	return tokenString, expirationTime, nil
+	return token.SignedString([]byte(jwtSecret))
 }
 
 // ValidateJWTToken valida un token JWT y devuelve sus claims
@@ -106,7 +122,45 @@ func GenerateRandomToken(length int) (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// HasScope verifica si un conjunto de scopes incluye un scope específico
+// ScopeMatches indica si un scope concedido cubre el scope requerido.
+// "*" cubre cualquier scope y "recurso:*" cubre todos los scopes "recurso:accion".
+func ScopeMatches(grantedScope string, requiredScope string) bool {
+	if grantedScope == "*" || grantedScope == requiredScope {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(grantedScope, ":*"); ok {
+		return strings.HasPrefix(requiredScope, prefix+":")
+	}
+	return false
+}
+
+// HasScope verifica si un conjunto de scopes incluye un scope específico, considerando comodines
 func HasScope(tokenScopes []string, requiredScope string) bool {
-	return slices.Contains(tokenScopes, requiredScope)
+	return slices.ContainsFunc(tokenScopes, func(scope string) bool {
+		return ScopeMatches(scope, requiredScope)
+	})
+}
+
+// HasAllScopes verifica que el conjunto de scopes cubra todos los scopes requeridos
+func HasAllScopes(tokenScopes []string, requiredScopes []string) bool {
+	for _, requiredScope := range requiredScopes {
+		if !HasScope(tokenScopes, requiredScope) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasAnyScope verifica que el conjunto de scopes cubra al menos uno de los scopes requeridos
+func HasAnyScope(tokenScopes []string, requiredScopes []string) bool {
+	return slices.ContainsFunc(requiredScopes, func(requiredScope string) bool {
+		return HasScope(tokenScopes, requiredScope)
+	})
+}
+
+// ParseScopes convierte una lista de scopes separada por espacios o comas en un slice
+func ParseScopes(scopes string) []string {
+	return strings.FieldsFunc(scopes, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
 }
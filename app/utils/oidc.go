@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	oidcKeyOnce sync.Once
+	oidcKey     *rsa.PrivateKey
+	oidcKeyErr  error
+)
+
+// OIDCSigningKey carga la llave privada RSA generada con oauth:keys para firmar los id_token
+func OIDCSigningKey() (*rsa.PrivateKey, error) {
+	oidcKeyOnce.Do(func() {
+		path := os.Getenv("OAUTH_PRIVATE_KEY_PATH")
+		if path == "" {
+			path = "storage/oauth/oauth-private.key"
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			oidcKeyErr = fmt.Errorf("no se pudo leer la llave privada OAuth, ejecuta oauth:keys: %w", err)
+			return
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			oidcKeyErr = errors.New("la llave privada OAuth no tiene formato PEM")
+			return
+		}
+
+		oidcKey, oidcKeyErr = x509.ParsePKCS1PrivateKey(block.Bytes)
+	})
+
+	return oidcKey, oidcKeyErr
+}
+
+// OIDCKeyID deriva el identificador (kid) de la llave a partir de su parte pública
+func OIDCKeyID(key *rsa.PublicKey) string {
+	der := x509.MarshalPKCS1PublicKey(key)
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// SignIDToken firma un id_token con RS256 usando la llave de oauth:keys
+func SignIDToken(claims jwt.MapClaims) (string, error) {
+	key, err := OIDCSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = OIDCKeyID(&key.PublicKey)
+	return token.SignedString(key)
+}
+
+// JWKS devuelve el conjunto de llaves públicas en formato JSON Web Key Set
+func JWKS() (map[string]any, error) {
+	key, err := OIDCSigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := &key.PublicKey
+	return map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": OIDCKeyID(publicKey),
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	}, nil
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// RememberCookieName es la cookie que guarda el token de "recordarme" del guard web
+const RememberCookieName = "remember_web"
+
+// rememberCookieMaxAge es la vigencia de la cookie de "recordarme" (400 días, el máximo que aceptan los navegadores)
+const rememberCookieMaxAge = 60 * 60 * 24 * 400
+
+// QueueRememberCookie genera un token nuevo, lo escribe en la cookie y devuelve su hash para guardarlo en users.remember_token
+func QueueRememberCookie(response http.ResponseWriter, userID int) (string, error) {
+	token, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(response, rememberCookie(strconv.Itoa(userID)+"|"+token, rememberCookieMaxAge))
+
+	return HashToken(token), nil
+}
+
+// ReadRememberCookie devuelve el usuario y el hash del token guardados en la cookie de "recordarme"
+func ReadRememberCookie(request *http.Request) (userID int, tokenHash string, ok bool) {
+	cookie, err := request.Cookie(RememberCookieName)
+	if err != nil {
+		return 0, "", false
+	}
+
+	id, token, found := strings.Cut(cookie.Value, "|")
+	if !found || token == "" {
+		return 0, "", false
+	}
+
+	userID, err = strconv.Atoi(id)
+	if err != nil {
+		return 0, "", false
+	}
+
+	return userID, HashToken(token), true
+}
+
+// ForgetRememberCookie elimina la cookie de "recordarme"
+func ForgetRememberCookie(response http.ResponseWriter) {
+	http.SetCookie(response, rememberCookie("", -1))
+}
+
+// rememberCookie arma la cookie con los mismos atributos que la de sesión (HttpOnly, Secure y
+// SameSite), para que una credencial de 400 días no viaje por HTTP plano
+func rememberCookie(value string, maxAge int) *http.Cookie {
+	options := sessionOptions()
+	options.MaxAge = maxAge
+	return sessions.NewCookie(RememberCookieName, value, options)
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// SessionDriverFactory crea el store de un driver de sesión con las opciones de cookie y las llaves indicadas
+type SessionDriverFactory func(options *sessions.Options, keyPairs ...[]byte) sessions.Store
+
+// sessionDestroyer lo implementan los drivers que guardan la sesión en el servidor
+type sessionDestroyer interface {
+	Destroy(id string) error
+}
+
+// sessionCollector lo implementan los drivers que necesitan limpiar sesiones expiradas
+type sessionCollector interface {
+	GC(maxLifetime time.Duration) (int64, error)
+}
+
+var (
+	sessionDriversMutex sync.RWMutex
+	sessionDrivers      = map[string]SessionDriverFactory{
+		"cookie": newCookieSessionStore,
+		"file":   newFileSessionStore,
+	}
+)
+
+// RegisterSessionDriver registra un driver de sesión para usarlo con SESSION_DRIVER.
+// Los drivers que dependen de la base de datos se registran desde su propio paquete.
+func RegisterSessionDriver(name string, factory SessionDriverFactory) {
+	sessionDriversMutex.Lock()
+	defer sessionDriversMutex.Unlock()
+	sessionDrivers[name] = factory
+}
+
+// SessionDriver devuelve el driver configurado en SESSION_DRIVER (por defecto cookie)
+func SessionDriver() string {
+	driver := os.Getenv("SESSION_DRIVER")
+	if driver == "" {
+		driver = "cookie"
+	}
+	return driver
+}
+
+// newSessionStore crea el store del driver configurado en SESSION_DRIVER
+func newSessionStore() *SessionStore {
+	driver := SessionDriver()
+
+	sessionDriversMutex.RLock()
+	factory, ok := sessionDrivers[driver]
+	sessionDriversMutex.RUnlock()
+
+	if !ok {
+		Logs("ERROR", fmt.Sprintf("Driver de sesión desconocido %q, se usará cookie", driver))
+		factory = newCookieSessionStore
+	}
+
+	return &SessionStore{Store: factory(sessionOptions(), sessionKeyPairs()...)}
+}
+
+// sessionKeyPairs devuelve la llave de firma (APP_KEY) y una llave AES derivada de ella para cifrar el contenido
+func sessionKeyPairs() [][]byte {
+	appKey := GetEnv("APP_KEY")
+	blockKey := sha256.Sum256([]byte("session-encryption:" + appKey))
+	return [][]byte{[]byte(appKey), blockKey[:]}
+}
+
+// sessionOptions son las opciones de cookie comunes a todas las sesiones
+func sessionOptions() *sessions.Options {
+	return &sessions.Options{
+		Path:     "/",
+		MaxAge:   int(SessionLifetime().Seconds()),
+		HttpOnly: true,
+		Secure:   SecureCookies(),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// SecureCookies indica si las cookies deben marcarse como Secure.
+// Se puede forzar con SESSION_SECURE_COOKIE; si no, se activa cuando APP_URL usa https o APP_ENV es production.
+func SecureCookies() bool {
+	if value := os.Getenv("SESSION_SECURE_COOKIE"); value != "" {
+		secure, err := strconv.ParseBool(value)
+		return err == nil && secure
+	}
+	return strings.HasPrefix(os.Getenv("APP_URL"), "https://") || os.Getenv("APP_ENV") == "production"
+}
+
+// SessionStore envuelve el driver configurado y entrega una sesión nueva cuando la existente no se puede recuperar
+// (llave rotada, cookie alterada o sesión eliminada por el GC) en lugar de devolver un error
+type SessionStore struct {
+	sessions.Store
+}
+
+func (s *SessionStore) Get(request *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(request).Get(s, name)
+}
+
+func (s *SessionStore) New(request *http.Request, name string) (*sessions.Session, error) {
+	session, err := s.Store.New(request, name)
+	if err != nil || session == nil {
+		session = sessions.NewSession(s, name)
+		options := *sessionOptions()
+		session.Options = &options
+		session.IsNew = true
+	}
+	return session, nil
+}
+
+// DestroySession elimina del servidor la sesión con el ID indicado, si el driver lo permite
+func DestroySession(id string) error {
+	if id == "" {
+		return nil
+	}
+	if destroyer, ok := GetSessionStore().Store.(sessionDestroyer); ok {
+		return destroyer.Destroy(id)
+	}
+	return nil
+}
+
+// CollectSessionGarbage elimina las sesiones inactivas por más de SESSION_LIFETIME
+func CollectSessionGarbage() (int64, error) {
+	if collector, ok := GetSessionStore().Store.(sessionCollector); ok {
+		return collector.GC(SessionLifetime())
+	}
+	return 0, nil
+}
+
+// newCookieSessionStore guarda la sesión completa en la cookie, firmada y cifrada
+func newCookieSessionStore(options *sessions.Options, keyPairs ...[]byte) sessions.Store {
+	store := sessions.NewCookieStore(keyPairs...)
+	store.Options = options
+	store.MaxAge(options.MaxAge)
+	return store
+}
+
+// SessionFilesPath es el directorio del driver file
+const SessionFilesPath = "storage/sessions"
+
+// fileSessionStore guarda la sesión en storage/sessions; la cookie solo lleva el ID
+type fileSessionStore struct {
+	*sessions.FilesystemStore
+	path string
+}
+
+func newFileSessionStore(options *sessions.Options, keyPairs ...[]byte) sessions.Store {
+	if err := os.MkdirAll(SessionFilesPath, 0700); err != nil {
+		Logs("ERROR", "No se pudo crear el directorio de sesiones: "+err.Error())
+	}
+
+	store := sessions.NewFilesystemStore(SessionFilesPath, keyPairs...)
+	store.Options = options
+	store.MaxAge(options.MaxAge)
+	store.MaxLength(0)
+	return &fileSessionStore{FilesystemStore: store, path: SessionFilesPath}
+}
+
+// Destroy elimina el archivo de una sesión
+func (s *fileSessionStore) Destroy(id string) error {
+	err := os.Remove(filepath.Join(s.path, "session_"+filepath.Base(id)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// GC elimina los archivos de sesión modificados hace más de maxLifetime
+func (s *fileSessionStore) GC(maxLifetime time.Duration) (int64, error) {
+	files, err := filepath.Glob(filepath.Join(s.path, "session_*"))
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	cutoff := time.Now().Add(-maxLifetime)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(file); err == nil {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -3,18 +3,18 @@ package utils
 import (
 	"net/http"
 	"semita/app/structs"
+	"strconv"
 	"sync"
-
-	"github.com/gorilla/sessions"
+	"time"
 )
 
 var sessionStoreOnce sync.Once
-var sessionStore *sessions.CookieStore
+var sessionStore *SessionStore
 
-func GetSessionStore() *sessions.CookieStore {
+// GetSessionStore devuelve el store del driver configurado en SESSION_DRIVER (cookie, file o database)
+func GetSessionStore() *SessionStore {
 	sessionStoreOnce.Do(func() {
-		appKey := GetEnv("APP_KEY")
-		sessionStore = sessions.NewCookieStore([]byte(appKey))
+		sessionStore = newSessionStore()
 	})
 	return sessionStore
 }
@@ -26,19 +26,31 @@ func LoginUserSession(response http.ResponseWriter, request *http.Request, user
 		return sessionError
 	}
 
+	// Regenerar el ID de la sesión para evitar la fijación de sesión
+	previousID := session.ID
+	session.ID = ""
+
 	session.Values["user_id"] = user.ID
 	session.Values["user_name"] = user.Name
 	session.Values["user_email"] = user.Email
 	session.Values["authenticated"] = true
+	session.Values["password_hash"] = PasswordFingerprint(user.Password)
+	session.Values["session_epoch"] = user.SessionEpoch
+	clearTwoFactorChallenge(session.Values)
+	delete(session.Values, currentTeamSessionKey)
+
+	// Un token CSRF nuevo por cada inicio de sesión
+	if csrfToken, err := GenerateRandomToken(20); err == nil {
+		session.Values[csrfSessionKey] = csrfToken
+	}
+
+	session.Options = sessionOptions()
 
-	session.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   84400 * 7, // 7 days
-		HttpOnly: true,
-		Secure:   false, // Set to true if using HTTPS
+	if err := session.Save(request, response); err != nil {
+		return err
 	}
 
-	return session.Save(request, response)
+	return DestroySession(previousID)
 }
 
 func GetAuthenticatedUser(request *http.Request) (structs.UserStruct, bool) {
@@ -72,13 +84,158 @@ func LogoutUserSession(response http.ResponseWriter, request *http.Request) erro
 	session.Values["user_name"] = nil
 	session.Values["user_email"] = nil
 	session.Values["authenticated"] = false
+	session.Values["password_hash"] = nil
+	session.Values["session_epoch"] = nil
 
 	session.Options.MaxAge = -1
 
 	return session.Save(request, response)
 }
 
+// twoFactorChallengeLifetime es el tiempo que tiene el usuario para enviar el segundo factor tras la contraseña
+const twoFactorChallengeLifetime = 5 * time.Minute
+
+// StartTwoFactorChallenge guarda en la sesión al usuario que superó la contraseña y todavía debe
+// enviar su segundo factor. La sesión no queda autenticada hasta completar el desafío.
+func StartTwoFactorChallenge(response http.ResponseWriter, request *http.Request, userID int, remember bool) error {
+	session, err := GetSessionStore().Get(request, "user-session")
+	if err != nil {
+		return err
+	}
+
+	session.Values["two_factor_user_id"] = userID
+	session.Values["two_factor_remember"] = remember
+	session.Values["two_factor_expires"] = time.Now().Add(twoFactorChallengeLifetime).Unix()
+
+	return session.Save(request, response)
+}
+
+// PendingTwoFactorUser devuelve el usuario con un desafío de segundo factor vigente
+func PendingTwoFactorUser(request *http.Request) (userID int, remember bool, ok bool) {
+	session, err := GetSessionStore().Get(request, "user-session")
+	if err != nil {
+		return 0, false, false
+	}
+
+	userID, ok = session.Values["two_factor_user_id"].(int)
+	expires, _ := session.Values["two_factor_expires"].(int64)
+	if !ok || time.Now().Unix() > expires {
+		return 0, false, false
+	}
+
+	remember, _ = session.Values["two_factor_remember"].(bool)
+	return userID, remember, true
+}
+
+func clearTwoFactorChallenge(values map[interface{}]interface{}) {
+	delete(values, "two_factor_user_id")
+	delete(values, "two_factor_remember")
+	delete(values, "two_factor_expires")
+}
+
+// PutSessionValue guarda un valor temporal en la sesión web, por ejemplo un desafío de WebAuthn
+func PutSessionValue(response http.ResponseWriter, request *http.Request, key string, value string) error {
+	session, err := GetSessionStore().Get(request, "user-session")
+	if err != nil {
+		return err
+	}
+	session.Values[key] = value
+	return session.Save(request, response)
+}
+
+// PullSessionValue obtiene un valor de la sesión y lo elimina, para que solo se pueda usar una vez
+func PullSessionValue(response http.ResponseWriter, request *http.Request, key string) string {
+	session, err := GetSessionStore().Get(request, "user-session")
+	if err != nil {
+		return ""
+	}
+
+	value, ok := session.Values[key].(string)
+	if !ok {
+		return ""
+	}
+
+	delete(session.Values, key)
+	if err := session.Save(request, response); err != nil {
+		Logs("ERROR", "No se pudo guardar la sesión: "+err.Error())
+	}
+	return value
+}
+
+// currentTeamSessionKey guarda en la sesión web el equipo elegido por el usuario
+const currentTeamSessionKey = "current_team_id"
+
+// SetCurrentTeam guarda el equipo actual en la sesión web; con 0 se vuelve al contexto sin equipo
+func SetCurrentTeam(response http.ResponseWriter, request *http.Request, teamID int) error {
+	session, err := GetSessionStore().Get(request, "user-session")
+	if err != nil {
+		return err
+	}
+	if teamID == 0 {
+		delete(session.Values, currentTeamSessionKey)
+	} else {
+		session.Values[currentTeamSessionKey] = teamID
+	}
+	return session.Save(request, response)
+}
+
+// CurrentTeam devuelve el equipo guardado en la sesión web, o 0 si no hay
+func CurrentTeam(request *http.Request) int {
+	session, err := GetSessionStore().Get(request, "user-session")
+	if err != nil {
+		return 0
+	}
+	teamID, _ := session.Values[currentTeamSessionKey].(int)
+	return teamID
+}
+
+// CurrentSessionID devuelve el ID de la sesión web de la petición; vacío con el driver cookie
+func CurrentSessionID(request *http.Request) string {
+	var session, sessionError = GetSessionStore().Get(request, "user-session")
+	if sessionError != nil {
+		return ""
+	}
+	return session.ID
+}
+
 func IsUserAuthenticated(request *http.Request) bool {
 	_, authenticated := GetAuthenticatedUser(request)
 	return authenticated
 }
+
+// SessionLifetime es la duración de la sesión web (SESSION_LIFETIME, en minutos, por defecto 120)
+func SessionLifetime() time.Duration {
+	minutes, err := strconv.Atoi(GetEnv("SESSION_LIFETIME"))
+	if err != nil || minutes <= 0 {
+		minutes = 120
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// PasswordFingerprint resume el hash de la contraseña para detectar cambios sin guardarlo en la sesión
+func PasswordFingerprint(passwordHash string) string {
+	return HashToken("session:" + passwordHash)
+}
+
+// SessionPasswordFingerprint devuelve la huella de contraseña guardada al iniciar sesión
+func SessionPasswordFingerprint(request *http.Request) string {
+	var session, sessionError = GetSessionStore().Get(request, "user-session")
+	if sessionError != nil {
+		return ""
+	}
+
+	fingerprint, _ := session.Values["password_hash"].(string)
+	return fingerprint
+}
+
+// SessionEpoch devuelve la versión de las sesiones del usuario guardada al iniciar sesión; las
+// sesiones anteriores a la versión no la tienen y cuentan como la versión 0
+func SessionEpoch(request *http.Request) int {
+	var session, sessionError = GetSessionStore().Get(request, "user-session")
+	if sessionError != nil {
+		return -1
+	}
+
+	epoch, _ := session.Values["session_epoch"].(int)
+	return epoch
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature se devuelve cuando la firma no coincide con la URL
+	ErrInvalidSignature = errors.New("firma inválida")
+	// ErrExpiredSignature se devuelve cuando la URL firmada ya expiró
+	ErrExpiredSignature = errors.New("la URL firmada expiró")
+)
+
+// URLSigner firma y valida URLs temporales con una llave HMAC.
+// Las URLs absolutas incluyen APP_URL en la firma; las relativas solo la ruta y los parámetros.
+type URLSigner struct {
+	key []byte
+}
+
+// NewURLSigner crea un firmador con la llave indicada. Con una llave vacía el firmador no firma ni
+// valida: devuelve ErrMissingAppKey.
+func NewURLSigner(key string) *URLSigner {
+	return &URLSigner{key: []byte(key)}
+}
+
+// DefaultURLSigner devuelve el firmador basado en APP_KEY; si APP_KEY no está generada el firmador
+// rechaza firmar y validar
+func DefaultURLSigner() *URLSigner {
+	key, _ := AppKey()
+	return NewURLSigner(key)
+}
+
+// Sign genera una URL relativa firmada. Con ttl 0 el enlace no expira.
+func (s *URLSigner) Sign(route string, params url.Values, ttl time.Duration) (string, error) {
+	return s.sign(route, params, ttl, false)
+}
+
+// SignAbsolute genera una URL absoluta firmada sobre APP_URL. Con ttl 0 el enlace no expira.
+func (s *URLSigner) SignAbsolute(route string, params url.Values, ttl time.Duration) (string, error) {
+	signed, err := s.sign(route, params, ttl, true)
+	if err != nil {
+		return "", err
+	}
+	return AppURL() + signed, nil
+}
+
+// Validate comprueba la firma y la expiración de una URL generada por el firmador.
+// Acepta tanto la URL completa como la URL de la petición, que no incluye el host.
+func (s *URLSigner) Validate(u *url.URL, absolute bool) error {
+	if len(s.key) == 0 {
+		return ErrMissingAppKey
+	}
+
+	query := u.Query()
+	signature := query.Get("signature")
+	if signature == "" {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.signature(u.Path, query, absolute))) {
+		return ErrInvalidSignature
+	}
+
+	if expires := query.Get("expires"); expires != "" {
+		timestamp, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		if time.Now().Unix() > timestamp {
+			return ErrExpiredSignature
+		}
+	}
+
+	return nil
+}
+
+// sign agrega la expiración y la firma a la ruta, conservando los parámetros que ya tuviera
+func (s *URLSigner) sign(route string, params url.Values, ttl time.Duration, absolute bool) (string, error) {
+	if len(s.key) == 0 {
+		return "", ErrMissingAppKey
+	}
+	if !strings.HasPrefix(route, "/") {
+		route = "/" + route
+	}
+
+	// La firma se calcula sobre la ruta decodificada, igual que la que recibe Validate
+	u, err := url.Parse(route)
+	if err != nil {
+		u = &url.URL{Path: route}
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Del("signature")
+	if ttl != 0 {
+		query.Set("expires", strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+	}
+	query.Set("signature", s.signature(u.Path, query, absolute))
+
+	return u.EscapedPath() + "?" + query.Encode(), nil
+}
+
+// signature calcula el HMAC-SHA256 de la ruta y los parámetros ordenados, sin incluir la firma
+func (s *URLSigner) signature(path string, query url.Values, absolute bool) string {
+	unsigned := url.Values{}
+	for key, values := range query {
+		if key != "signature" {
+			unsigned[key] = values
+		}
+	}
+
+	payload := path + "?" + unsigned.Encode()
+	if absolute {
+		payload = AppURL() + payload
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign genera una URL relativa firmada con APP_KEY
+func Sign(route string, params url.Values, ttl time.Duration) (string, error) {
+	return DefaultURLSigner().Sign(route, params, ttl)
+}
+
+// SignedURL genera una URL absoluta firmada con APP_KEY, apta para enviarse por correo
+func SignedURL(route string, params url.Values, ttl time.Duration) (string, error) {
+	return DefaultURLSigner().SignAbsolute(route, params, ttl)
+}
+
+// ValidateSignature valida una URL absoluta generada con SignedURL
+func ValidateSignature(u *url.URL) error {
+	return DefaultURLSigner().Validate(u, true)
+}
+
+// ValidateRelativeSignature valida una URL relativa generada con Sign
+func ValidateRelativeSignature(u *url.URL) error {
+	return DefaultURLSigner().Validate(u, false)
+}
@@ -17,6 +17,15 @@ func Commands() {
 	RootCmd.AddCommand(commands.KeyGenerateCmd)
 	RootCmd.AddCommand(commands.OauthKeysCmd)
 	RootCmd.AddCommand(commands.OauthClientCmd)
+	RootCmd.AddCommand(commands.OauthPurgeCmd)
+	RootCmd.AddCommand(commands.AuthClearResetsCmd)
+	RootCmd.AddCommand(commands.SessionGcCmd)
+	RootCmd.AddCommand(commands.ThrottleGcCmd)
+	RootCmd.AddCommand(commands.PermissionCacheResetCmd)
+	RootCmd.AddCommand(commands.PermissionExpiringCmd)
+	RootCmd.AddCommand(commands.PermissionExpireCmd)
+	RootCmd.AddCommand(commands.RbacExportCmd)
+	RootCmd.AddCommand(commands.RbacApplyCmd)
 	RootCmd.AddCommand(commands.SeedAllCommand)
 	RootCmd.AddCommand(commands.SeedRunCommand)
 
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// RateLimiter define un limitador con nombre para middleware.RateLimit
+type RateLimiter struct {
+	MaxAttempts int           // Peticiones permitidas dentro de la ventana
+	Window      time.Duration // Duración de la ventana deslizante
+	By          string        // Clave del contador: ip, user (user_id del token) o client (client_id del token)
+}
+
+// RateLimiters devuelve los limitadores disponibles. Los límites se leen de RATE_LIMIT_* en cada
+// llamada, después de cargar el .env. Los que cuentan por user o client deben ir después de
+// AuthMiddleware; si la petición no trae token se cuenta por IP.
+func RateLimiters() map[string]RateLimiter {
+	return map[string]RateLimiter{
+		// Rutas públicas de la API propensas a abuso: registro, recuperación de contraseña
+		"auth": {MaxAttempts: rateLimitPerMinute("RATE_LIMIT_AUTH", 10), Window: time.Minute, By: "ip"},
+		// Rutas autenticadas de la API, por usuario
+		"api": {MaxAttempts: rateLimitPerMinute("RATE_LIMIT_API", 60), Window: time.Minute, By: "user"},
+		// Rutas autenticadas de la API, por cliente OAuth
+		"client": {MaxAttempts: rateLimitPerMinute("RATE_LIMIT_CLIENT", 600), Window: time.Minute, By: "client"},
+	}
+}
+
+// rateLimitPerMinute lee el límite por minuto de una variable de entorno
+func rateLimitPerMinute(key string, fallback int) int {
+	limit, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || limit < 1 {
+		return fallback
+	}
+	return limit
+}
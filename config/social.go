@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"semita/app/core/social"
+	"semita/app/utils"
+	"strings"
+)
+
+// socialPresets son los valores por defecto de los proveedores conocidos. Cualquier campo se puede
+// sobrescribir con SOCIAL_<NOMBRE>_<CAMPO>, y un nombre sin preset (p. ej. keycloak) se configura
+// solo con variables de entorno, normalmente con SOCIAL_<NOMBRE>_ISSUER.
+var socialPresets = map[string]social.Config{
+	"google": {
+		Label:  "Google",
+		Issuer: "https://accounts.google.com",
+		Scopes: []string{"openid", "email", "profile"},
+	},
+	"github": {
+		Label:       "GitHub",
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		EmailsURL:   "https://api.github.com/user/emails",
+		Scopes:      []string{"read:user", "user:email"},
+	},
+	"microsoft": {
+		Label:  "Microsoft",
+		Scopes: []string{"openid", "email", "profile"},
+	},
+}
+
+// SocialProviders devuelve los proveedores habilitados en SOCIAL_PROVIDERS (separados por coma),
+// en ese orden. Se omiten los que no tienen SOCIAL_<NOMBRE>_CLIENT_ID.
+func SocialProviders() []social.Config {
+	var providers []social.Config
+	for _, name := range strings.Split(os.Getenv("SOCIAL_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		provider := socialProvider(name)
+		if provider.ClientID == "" {
+			utils.Logs("WARNING", "El proveedor social "+name+" no tiene CLIENT_ID y se ignora")
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// socialProvider arma la configuración de un proveedor a partir del preset y las variables de entorno
+func socialProvider(name string) social.Config {
+	provider := socialPresets[name]
+	provider.Name = name
+	if name == "microsoft" {
+		microsoftEndpoints(&provider)
+	}
+
+	prefix := "SOCIAL_" + strings.ToUpper(name) + "_"
+	override := func(field *string, key string) {
+		if value := os.Getenv(prefix + key); value != "" {
+			*field = value
+		}
+	}
+	override(&provider.Label, "LABEL")
+	override(&provider.ClientID, "CLIENT_ID")
+	override(&provider.ClientSecret, "CLIENT_SECRET")
+	override(&provider.Issuer, "ISSUER")
+	override(&provider.AuthURL, "AUTH_URL")
+	override(&provider.TokenURL, "TOKEN_URL")
+	override(&provider.UserInfoURL, "USERINFO_URL")
+	override(&provider.JWKSURL, "JWKS_URL")
+	override(&provider.EmailsURL, "EMAILS_URL")
+
+	if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+		provider.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+	} else if len(provider.Scopes) == 0 && provider.Issuer != "" {
+		provider.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	provider.RedirectURL = utils.AppURL() + "/auth/social/" + name + "/callback"
+	override(&provider.RedirectURL, "REDIRECT_URL")
+	return provider
+}
+
+// microsoftEndpoints configura Microsoft Entra ID según SOCIAL_MICROSOFT_TENANT. Con un tenant
+// concreto se usa descubrimiento OpenID Connect; con common, organizations o consumers el issuer
+// del id_token varía por cuenta, así que el perfil se toma solo del endpoint userinfo.
+func microsoftEndpoints(provider *social.Config) {
+	tenant := os.Getenv("SOCIAL_MICROSOFT_TENANT")
+	if tenant == "" {
+		tenant = "common"
+	}
+
+	base := "https://login.microsoftonline.com/" + tenant
+	switch tenant {
+	case "common", "organizations", "consumers":
+		provider.AuthURL = base + "/oauth2/v2.0/authorize"
+		provider.TokenURL = base + "/oauth2/v2.0/token"
+		provider.UserInfoURL = "https://graph.microsoft.com/oidc/userinfo"
+	default:
+		provider.Issuer = base + "/v2.0"
+	}
+}
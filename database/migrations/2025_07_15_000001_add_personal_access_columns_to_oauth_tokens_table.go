@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type AddPersonalAccessColumnsToOAuthTokensTable struct {
+	database.BaseMigration
+}
+
+func NewAddPersonalAccessColumnsToOAuthTokensTable() *AddPersonalAccessColumnsToOAuthTokensTable {
+	return &AddPersonalAccessColumnsToOAuthTokensTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_personal_access_columns_to_oauth_tokens_table",
+			Timestamp: "2025_07_15_000001",
+		},
+	}
+}
+
+func (m *AddPersonalAccessColumnsToOAuthTokensTable) Up(db *sql.DB) error {
+	query := `
+		ALTER TABLE oauth_tokens
+			ADD COLUMN name VARCHAR(255) NULL AFTER client_id,
+			ADD COLUMN last_used_at DATETIME NULL AFTER expires_at
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *AddPersonalAccessColumnsToOAuthTokensTable) Down(db *sql.DB) error {
+	_, err := db.Exec("ALTER TABLE oauth_tokens DROP COLUMN name, DROP COLUMN last_used_at")
+	return err
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateSessionsTable struct {
+	database.BaseMigration
+}
+
+func NewCreateSessionsTable() *CreateSessionsTable {
+	return &CreateSessionsTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_sessions_table",
+			Timestamp: "2025_07_15_000002",
+		},
+	}
+}
+
+func (m *CreateSessionsTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE sessions (
+			id VARCHAR(128) NOT NULL PRIMARY KEY,
+			user_id BIGINT UNSIGNED NULL,
+			ip_address VARCHAR(45) NULL,
+			user_agent TEXT NULL,
+			payload MEDIUMTEXT NOT NULL,
+			last_activity INT UNSIGNED NOT NULL,
+			INDEX idx_sessions_user_id (user_id),
+			INDEX idx_sessions_last_activity (last_activity)
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateSessionsTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS sessions")
+	return err
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type AddDeviceColumnsToOAuthTokensTable struct {
+	database.BaseMigration
+}
+
+func NewAddDeviceColumnsToOAuthTokensTable() *AddDeviceColumnsToOAuthTokensTable {
+	return &AddDeviceColumnsToOAuthTokensTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_device_columns_to_oauth_tokens_table",
+			Timestamp: "2025_07_15_000003",
+		},
+	}
+}
+
+func (m *AddDeviceColumnsToOAuthTokensTable) Up(db *sql.DB) error {
+	query := `
+		ALTER TABLE oauth_tokens
+			ADD COLUMN ip_address VARCHAR(45) NULL AFTER last_used_at,
+			ADD COLUMN user_agent TEXT NULL AFTER ip_address
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *AddDeviceColumnsToOAuthTokensTable) Down(db *sql.DB) error {
+	_, err := db.Exec("ALTER TABLE oauth_tokens DROP COLUMN ip_address, DROP COLUMN user_agent")
+	return err
+}
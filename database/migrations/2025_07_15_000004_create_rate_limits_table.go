@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateRateLimitsTable struct {
+	database.BaseMigration
+}
+
+func NewCreateRateLimitsTable() *CreateRateLimitsTable {
+	return &CreateRateLimitsTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_rate_limits_table",
+			Timestamp: "2025_07_15_000004",
+		},
+	}
+}
+
+func (m *CreateRateLimitsTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE rate_limits (
+			throttle_key VARCHAR(191) NOT NULL PRIMARY KEY,
+			attempts INT UNSIGNED NOT NULL DEFAULT 0,
+			reset_at INT UNSIGNED NOT NULL,
+			INDEX idx_rate_limits_reset_at (reset_at)
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateRateLimitsTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS rate_limits")
+	return err
+}
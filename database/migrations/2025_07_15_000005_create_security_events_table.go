@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateSecurityEventsTable struct {
+	database.BaseMigration
+}
+
+func NewCreateSecurityEventsTable() *CreateSecurityEventsTable {
+	return &CreateSecurityEventsTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_security_events_table",
+			Timestamp: "2025_07_15_000005",
+		},
+	}
+}
+
+func (m *CreateSecurityEventsTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE security_events (
+			id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+			user_id BIGINT UNSIGNED NULL,
+			event VARCHAR(100) NOT NULL,
+			email VARCHAR(255) NULL,
+			ip_address VARCHAR(45) NULL,
+			user_agent TEXT NULL,
+			details TEXT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_security_events_user_id (user_id),
+			INDEX idx_security_events_event (event),
+			INDEX idx_security_events_created_at (created_at)
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateSecurityEventsTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS security_events")
+	return err
+}
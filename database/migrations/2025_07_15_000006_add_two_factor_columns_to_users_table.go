@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type AddTwoFactorColumnsToUsersTable struct {
+	database.BaseMigration
+}
+
+func NewAddTwoFactorColumnsToUsersTable() *AddTwoFactorColumnsToUsersTable {
+	return &AddTwoFactorColumnsToUsersTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_two_factor_columns_to_users_table",
+			Timestamp: "2025_07_15_000006",
+		},
+	}
+}
+
+func (m *AddTwoFactorColumnsToUsersTable) Up(db *sql.DB) error {
+	query := `
+		ALTER TABLE users
+			ADD COLUMN two_factor_secret TEXT NULL AFTER password,
+			ADD COLUMN two_factor_recovery_codes TEXT NULL AFTER two_factor_secret,
+			ADD COLUMN two_factor_confirmed_at DATETIME NULL AFTER two_factor_recovery_codes,
+			ADD COLUMN two_factor_last_step BIGINT NULL AFTER two_factor_confirmed_at
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *AddTwoFactorColumnsToUsersTable) Down(db *sql.DB) error {
+	_, err := db.Exec(`ALTER TABLE users DROP COLUMN two_factor_secret, DROP COLUMN two_factor_recovery_codes,
+		DROP COLUMN two_factor_confirmed_at, DROP COLUMN two_factor_last_step`)
+	return err
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type AddRequiresTwoFactorToRolesTable struct {
+	database.BaseMigration
+}
+
+func NewAddRequiresTwoFactorToRolesTable() *AddRequiresTwoFactorToRolesTable {
+	return &AddRequiresTwoFactorToRolesTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_requires_two_factor_to_roles_table",
+			Timestamp: "2025_07_15_000007",
+		},
+	}
+}
+
+func (m *AddRequiresTwoFactorToRolesTable) Up(db *sql.DB) error {
+	_, err := db.Exec("ALTER TABLE roles ADD COLUMN requires_two_factor BOOLEAN NOT NULL DEFAULT FALSE AFTER description")
+	if err != nil {
+		return err
+	}
+
+	// Los super-admin existentes quedan obligados a usar 2FA
+	_, err = db.Exec("UPDATE roles SET requires_two_factor = TRUE WHERE name = 'super-admin'")
+	return err
+}
+
+func (m *AddRequiresTwoFactorToRolesTable) Down(db *sql.DB) error {
+	_, err := db.Exec("ALTER TABLE roles DROP COLUMN requires_two_factor")
+	return err
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateWebAuthnCredentialsTable struct {
+	database.BaseMigration
+}
+
+func NewCreateWebAuthnCredentialsTable() *CreateWebAuthnCredentialsTable {
+	return &CreateWebAuthnCredentialsTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_webauthn_credentials_table",
+			Timestamp: "2025_07_15_000008",
+		},
+	}
+}
+
+func (m *CreateWebAuthnCredentialsTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE webauthn_credentials (
+			id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			credential_id VARCHAR(255) NOT NULL UNIQUE,
+			public_key BLOB NOT NULL,
+			sign_count INT UNSIGNED NOT NULL DEFAULT 0,
+			aaguid VARCHAR(36) NULL,
+			attestation_format VARCHAR(32) NOT NULL,
+			backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+			last_used_at DATETIME NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_webauthn_credentials_user_id (user_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateWebAuthnCredentialsTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS webauthn_credentials")
+	return err
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateUserIdentitiesTable struct {
+	database.BaseMigration
+}
+
+func NewCreateUserIdentitiesTable() *CreateUserIdentitiesTable {
+	return &CreateUserIdentitiesTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_user_identities_table",
+			Timestamp: "2025_07_15_000009",
+		},
+	}
+}
+
+func (m *CreateUserIdentitiesTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE user_identities (
+			id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			provider VARCHAR(50) NOT NULL,
+			provider_user_id VARCHAR(255) NOT NULL,
+			email VARCHAR(255) NULL,
+			name VARCHAR(255) NULL,
+			avatar_url VARCHAR(1024) NULL,
+			access_token TEXT NULL,
+			refresh_token TEXT NULL,
+			expires_at DATETIME NULL,
+			last_login_at DATETIME NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			UNIQUE KEY uq_user_identities_provider_user (provider, provider_user_id),
+			UNIQUE KEY uq_user_identities_user_provider (user_id, provider),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateUserIdentitiesTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS user_identities")
+	return err
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateCacheVersionsTable struct {
+	database.BaseMigration
+}
+
+func NewCreateCacheVersionsTable() *CreateCacheVersionsTable {
+	return &CreateCacheVersionsTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_cache_versions_table",
+			Timestamp: "2025_07_15_000010",
+		},
+	}
+}
+
+func (m *CreateCacheVersionsTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE cache_versions (
+			cache_key VARCHAR(100) PRIMARY KEY,
+			version BIGINT UNSIGNED NOT NULL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateCacheVersionsTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS cache_versions")
+	return err
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateRoleParentsTable struct {
+	database.BaseMigration
+}
+
+func NewCreateRoleParentsTable() *CreateRoleParentsTable {
+	return &CreateRoleParentsTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_role_parents_table",
+			Timestamp: "2025_07_15_000011",
+		},
+	}
+}
+
+func (m *CreateRoleParentsTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE role_parents (
+			id INT PRIMARY KEY AUTO_INCREMENT,
+			role_id INT NOT NULL,
+			parent_role_id INT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+			FOREIGN KEY (parent_role_id) REFERENCES roles(id) ON DELETE CASCADE,
+			UNIQUE KEY unique_role_parent (role_id, parent_role_id),
+			INDEX idx_role_parents_parent_role_id (parent_role_id)
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateRoleParentsTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS role_parents")
+	return err
+}
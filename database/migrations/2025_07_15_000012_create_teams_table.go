@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateTeamsTable struct {
+	database.BaseMigration
+}
+
+func NewCreateTeamsTable() *CreateTeamsTable {
+	return &CreateTeamsTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_teams_table",
+			Timestamp: "2025_07_15_000012",
+		},
+	}
+}
+
+func (m *CreateTeamsTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE teams (
+			id INT PRIMARY KEY AUTO_INCREMENT,
+			name VARCHAR(255) NOT NULL,
+			owner_id INT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+			INDEX idx_teams_owner_id (owner_id)
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateTeamsTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS teams")
+	return err
+}
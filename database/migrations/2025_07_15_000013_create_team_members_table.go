@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateTeamMembersTable struct {
+	database.BaseMigration
+}
+
+func NewCreateTeamMembersTable() *CreateTeamMembersTable {
+	return &CreateTeamMembersTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_team_members_table",
+			Timestamp: "2025_07_15_000013",
+		},
+	}
+}
+
+func (m *CreateTeamMembersTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE team_members (
+			id INT PRIMARY KEY AUTO_INCREMENT,
+			team_id INT NOT NULL,
+			user_id INT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			UNIQUE KEY unique_team_member (team_id, user_id),
+			INDEX idx_team_members_user_id (user_id)
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateTeamMembersTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS team_members")
+	return err
+}
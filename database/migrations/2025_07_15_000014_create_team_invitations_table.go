@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type CreateTeamInvitationsTable struct {
+	database.BaseMigration
+}
+
+func NewCreateTeamInvitationsTable() *CreateTeamInvitationsTable {
+	return &CreateTeamInvitationsTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "create_team_invitations_table",
+			Timestamp: "2025_07_15_000014",
+		},
+	}
+}
+
+func (m *CreateTeamInvitationsTable) Up(db *sql.DB) error {
+	query := `
+		CREATE TABLE team_invitations (
+			id INT PRIMARY KEY AUTO_INCREMENT,
+			team_id INT NOT NULL,
+			email VARCHAR(255) NOT NULL,
+			role_id INT NULL,
+			token CHAR(64) NOT NULL,
+			invited_by INT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+			FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE SET NULL,
+			FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+			UNIQUE KEY unique_team_invitation_token (token),
+			UNIQUE KEY unique_team_invitation_email (team_id, email)
+		)
+	`
+	_, err := db.Exec(query)
+	return err
+}
+
+func (m *CreateTeamInvitationsTable) Down(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE IF EXISTS team_invitations")
+	return err
+}
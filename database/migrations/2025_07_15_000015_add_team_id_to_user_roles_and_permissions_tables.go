@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type AddTeamIDToUserRolesAndPermissionsTables struct {
+	database.BaseMigration
+}
+
+func NewAddTeamIDToUserRolesAndPermissionsTables() *AddTeamIDToUserRolesAndPermissionsTables {
+	return &AddTeamIDToUserRolesAndPermissionsTables{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_team_id_to_user_roles_and_permissions_tables",
+			Timestamp: "2025_07_15_000015",
+		},
+	}
+}
+
+// Up agrega team_id (0 = asignación global) y amplía las claves únicas para que el mismo rol o
+// permiso pueda asignarse en varios equipos. team_id no admite NULL porque MySQL considera distintos
+// los NULL de una clave única y permitiría duplicar las asignaciones globales; por eso tampoco es una
+// clave foránea, y DeleteTeam borra las asignaciones del equipo.
+func (m *AddTeamIDToUserRolesAndPermissionsTables) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE user_roles
+			ADD COLUMN team_id INT NOT NULL DEFAULT 0 AFTER role_id,
+			ADD INDEX idx_user_roles_team_id (team_id),
+			DROP INDEX unique_user_role,
+			ADD UNIQUE KEY unique_user_role (user_id, role_id, team_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE user_permissions
+			ADD COLUMN team_id INT NOT NULL DEFAULT 0 AFTER permission_id,
+			ADD INDEX idx_user_permissions_team_id (team_id),
+			DROP INDEX unique_user_permission,
+			ADD UNIQUE KEY unique_user_permission (user_id, permission_id, team_id)
+	`)
+	return err
+}
+
+// Down descarta las asignaciones por equipo antes de restaurar las claves únicas originales
+func (m *AddTeamIDToUserRolesAndPermissionsTables) Down(db *sql.DB) error {
+	statements := []string{
+		"DELETE FROM user_roles WHERE team_id <> 0",
+		"DELETE FROM user_permissions WHERE team_id <> 0",
+		`ALTER TABLE user_roles DROP INDEX unique_user_role, DROP INDEX idx_user_roles_team_id,
+			DROP COLUMN team_id, ADD UNIQUE KEY unique_user_role (user_id, role_id)`,
+		`ALTER TABLE user_permissions DROP INDEX unique_user_permission, DROP INDEX idx_user_permissions_team_id,
+			DROP COLUMN team_id, ADD UNIQUE KEY unique_user_permission (user_id, permission_id)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type AddValidityWindowToUserRolesAndPermissionsTables struct {
+	database.BaseMigration
+}
+
+func NewAddValidityWindowToUserRolesAndPermissionsTables() *AddValidityWindowToUserRolesAndPermissionsTables {
+	return &AddValidityWindowToUserRolesAndPermissionsTables{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_validity_window_to_user_roles_and_permissions_tables",
+			Timestamp: "2025_07_15_000016",
+		},
+	}
+}
+
+// Up agrega la vigencia opcional de las asignaciones: sin starts_at rige desde su creación y sin
+// expires_at no vence
+func (m *AddValidityWindowToUserRolesAndPermissionsTables) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE user_roles
+			ADD COLUMN starts_at DATETIME NULL AFTER team_id,
+			ADD COLUMN expires_at DATETIME NULL AFTER starts_at,
+			ADD INDEX idx_user_roles_expires_at (expires_at)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE user_permissions
+			ADD COLUMN starts_at DATETIME NULL AFTER team_id,
+			ADD COLUMN expires_at DATETIME NULL AFTER starts_at,
+			ADD INDEX idx_user_permissions_expires_at (expires_at)
+	`)
+	return err
+}
+
+func (m *AddValidityWindowToUserRolesAndPermissionsTables) Down(db *sql.DB) error {
+	statements := []string{
+		"ALTER TABLE user_roles DROP INDEX idx_user_roles_expires_at, DROP COLUMN starts_at, DROP COLUMN expires_at",
+		"ALTER TABLE user_permissions DROP INDEX idx_user_permissions_expires_at, DROP COLUMN starts_at, DROP COLUMN expires_at",
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"database/sql"
+	"semita/app/core/database"
+)
+
+type AddSessionEpochToUsersTable struct {
+	database.BaseMigration
+}
+
+func NewAddSessionEpochToUsersTable() *AddSessionEpochToUsersTable {
+	return &AddSessionEpochToUsersTable{
+		BaseMigration: database.BaseMigration{
+			Name:      "add_session_epoch_to_users_table",
+			Timestamp: "2025_07_15_000017",
+		},
+	}
+}
+
+// Up agrega la versión de las sesiones del usuario: cada sesión web guarda la vigente al iniciarse y
+// deja de ser válida cuando la versión cambia, sea cual sea el driver de sesión
+func (m *AddSessionEpochToUsersTable) Up(db *sql.DB) error {
+	_, err := db.Exec(`ALTER TABLE users ADD COLUMN session_epoch INT UNSIGNED NOT NULL DEFAULT 0 AFTER remember_token`)
+	return err
+}
+
+func (m *AddSessionEpochToUsersTable) Down(db *sql.DB) error {
+	_, err := db.Exec(`ALTER TABLE users DROP COLUMN session_epoch`)
+	return err
+}
@@ -0,0 +1,87 @@
+package seeders
+
+import (
+	"log"
+	"semita/app/core/database"
+	"semita/app/models"
+	"semita/config"
+)
+
+// OAuthScopesSeeder seeder para los scopes OAuth que exigen las rutas de la API
+type OAuthScopesSeeder struct {
+	database.BaseSeeder
+}
+
+// NewOAuthScopesSeeder crea una nueva instancia del seeder
+func NewOAuthScopesSeeder() *OAuthScopesSeeder {
+	return &OAuthScopesSeeder{
+		BaseSeeder: database.BaseSeeder{
+			DB:   config.DatabaseConnect(),
+			Name: "oauth_scopes_seeder",
+		},
+	}
+}
+
+// oauthScopes son los scopes de OpenID Connect y los declarados en routes/api.go
+var oauthScopes = []struct {
+	Name        string
+	Description string
+}{
+	{Name: "openid", Description: "Identificar al usuario con OpenID Connect (id_token)"},
+	{Name: "profile", Description: "Acceder al nombre del usuario"},
+	{Name: "email", Description: "Acceder al correo del usuario y su estado de verificación"},
+	{Name: "roles:read", Description: "Consultar roles"},
+	{Name: "roles:write", Description: "Crear, editar, eliminar y asignar roles"},
+	{Name: "permissions:read", Description: "Consultar permisos"},
+	{Name: "permissions:write", Description: "Crear, editar, eliminar y asignar permisos"},
+	{Name: "users:read", Description: "Consultar roles y permisos de usuarios"},
+	{Name: "tokens:read", Description: "Consultar los tokens de acceso personal propios"},
+	{Name: "tokens:write", Description: "Crear y revocar tokens de acceso personal propios"},
+	{Name: "sessions:read", Description: "Consultar las sesiones y tokens activos propios"},
+	{Name: "sessions:write", Description: "Cerrar sesiones y revocar tokens propios"},
+}
+
+// GetName retorna el nombre del seeder
+func (oss *OAuthScopesSeeder) GetName() string {
+	return oss.Name
+}
+
+// GetDependencies retorna las dependencias del seeder
+func (oss *OAuthScopesSeeder) GetDependencies() []string {
+	return []string{} // No tiene dependencias
+}
+
+// Seed ejecuta el seeding de scopes OAuth
+func (oss *OAuthScopesSeeder) Seed() error {
+	log.Println("Seeding OAuth scopes...")
+
+	for _, scope := range oauthScopes {
+		if _, err := models.GetScopeByName(scope.Name); err == nil {
+			log.Printf("Scope '%s' already exists, skipping...", scope.Name)
+			continue
+		}
+
+		if _, err := models.CreateScope(scope.Name, scope.Description); err != nil {
+			log.Printf("Error creating scope '%s': %v", scope.Name, err)
+			continue
+		}
+		log.Printf("Created scope: %s", scope.Name)
+	}
+
+	log.Println("OAuth scopes seeding completed successfully!")
+	return nil
+}
+
+// Rollback revierte el seeding de scopes OAuth
+func (oss *OAuthScopesSeeder) Rollback() error {
+	log.Println("Rolling back OAuth scopes...")
+
+	for _, scope := range oauthScopes {
+		if _, err := oss.DB.Exec(`DELETE FROM oauth_scopes WHERE name = ?`, scope.Name); err != nil {
+			log.Printf("Error deleting scope '%s': %v", scope.Name, err)
+		}
+	}
+
+	log.Println("OAuth scopes rollback completed successfully!")
+	return nil
+}
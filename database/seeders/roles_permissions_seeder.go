@@ -8,6 +8,9 @@ import (
 	"semita/config"
 )
 
+// seededGuards son los guards en los que se crean los roles y permisos básicos
+var seededGuards = []string{"web", "api"}
+
 // RolesPermissionsSeeder seeder para roles y permisos
 type RolesPermissionsSeeder struct {
 	database.BaseSeeder
@@ -37,31 +40,44 @@ func (rps *RolesPermissionsSeeder) GetDependencies() []string {
 func (rps *RolesPermissionsSeeder) Seed() error {
 	log.Println("Seeding roles and permissions...")
 
+	// Los roles y permisos se crean en cada guard: los del guard web no autorizan peticiones con token
+	for _, guardName := range seededGuards {
+		rps.seedGuard(guardName)
+	}
+
+	log.Println("Roles and permissions seeding completed successfully!")
+	return nil
+}
+
+// seedGuard crea los permisos y roles básicos del guard y asigna los permisos de cada rol
+func (rps *RolesPermissionsSeeder) seedGuard(guardName string) {
 	// Crear permisos básicos
 	permissions := []structs.CreatePermissionStruct{
-		{Name: "create-users", GuardName: "web", Description: "Crear usuarios"},
-		{Name: "edit-users", GuardName: "web", Description: "Editar usuarios"},
-		{Name: "delete-users", GuardName: "web", Description: "Eliminar usuarios"},
-		{Name: "view-users", GuardName: "web", Description: "Ver usuarios"},
-		{Name: "create-roles", GuardName: "web", Description: "Crear roles"},
-		{Name: "edit-roles", GuardName: "web", Description: "Editar roles"},
-		{Name: "delete-roles", GuardName: "web", Description: "Eliminar roles"},
-		{Name: "view-roles", GuardName: "web", Description: "Ver roles"},
-		{Name: "assign-roles", GuardName: "web", Description: "Asignar roles"},
-		{Name: "create-permissions", GuardName: "web", Description: "Crear permisos"},
-		{Name: "edit-permissions", GuardName: "web", Description: "Editar permisos"},
-		{Name: "delete-permissions", GuardName: "web", Description: "Eliminar permisos"},
-		{Name: "view-permissions", GuardName: "web", Description: "Ver permisos"},
-		{Name: "assign-permissions", GuardName: "web", Description: "Asignar permisos"},
-		{Name: "manage-posts", GuardName: "web", Description: "Gestionar posts"},
-		{Name: "publish-posts", GuardName: "web", Description: "Publicar posts"},
-		{Name: "edit-posts", GuardName: "web", Description: "Editar posts"},
-		{Name: "delete-posts", GuardName: "web", Description: "Eliminar posts"},
-		{Name: "view-dashboard", GuardName: "web", Description: "Ver dashboard administrativo"},
-		{Name: "manage-settings", GuardName: "web", Description: "Gestionar configuración del sistema"},
-	}
-
-	log.Println("Creating permissions...")
+		{Name: "create-users", GuardName: guardName, Description: "Crear usuarios"},
+		{Name: "edit-users", GuardName: guardName, Description: "Editar usuarios"},
+		{Name: "delete-users", GuardName: guardName, Description: "Eliminar usuarios"},
+		{Name: "view-users", GuardName: guardName, Description: "Ver usuarios"},
+		{Name: "create-roles", GuardName: guardName, Description: "Crear roles"},
+		{Name: "edit-roles", GuardName: guardName, Description: "Editar roles"},
+		{Name: "delete-roles", GuardName: guardName, Description: "Eliminar roles"},
+		{Name: "view-roles", GuardName: guardName, Description: "Ver roles"},
+		{Name: "assign-roles", GuardName: guardName, Description: "Asignar roles"},
+		{Name: "create-permissions", GuardName: guardName, Description: "Crear permisos"},
+		{Name: "edit-permissions", GuardName: guardName, Description: "Editar permisos"},
+		{Name: "delete-permissions", GuardName: guardName, Description: "Eliminar permisos"},
+		{Name: "view-permissions", GuardName: guardName, Description: "Ver permisos"},
+		{Name: "assign-permissions", GuardName: guardName, Description: "Asignar permisos"},
+		{Name: "manage-posts", GuardName: guardName, Description: "Gestionar posts"},
+		{Name: "publish-posts", GuardName: guardName, Description: "Publicar posts"},
+		{Name: "edit-posts", GuardName: guardName, Description: "Editar posts"},
+		{Name: "delete-posts", GuardName: guardName, Description: "Eliminar posts"},
+		{Name: "view-dashboard", GuardName: guardName, Description: "Ver dashboard administrativo"},
+		{Name: "manage-settings", GuardName: guardName, Description: "Gestionar configuración del sistema"},
+		{Name: "manage-team", GuardName: guardName, Description: "Invitar y quitar miembros del equipo"},
+		{Name: "*", GuardName: guardName, Description: "Todos los permisos, incluidos los que se creen después"},
+	}
+
+	log.Printf("Creating %s permissions...", guardName)
 	createdPermissions := make(map[string]*structs.PermissionStruct)
 	for _, permData := range permissions {
 		// Verificar si el permiso ya existe
@@ -83,14 +99,16 @@ func (rps *RolesPermissionsSeeder) Seed() error {
 
 	// Crear roles básicos
 	roles := []structs.CreateRoleStruct{
-		{Name: "super-admin", GuardName: "web", Description: "Super administrador con todos los permisos"},
-		{Name: "admin", GuardName: "web", Description: "Administrador del sistema"},
-		{Name: "editor", GuardName: "web", Description: "Editor de contenido"},
-		{Name: "moderator", GuardName: "web", Description: "Moderador"},
-		{Name: "user", GuardName: "web", Description: "Usuario regular"},
+		{Name: "super-admin", GuardName: guardName, Description: "Super administrador con todos los permisos", RequiresTwoFactor: true},
+		{Name: "admin", GuardName: guardName, Description: "Administrador del sistema"},
+		{Name: "editor", GuardName: guardName, Description: "Editor de contenido"},
+		{Name: "moderator", GuardName: guardName, Description: "Moderador"},
+		{Name: "user", GuardName: guardName, Description: "Usuario regular"},
+		{Name: "team-admin", GuardName: guardName, Description: "Administrador de un equipo"},
+		{Name: "team-member", GuardName: guardName, Description: "Miembro de un equipo"},
 	}
 
-	log.Println("Creating roles...")
+	log.Printf("Creating %s roles...", guardName)
 	createdRoles := make(map[string]*structs.RoleStruct)
 	for _, roleData := range roles {
 		// Verificar si el rol ya existe
@@ -111,7 +129,7 @@ func (rps *RolesPermissionsSeeder) Seed() error {
 	}
 
 	// Asignar permisos a roles
-	log.Println("Assigning permissions to roles...")
+	log.Printf("Assigning %s permissions to roles...", guardName)
 
 	// Super Admin - todos los permisos
 	if superAdmin, exists := createdRoles["super-admin"]; exists {
@@ -180,8 +198,16 @@ func (rps *RolesPermissionsSeeder) Seed() error {
 		log.Println("Assigned moderator permissions to moderator role")
 	}
 
-	log.Println("Roles and permissions seeding completed successfully!")
-	return nil
+	// Team Admin - gestión del equipo donde se asigna
+	if teamAdmin, exists := createdRoles["team-admin"]; exists {
+		if permission, exists := createdPermissions["manage-team"]; exists {
+			err := models.AssignPermissionToRole(teamAdmin.ID, permission.ID)
+			if err != nil {
+				log.Printf("Error assigning permission '%s' to role 'team-admin': %v", permission.Name, err)
+			}
+		}
+		log.Println("Assigned team permissions to team-admin role")
+	}
 }
 
 // Rollback revierte el seeding de roles y permisos
@@ -213,9 +239,9 @@ func (rps *RolesPermissionsSeeder) Rollback() error {
 	}
 
 	// Eliminar roles
-	roleNames := []string{"super-admin", "admin", "editor", "moderator", "user"}
+	roleNames := []string{"super-admin", "admin", "editor", "moderator", "user", "team-admin", "team-member"}
 	for _, roleName := range roleNames {
-		query = `DELETE FROM roles WHERE name = ? AND guard_name = 'web'`
+		query = `DELETE FROM roles WHERE name = ? AND guard_name IN ('web', 'api')`
 		_, err = rps.DB.Exec(query, roleName)
 		if err != nil {
 			log.Printf("Error deleting role '%s': %v", roleName, err)
@@ -230,10 +256,10 @@ func (rps *RolesPermissionsSeeder) Rollback() error {
 		"create-roles", "edit-roles", "delete-roles", "view-roles", "assign-roles",
 		"create-permissions", "edit-permissions", "delete-permissions", "view-permissions", "assign-permissions",
 		"manage-posts", "publish-posts", "edit-posts", "delete-posts",
-		"view-dashboard", "manage-settings",
+		"view-dashboard", "manage-settings", "manage-team", "*",
 	}
 	for _, permName := range permissionNames {
-		query = `DELETE FROM permissions WHERE name = ? AND guard_name = 'web'`
+		query = `DELETE FROM permissions WHERE name = ? AND guard_name IN ('web', 'api')`
 		_, err = rps.DB.Exec(query, permName)
 		if err != nil {
 			log.Printf("Error deleting permission '%s': %v", permName, err)
@@ -242,6 +268,7 @@ func (rps *RolesPermissionsSeeder) Rollback() error {
 		}
 	}
 
+	models.FlushPermissionCache()
 	log.Println("Roles and permissions rollback completed successfully!")
 	return nil
 }
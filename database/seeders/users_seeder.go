@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"semita/app/core/database"
+	"semita/app/models"
 	"semita/config"
 )
 
@@ -135,33 +136,37 @@ func (us *UsersSeeder) Seed() error {
 		}
 	}
 
+	models.FlushPermissionCache()
 	log.Println("Users seeding completed successfully!")
 	return nil
 }
 
-// assignRoleToUser asigna un rol a un usuario
+// assignRoleToUser asigna a un usuario el rol con ese nombre en cada guard sembrado
 func (us *UsersSeeder) assignRoleToUser(userID int, roleName string) error {
-	// Obtener el ID del rol
-	var roleID int
-	roleQuery := `SELECT id FROM roles WHERE name = ? AND guard_name = 'web'`
-	err := us.DB.QueryRow(roleQuery, roleName).Scan(&roleID)
-	if err != nil {
-		return err
-	}
+	for _, guardName := range seededGuards {
+		// Obtener el ID del rol
+		var roleID int
+		roleQuery := `SELECT id FROM roles WHERE name = ? AND guard_name = ?`
+		err := us.DB.QueryRow(roleQuery, roleName, guardName).Scan(&roleID)
+		if err != nil {
+			return err
+		}
 
-	// Verificar si la relación ya existe
-	var existingID int
-	checkQuery := `SELECT id FROM user_roles WHERE user_id = ? AND role_id = ?`
-	err = us.DB.QueryRow(checkQuery, userID, roleID).Scan(&existingID)
+		// Verificar si la relación ya existe
+		var existingID int
+		checkQuery := `SELECT id FROM user_roles WHERE user_id = ? AND role_id = ?`
+		err = us.DB.QueryRow(checkQuery, userID, roleID).Scan(&existingID)
 
-	if err == sql.ErrNoRows {
-		// No existe, crear la relación
-		insertQuery := `INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)`
-		_, err = us.DB.Exec(insertQuery, userID, roleID)
-		return err
+		if err == sql.ErrNoRows {
+			// No existe, crear la relación
+			insertQuery := `INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)`
+			_, err = us.DB.Exec(insertQuery, userID, roleID)
+		}
+		if err != nil {
+			return err
+		}
 	}
-
-	return err
+	return nil
 }
 
 // Rollback revierte el seeding de usuarios
@@ -220,6 +225,7 @@ func (us *UsersSeeder) Rollback() error {
 		}
 	}
 
+	models.FlushPermissionCache()
 	log.Println("Users rollback completed successfully!")
 	return nil
 }
@@ -0,0 +1,148 @@
+// Registro e inicio de sesión con passkeys (WebAuthn).
+// El servidor envía y recibe los campos binarios en base64url.
+(function () {
+    'use strict';
+
+    function toBuffer(value) {
+        var base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+        while (base64.length % 4) {
+            base64 += '=';
+        }
+        var binary = atob(base64);
+        var bytes = new Uint8Array(binary.length);
+        for (var i = 0; i < binary.length; i++) {
+            bytes[i] = binary.charCodeAt(i);
+        }
+        return bytes.buffer;
+    }
+
+    function toBase64URL(buffer) {
+        if (!buffer) {
+            return '';
+        }
+        var bytes = new Uint8Array(buffer);
+        var binary = '';
+        for (var i = 0; i < bytes.length; i++) {
+            binary += String.fromCharCode(bytes[i]);
+        }
+        return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+    }
+
+    function descriptors(list) {
+        return (list || []).map(function (item) {
+            return { type: item.type, id: toBuffer(item.id) };
+        });
+    }
+
+    function post(url, csrf, body) {
+        return fetch(url, {
+            method: 'POST',
+            credentials: 'same-origin',
+            headers: {
+                'Accept': 'application/json',
+                'Content-Type': 'application/json',
+                'X-CSRF-Token': csrf
+            },
+            body: JSON.stringify(body || {})
+        }).then(function (response) {
+            return response.json().then(function (data) {
+                if (!response.ok) {
+                    throw new Error(data.error || data.message || response.statusText);
+                }
+                return data;
+            });
+        });
+    }
+
+    function showError(message) {
+        var alert = document.getElementById('passkey-error');
+        if (alert) {
+            alert.textContent = message;
+            alert.classList.remove('d-none');
+        }
+    }
+
+    function supported(element) {
+        if (window.PublicKeyCredential && navigator.credentials) {
+            return true;
+        }
+        showError(element.dataset.unsupported);
+        return false;
+    }
+
+    function register(form) {
+        var csrf = form.dataset.csrf;
+        var name = form.querySelector('[name="name"]').value;
+
+        post('/profile/passkeys/options', csrf).then(function (data) {
+            var options = data.publicKey;
+            options.challenge = toBuffer(options.challenge);
+            options.user.id = toBuffer(options.user.id);
+            options.excludeCredentials = descriptors(options.excludeCredentials);
+            return navigator.credentials.create({ publicKey: options });
+        }).then(function (credential) {
+            return post('/profile/passkeys', csrf, {
+                name: name,
+                credential: {
+                    id: credential.id,
+                    rawId: toBase64URL(credential.rawId),
+                    type: credential.type,
+                    response: {
+                        clientDataJSON: toBase64URL(credential.response.clientDataJSON),
+                        attestationObject: toBase64URL(credential.response.attestationObject)
+                    }
+                }
+            });
+        }).then(function (data) {
+            window.location.href = data.redirect;
+        }).catch(function (error) {
+            showError(error.message);
+        });
+    }
+
+    function login(button) {
+        var csrf = button.dataset.csrf;
+
+        post('/auth/passkey/options', csrf).then(function (data) {
+            var options = data.publicKey;
+            options.challenge = toBuffer(options.challenge);
+            options.allowCredentials = descriptors(options.allowCredentials);
+            return navigator.credentials.get({ publicKey: options });
+        }).then(function (credential) {
+            return post('/auth/passkey', csrf, {
+                id: credential.id,
+                rawId: toBase64URL(credential.rawId),
+                type: credential.type,
+                response: {
+                    clientDataJSON: toBase64URL(credential.response.clientDataJSON),
+                    authenticatorData: toBase64URL(credential.response.authenticatorData),
+                    signature: toBase64URL(credential.response.signature),
+                    userHandle: toBase64URL(credential.response.userHandle)
+                }
+            });
+        }).then(function (data) {
+            window.location.href = data.redirect;
+        }).catch(function (error) {
+            showError(error.message);
+        });
+    }
+
+    var form = document.getElementById('passkey-register');
+    if (form) {
+        form.addEventListener('submit', function (event) {
+            event.preventDefault();
+            if (supported(form)) {
+                register(form);
+            }
+        });
+    }
+
+    var button = document.getElementById('passkey-login');
+    if (button) {
+        button.addEventListener('click', function () {
+            if (supported(button)) {
+                login(button);
+            }
+        });
+    }
+})();
@@ -14,58 +14,79 @@ func Api(router *gin.RouterGroup) {
 	permissionController := &base.PermissionController{}
 	userPermissionController := &base.UserPermissionController{}
 
-	// Auth routes
-	router.POST("/auth/login", auth.Login)
-	router.POST("/auth/register", auth.Register)
+	// Auth routes; las rutas públicas se limitan por IP
+	router.POST("/auth/login", middleware.RateLimit("auth"), auth.Login)
+	router.POST("/auth/register", middleware.RateLimit("auth"), auth.Register)
 	router.POST("/auth/logout", middleware.AuthMiddleware(), auth.Logout)
-	router.POST("/auth/forgot-password", auth.ForgotPassword)
-	router.POST("/auth/reset-password", auth.ResetPassword)
+	router.POST("/auth/forgot-password", middleware.RateLimit("auth"), auth.ForgotPassword)
+	router.POST("/auth/reset-password", middleware.RateLimit("auth"), auth.ResetPassword)
 	router.POST("/auth/email/resend", middleware.AuthMiddleware(), auth.ResendEmailVerify)
-	router.GET("/auth/email/verify/:id/:hash", auth.VerifyEmail)
+	router.GET("/auth/email/verify/:id/:hash", middleware.RateLimit("auth"), middleware.ValidSignature(), auth.VerifyEmail)
 	router.POST("/auth/refresh-token", middleware.AuthMiddleware(), auth.RefreshToken)
 
-	// Rutas protegidas con autenticación
+	// Rutas protegidas con autenticación y email verificado; cada ruta declara los scopes que exige
+	// al token
 	protected := router.Group("/")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(), middleware.RequireVerifiedEmailApi(), middleware.RateLimit("api"))
 	{
+		// Tokens de acceso personal del usuario autenticado
+		tokens := protected.Group("/personal-access-tokens")
+		{
+			tokens.GET("/", middleware.RequireScopes("tokens:read"), auth.PersonalAccessTokenIndex)
+			tokens.POST("/", middleware.RequireScopes("tokens:write"), auth.PersonalAccessTokenStore)
+			tokens.DELETE("/:id", middleware.RequireScopes("tokens:write"), auth.PersonalAccessTokenDestroy)
+		}
+
+		// Sesiones web y tokens activos del usuario autenticado
+		sessions := protected.Group("/sessions")
+		{
+			sessions.GET("/", middleware.RequireScopes("sessions:read"), auth.ActiveSessionIndex)
+			sessions.DELETE("/", middleware.RequireScopes("sessions:write"), auth.ActiveSessionDestroyAll)
+			sessions.DELETE("/web/:id", middleware.RequireScopes("sessions:write"), auth.ActiveSessionDestroy)
+			sessions.DELETE("/tokens/:id", middleware.RequireScopes("sessions:write"), auth.ActiveTokenDestroy)
+		}
+
 		// Rutas de roles
 		roles := protected.Group("/roles")
 		{
-			roles.GET("/", roleController.Index)
-			roles.GET("/:id", roleController.Show)
-			roles.POST("/", middleware.RequirePermission("create-roles"), roleController.Store)
-			roles.PUT("/:id", middleware.RequirePermission("edit-roles"), roleController.Update)
-			roles.DELETE("/:id", middleware.RequirePermission("delete-roles"), roleController.Delete)
-			roles.POST("/assign-user", middleware.RequirePermission("assign-roles"), roleController.AssignToUser)
-			roles.POST("/revoke-user", middleware.RequirePermission("assign-roles"), roleController.RevokeFromUser)
-			roles.GET("/user/:user_id", roleController.GetUserRoles)
+			roles.GET("/", middleware.RequireScopes("roles:read"), roleController.Index)
+			roles.GET("/:id", middleware.RequireScopes("roles:read"), roleController.Show)
+			roles.POST("/", middleware.RequireScopes("roles:write"), middleware.RequirePermission("create-roles"), roleController.Store)
+			roles.PUT("/:id", middleware.RequireScopes("roles:write"), middleware.RequirePermission("edit-roles"), roleController.Update)
+			roles.DELETE("/:id", middleware.RequireScopes("roles:write"), middleware.RequirePermission("delete-roles"), roleController.Delete)
+			roles.POST("/assign-user", middleware.RequireScopes("roles:write"), middleware.RequirePermission("assign-roles"), roleController.AssignToUser)
+			roles.POST("/revoke-user", middleware.RequireScopes("roles:write"), middleware.RequirePermission("assign-roles"), roleController.RevokeFromUser)
+			roles.GET("/user/:user_id", middleware.RequireScopes("roles:read"), roleController.GetUserRoles)
+			roles.GET("/:id/parents", middleware.RequireScopes("roles:read"), roleController.GetParents)
+			roles.POST("/assign-parent", middleware.RequireScopes("roles:write"), middleware.RequirePermission("edit-roles"), roleController.AssignParent)
+			roles.POST("/revoke-parent", middleware.RequireScopes("roles:write"), middleware.RequirePermission("edit-roles"), roleController.RevokeParent)
 		}
 
 		// Rutas de permisos
 		permissions := protected.Group("/permissions")
 		{
-			permissions.GET("/", permissionController.Index)
-			permissions.GET("/:id", permissionController.Show)
-			permissions.POST("/", middleware.RequirePermission("create-permissions"), permissionController.Store)
-			permissions.PUT("/:id", middleware.RequirePermission("edit-permissions"), permissionController.Update)
-			permissions.DELETE("/:id", middleware.RequirePermission("delete-permissions"), permissionController.Delete)
-			permissions.POST("/assign-user", middleware.RequirePermission("assign-permissions"), permissionController.AssignToUser)
-			permissions.POST("/assign-role", middleware.RequirePermission("assign-permissions"), permissionController.AssignToRole)
-			permissions.POST("/revoke-user", middleware.RequirePermission("assign-permissions"), permissionController.RevokeFromUser)
-			permissions.POST("/revoke-role", middleware.RequirePermission("assign-permissions"), permissionController.RevokeFromRole)
-			permissions.GET("/user/:user_id", permissionController.GetUserPermissions)
-			permissions.GET("/role/:role_id", permissionController.GetRolePermissions)
+			permissions.GET("/", middleware.RequireScopes("permissions:read"), permissionController.Index)
+			permissions.GET("/:id", middleware.RequireScopes("permissions:read"), permissionController.Show)
+			permissions.POST("/", middleware.RequireScopes("permissions:write"), middleware.RequirePermission("create-permissions"), permissionController.Store)
+			permissions.PUT("/:id", middleware.RequireScopes("permissions:write"), middleware.RequirePermission("edit-permissions"), permissionController.Update)
+			permissions.DELETE("/:id", middleware.RequireScopes("permissions:write"), middleware.RequirePermission("delete-permissions"), permissionController.Delete)
+			permissions.POST("/assign-user", middleware.RequireScopes("permissions:write"), middleware.RequirePermission("assign-permissions"), permissionController.AssignToUser)
+			permissions.POST("/assign-role", middleware.RequireScopes("permissions:write"), middleware.RequirePermission("assign-permissions"), permissionController.AssignToRole)
+			permissions.POST("/revoke-user", middleware.RequireScopes("permissions:write"), middleware.RequirePermission("assign-permissions"), permissionController.RevokeFromUser)
+			permissions.POST("/revoke-role", middleware.RequireScopes("permissions:write"), middleware.RequirePermission("assign-permissions"), permissionController.RevokeFromRole)
+			permissions.GET("/user/:user_id", middleware.RequireScopes("permissions:read"), permissionController.GetUserPermissions)
+			permissions.GET("/role/:role_id", middleware.RequireScopes("permissions:read"), permissionController.GetRolePermissions)
 		}
 
 		// Rutas de verificación de permisos
 		userPerms := protected.Group("/user-permissions")
 		{
-			userPerms.GET("/user/:user_id", userPermissionController.CheckUserPermissions)
-			userPerms.GET("/current-user", userPermissionController.CheckCurrentUserPermissions)
-			userPerms.GET("/user/:user_id/check-role", userPermissionController.CheckRole)
-			userPerms.GET("/user/:user_id/check-permission", userPermissionController.CheckPermission)
-			userPerms.GET("/current-user/check-role", userPermissionController.CheckCurrentUserRole)
-			userPerms.GET("/current-user/check-permission", userPermissionController.CheckCurrentUserPermission)
+			userPerms.GET("/user/:user_id", middleware.RequireScopes("users:read"), userPermissionController.CheckUserPermissions)
+			userPerms.GET("/current-user", middleware.RequireScopes("users:read"), userPermissionController.CheckCurrentUserPermissions)
+			userPerms.GET("/user/:user_id/check-role", middleware.RequireScopes("users:read"), userPermissionController.CheckRole)
+			userPerms.GET("/user/:user_id/check-permission", middleware.RequireScopes("users:read"), userPermissionController.CheckPermission)
+			userPerms.GET("/current-user/check-role", middleware.RequireScopes("users:read"), userPermissionController.CheckCurrentUserRole)
+			userPerms.GET("/current-user/check-permission", middleware.RequireScopes("users:read"), userPermissionController.CheckCurrentUserPermission)
 		}
 	}
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"semita/app/http/controllers/oauth"
+	"semita/app/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuth registra los endpoints estándar de OAuth2 / OpenID Connect en la raíz del servidor
+func OAuth(router *gin.Engine) {
+	router.POST("/oauth/token", oauth.IssueToken)
+	router.GET("/oauth/userinfo", middleware.AuthMiddleware(), middleware.RequireScopes("openid"), oauth.UserInfo)
+	router.POST("/oauth/userinfo", middleware.AuthMiddleware(), middleware.RequireScopes("openid"), oauth.UserInfo)
+
+	router.GET("/.well-known/openid-configuration", oauth.OpenIDConfiguration)
+	router.GET("/.well-known/jwks.json", oauth.JWKS)
+}
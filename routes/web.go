@@ -3,6 +3,7 @@ package routes
 import (
 	"semita/app/http/controllers/web"
 	"semita/app/http/middleware"
+	"semita/app/structs"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,7 +12,11 @@ func Web() *gin.Engine {
 	router := gin.Default()
 
 	// IMPORTANTE: El middleware debe estar ANTES de todas las rutas
-	router.Use(middleware.MethodOverride(), middleware.LanguageMiddleware())
+	router.Use(middleware.VerifyCsrfToken(), middleware.MethodOverride(), middleware.LanguageMiddleware(), middleware.AuthenticateViaRemember(), middleware.AuthenticateSession(), middleware.EnsureTwoFactorEnrolled())
+
+	// Las rutas de la aplicación exigen un email verificado; las de autenticación y las de seguridad
+	// de la cuenta (sesiones, 2FA, passkeys y cuentas vinculadas) solo exigen la sesión
+	verified := middleware.RequireVerifiedEmail(func(c *gin.Context) { c.Next() })
 
 	// Ahora define todas las rutas
 	router.GET("/", web.HomeIndex)
@@ -26,49 +31,98 @@ func Web() *gin.Engine {
 	router.POST("/auth/forgot-password", web.AuthForgotPasswordPost)
 	router.GET("/auth/reset-password", web.AuthResetPassword)
 	router.POST("/auth/reset-password", web.AuthResetPasswordPost)
+	router.GET("/auth/email/verify", middleware.RequireAuth(web.AuthVerifyEmailNotice))
+	router.POST("/auth/email/resend", middleware.RequireAuth(web.AuthVerifyEmailResend))
+	router.GET("/auth/two-factor-challenge", middleware.RedirectGuest(web.TwoFactorChallenge))
+	router.POST("/auth/two-factor-challenge", middleware.RedirectGuest(web.TwoFactorChallengePost))
+	router.POST("/auth/passkey/options", middleware.RedirectGuest(web.PasskeyLoginOptions))
+	router.POST("/auth/passkey", middleware.RedirectGuest(web.PasskeyLogin))
+	router.GET("/auth/social/:provider", web.SocialRedirect)
+	router.GET("/auth/social/:provider/callback", web.SocialCallback)
 
 	// General routes
-	router.GET("/nosotros", middleware.RequireAuth(web.Nosotros))
-	router.GET("/parametros/:id/:slug", middleware.RequireAuth(web.Parametros))
-	router.GET("/querystring", middleware.RequireAuth(web.QueryString))
-	router.GET("/estructuras", middleware.RequireAuth(web.Estructuras))
+	router.GET("/nosotros", middleware.RequireVerifiedEmail(web.Nosotros))
+	router.GET("/parametros/:id/:slug", middleware.RequireVerifiedEmail(web.Parametros))
+	router.GET("/querystring", middleware.RequireVerifiedEmail(web.QueryString))
+	router.GET("/estructuras", middleware.RequireVerifiedEmail(web.Estructuras))
 
 	// Form routes
-	router.GET("/formulario", middleware.RequireAuth(web.FormulariosGet))
-	router.POST("/formulario-post", middleware.RequireAuth(web.FormulariosPost))
+	router.GET("/formulario", middleware.RequireVerifiedEmail(web.FormulariosGet))
+	router.POST("/formulario-post", middleware.RequireVerifiedEmail(web.FormulariosPost))
 
 	// Utility routes
-	router.GET("/pdf", middleware.RequireAuth(web.IndexPDF))
-	router.GET("/pdf/new", middleware.RequireAuth(web.GenerateNewPDF))
-	router.GET("/excel", middleware.RequireAuth(web.IndexExcel))
-	router.GET("/excel/new", middleware.RequireAuth(web.GenerateNewExcel))
-	router.GET("/qr", middleware.RequireAuth(web.IndexQR))
-	router.GET("/qr/new", middleware.RequireAuth(web.GenerateNewQR))
-	router.GET("/email", middleware.RequireAuth(web.IndexSendEmail))
-	router.GET("/email/new", middleware.RequireAuth(web.GenerateNewEmail))
-
-	router.GET("/dummyjson", middleware.RequireAuth(web.DummyApiIndex))
-	router.GET("/dummyjson/users/create", middleware.RequireAuth(web.DummyApiCreate))
-	router.POST("/dummyjson/users/store", middleware.RequireAuth(web.DummyApiStore))
-	router.GET("/dummyjson/users/show/:id", middleware.RequireAuth(web.DummyApiShow))
-	router.GET("/dummyjson/users/edit/:id", middleware.RequireAuth(web.DummyApiEdit))
-	router.POST("/dummyjson/users/update/:id", middleware.RequireAuth(web.DummyApiUpdate))
-	router.POST("/dummyjson/users/delete/:id", middleware.RequireAuth(web.DummyApiDelete))
-
-	router.GET("/users", middleware.RequireAuth(web.UserIndex))
-	router.GET("/users/create", middleware.RequireAuth(web.UserCreate))
-	router.POST("/users/store", middleware.RequireAuth(web.UserStore))
-	router.GET("/users/show/:id", middleware.RequireAuth(web.UserShow))
-	router.GET("/users/edit/:id", middleware.RequireAuth(web.UserEdit))
-	router.POST("/users/update/:id", middleware.RequireAuth(web.UserUpdate))
-	router.POST("/users/delete/:id", middleware.RequireAuth(web.UserDelete))
+	router.GET("/pdf", middleware.RequireVerifiedEmail(web.IndexPDF))
+	router.GET("/pdf/new", middleware.RequireVerifiedEmail(web.GenerateNewPDF))
+	router.GET("/excel", middleware.RequireVerifiedEmail(web.IndexExcel))
+	router.GET("/excel/new", middleware.RequireVerifiedEmail(web.GenerateNewExcel))
+	router.GET("/qr", middleware.RequireVerifiedEmail(web.IndexQR))
+	router.GET("/qr/new", middleware.RequireVerifiedEmail(web.GenerateNewQR))
+	router.GET("/files/:name", middleware.ValidRelativeSignature(), web.FileDownload)
+	router.GET("/email", middleware.RequireVerifiedEmail(web.IndexSendEmail))
+	router.GET("/email/new", middleware.RequireVerifiedEmail(web.GenerateNewEmail))
+
+	router.GET("/dummyjson", middleware.RequireVerifiedEmail(web.DummyApiIndex))
+	router.GET("/dummyjson/users/create", middleware.RequireVerifiedEmail(web.DummyApiCreate))
+	router.POST("/dummyjson/users/store", middleware.RequireVerifiedEmail(web.DummyApiStore))
+	router.GET("/dummyjson/users/show/:id", middleware.RequireVerifiedEmail(web.DummyApiShow))
+	router.GET("/dummyjson/users/edit/:id", middleware.RequireVerifiedEmail(web.DummyApiEdit))
+	router.POST("/dummyjson/users/update/:id", middleware.RequireVerifiedEmail(web.DummyApiUpdate))
+	router.POST("/dummyjson/users/delete/:id", middleware.RequireVerifiedEmail(web.DummyApiDelete))
+
+	// Usuarios - autorizados por UserPolicy; cada usuario puede ver y editar su propia cuenta
+	router.GET("/users", verified, middleware.Can("view-any", middleware.BindModel(structs.UserStruct{})), web.UserIndex)
+	router.GET("/users/create", verified, middleware.Can("create", middleware.BindModel(structs.UserStruct{})), web.UserCreate)
+	router.POST("/users/store", verified, middleware.Can("create", middleware.BindModel(structs.UserStruct{})), web.UserStore)
+	router.GET("/users/show/:id", verified, middleware.Can("view", middleware.BindUser("id")), web.UserShow)
+	router.GET("/users/edit/:id", verified, middleware.Can("update", middleware.BindUser("id")), web.UserEdit)
+	router.POST("/users/update/:id", verified, middleware.Can("update", middleware.BindUser("id")), web.UserUpdate)
+	router.POST("/users/delete/:id", verified, middleware.Can("delete", middleware.BindUser("id")), web.UserDelete)
+
+	// Tokens de acceso personal
+	router.GET("/profile/tokens", middleware.RequireVerifiedEmail(web.PersonalAccessTokenIndex))
+	router.POST("/profile/tokens/store", middleware.RequireVerifiedEmail(web.PersonalAccessTokenStore))
+	router.POST("/profile/tokens/delete/:id", middleware.RequireVerifiedEmail(web.PersonalAccessTokenDelete))
+
+	// Sesiones y dispositivos
+	router.GET("/profile/sessions", middleware.RequireAuth(web.ActiveSessionIndex))
+	router.POST("/profile/sessions/delete/:id", middleware.RequireAuth(web.ActiveSessionDelete))
+	router.POST("/profile/sessions/tokens/delete/:id", middleware.RequireAuth(web.ActiveTokenDelete))
+	router.POST("/profile/sessions/logout-all", middleware.RequireAuth(web.ActiveSessionLogoutAll))
+
+	// Autenticación en dos pasos
+	router.GET("/profile/two-factor", middleware.RequireAuth(web.TwoFactorIndex))
+	router.POST("/profile/two-factor/enable", middleware.RequireAuth(web.TwoFactorEnable))
+	router.POST("/profile/two-factor/confirm", middleware.RequireAuth(web.TwoFactorConfirm))
+	router.POST("/profile/two-factor/recovery-codes", middleware.RequireAuth(web.TwoFactorRecoveryCodes))
+	router.POST("/profile/two-factor/disable", middleware.RequireAuth(web.TwoFactorDisable))
+
+	// Passkeys (WebAuthn)
+	router.GET("/profile/passkeys", middleware.RequireAuth(web.PasskeyIndex))
+	router.POST("/profile/passkeys/options", middleware.RequireAuth(web.PasskeyCreateOptions))
+	router.POST("/profile/passkeys", middleware.RequireAuth(web.PasskeyStore))
+	router.POST("/profile/passkeys/delete/:id", middleware.RequireAuth(web.PasskeyDelete))
+
+	// Cuentas externas vinculadas
+	router.GET("/profile/identities", middleware.RequireAuth(web.SocialIdentities))
+	router.POST("/profile/identities/delete/:id", middleware.RequireAuth(web.SocialIdentityDelete))
+
+	// Equipos - el equipo de la ruta fija el contexto de roles y permisos de la petición
+	router.GET("/teams", middleware.RequireVerifiedEmail(web.TeamIndex))
+	router.POST("/teams/store", middleware.RequireVerifiedEmail(web.TeamStore))
+	router.POST("/teams/switch/:id", middleware.RequireVerifiedEmail(web.TeamSwitch))
+	router.GET("/teams/show/:id", verified, middleware.ResolveTeam("id"), middleware.Can("view", middleware.BindTeam("id")), web.TeamShow)
+	router.POST("/teams/invite/:id", verified, middleware.ResolveTeam("id"), middleware.Can("manage", middleware.BindTeam("id")), web.TeamInvite)
+	router.POST("/teams/invitations/delete/:id/:invitation", verified, middleware.ResolveTeam("id"), middleware.Can("manage", middleware.BindTeam("id")), web.TeamInvitationDelete)
+	router.POST("/teams/members/delete/:id/:user", verified, middleware.ResolveTeam("id"), middleware.Can("manage", middleware.BindTeam("id")), web.TeamMemberDelete)
+	router.POST("/teams/delete/:id", verified, middleware.ResolveTeam("id"), middleware.Can("delete", middleware.BindTeam("id")), web.TeamDelete)
+	router.GET("/teams/invitations/accept/:token", middleware.RequireVerifiedEmail(web.TeamInvitationAccept))
 
 	// Inicializar controlador administrativo
 	adminController := &web.AdminController{}
 
 	// Rutas administrativas protegidas con roles y permisos
 	admin := router.Group("/admin")
-	admin.Use(middleware.RequireAuth(func(c *gin.Context) { c.Next() }))
+	admin.Use(verified)
 	{
 		// Dashboard principal - requiere permiso para ver dashboard
 		admin.GET("/", middleware.RequirePermission("view-dashboard"), adminController.Dashboard)